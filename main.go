@@ -2,12 +2,23 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"kspm/pkg/audit"
 	"kspm/pkg/controlchecks"
 	"kspm/pkg/entity"
+	"kspm/pkg/findings"
 	"kspm/pkg/k8s"
+	"kspm/pkg/manifest"
+	"kspm/pkg/netpol"
+	"kspm/pkg/podsecurity"
+	"kspm/pkg/policy"
+	"kspm/pkg/preflight"
 	"kspm/pkg/reports"
 	"kspm/pkg/riskposture"
+	"kspm/pkg/rules"
+	"kspm/pkg/suppress"
 	"kspm/pkg/trivytypes"
 	"log"
 	"os"
@@ -16,24 +27,39 @@ import (
 	"strings"
 	"syscall"
 	"text/tabwriter"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	psaapi "k8s.io/pod-security-admission/api"
 	// Adjust this import to match your project's structure
 )
 
 func initClient() (*kubernetes.Clientset, error) {
-	var config *rest.Config
-	var err error
+	config, err := restConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
 
+	return clientset, nil
+}
+
+// restConfig loads the in-cluster configuration, falling back to the local kubeconfig
+func restConfig() (*rest.Config, error) {
 	// Try to use the in-cluster configuration (if exists)
-	config, err = rest.InClusterConfig()
+	config, err := rest.InClusterConfig()
 	if err != nil {
 		// If in-cluster configuration does not exist, try to use the local configuration
 		kubeconfig := os.Getenv("KUBECONFIG")
@@ -45,13 +71,7 @@ func initClient() (*kubernetes.Clientset, error) {
 			return nil, fmt.Errorf("failed to build config: %w", err)
 		}
 	}
-
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create client: %w", err)
-	}
-
-	return clientset, nil
+	return config, nil
 }
 
 var (
@@ -59,12 +79,22 @@ var (
 	watchDeploymentsFlag  bool
 	watchSecretsFlag      bool
 	watchClusterRolesFlag bool
+	watchWorkloadsFlag    bool
 	watchFlag             bool
 	//checkFlag             bool
 	deploymentFlag bool
 	riskFlag       bool
 	rbacFlag       bool
 	namespace      string
+	disabledRules  []string
+	outputFormat   string
+	failOn         string
+	maxScore       int
+	ciGate         reports.Gate
+	ignoreFile     string
+	suppressions   *suppress.File
+	policyFile     string
+	activePolicy   = policy.Default()
 	rootCmd        = &cobra.Command{
 		Use:   "paranoia",
 		Short: "Paranoia is a tool for monitoring and securing Kubernetes clusters",
@@ -79,11 +109,19 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&watchDeploymentsFlag, "watch-deployments", false, "Watch Deployments")
 	rootCmd.PersistentFlags().BoolVar(&watchSecretsFlag, "watch-secrets", false, "Watch Secrets")
 	rootCmd.PersistentFlags().BoolVar(&watchClusterRolesFlag, "watch-clusterroles", false, "Watch ClusterRoles")
+	rootCmd.PersistentFlags().BoolVar(&watchWorkloadsFlag, "watch-workloads", false, "Watch StatefulSets, DaemonSets, ReplicaSets, Jobs and CronJobs")
 	//rootCmd.PersistentFlags().BoolVarP(&checkFlag, "check", "c", false, "Run control checks")
 	rootCmd.PersistentFlags().BoolVarP(&deploymentFlag, "deployment", "d", false, "Run deployment checks")
 	rootCmd.PersistentFlags().BoolVarP(&riskFlag, "risk", "r", false, "Run risk checks")
 	rootCmd.PersistentFlags().BoolVarP(&rbacFlag, "rbac", "b", false, "Run RBAC checks")
 	rootCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "", "The name of the image to scan")
+	rootCmd.PersistentFlags().StringSliceVar(&disabledRules, "disable-rules", nil, "Comma-separated rule IDs to skip (see 'paranoia rules')")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", reports.FormatTable, "Output format: table, json, ndjson, sarif or junit")
+	rootCmd.PersistentFlags().StringVar(&failOn, "fail-on", "", "Exit with code 2 if any finding is at or above this severity (CRITICAL, HIGH, MEDIUM, LOW, INFO)")
+	rootCmd.PersistentFlags().IntVar(&maxScore, "max-score", -1, "Exit with code 2 if the risk score exceeds this value (disabled when negative)")
+	rootCmd.PersistentFlags().StringVar(&ignoreFile, "ignore-file", suppress.DefaultFile, "Suppression file of accepted risks (read when present)")
+	rootCmd.PersistentFlags().StringVar(&policyFile, "policy", policy.DefaultFile, "Policy file overriding rule severities, weights and thresholds (read when present)")
+	rootCmd.PersistentPreRunE = applyGlobalFlags
 
 	rootCmd.AddCommand(createWatchCmd())
 	rootCmd.AddCommand(createCheckCmd())
@@ -91,6 +129,462 @@ func init() {
 	rootCmd.AddCommand(createRbacCmd())
 	rootCmd.AddCommand(reportCmd())
 	rootCmd.AddCommand(reportHTMLCmd())
+	rootCmd.AddCommand(createRulesCmd())
+	rootCmd.AddCommand(createScanCmd())
+	rootCmd.AddCommand(createPSSCmd())
+	rootCmd.AddCommand(createPSACmd())
+	rootCmd.AddCommand(createNetpolCmd())
+	rootCmd.AddCommand(createWhoCanCmd())
+	rootCmd.AddCommand(createWhoAmICmd())
+	rootCmd.AddCommand(createDoctorCmd())
+	rootCmd.AddCommand(createAuditCmd())
+}
+
+// Exit codes shared by the scanning commands
+const (
+	exitError      = 1 // the scan could not run
+	exitGateFailed = 2 // --fail-on or --max-score was exceeded
+)
+
+// applyGlobalFlags validates --output, --fail-on and --max-score, loads the
+// --policy and --ignore-file files and disables the rules passed through
+// --disable-rules
+func applyGlobalFlags(cmd *cobra.Command, args []string) error {
+	format, err := reports.ParseOutputFormat(outputFormat)
+	if err != nil {
+		return err
+	}
+	outputFormat = format
+
+	ciGate = reports.Gate{MaxScore: maxScore}
+	if failOn != "" {
+		sev := findings.ParseSeverity(failOn)
+		if sev == findings.SeverityInfo && !strings.EqualFold(strings.TrimSpace(failOn), string(findings.SeverityInfo)) {
+			return fmt.Errorf("unknown severity %q for --fail-on", failOn)
+		}
+		ciGate.FailOn = sev
+	}
+
+	// The default files are optional; explicit --policy and --ignore-file paths must exist
+	if _, err := os.Stat(policyFile); err == nil || cmd.Flag("policy").Changed {
+		p, err := policy.Load(policyFile)
+		if err != nil {
+			return err
+		}
+		if err := p.Apply(); err != nil {
+			return err
+		}
+		activePolicy = p
+	}
+
+	suppressions = nil
+	if _, err := os.Stat(ignoreFile); err == nil || cmd.Flag("ignore-file").Changed {
+		file, err := suppress.Load(ignoreFile)
+		if err != nil {
+			return err
+		}
+		for _, rule := range file.Expired(time.Now()) {
+			fmt.Fprintf(os.Stderr, "Warning: suppression %q expired on %s; matching findings are reported again\n", rule.Justification, rule.Expires)
+		}
+		suppressions = file
+	}
+
+	for _, id := range disabledRules {
+		if err := rules.Disable(strings.TrimSpace(id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// postureView applies the rule overrides and suppressions, then scores the
+// remaining findings
+func postureView(title string, list []findings.Finding, signals []riskposture.Signal) reports.ReportView {
+	kept, suppressed := suppressions.Apply(rules.Apply(list), time.Now())
+	view := reports.BuildPostureView(title, kept, signals)
+	view.Suppressed = reports.SuppressedFindings(suppressed)
+	return view
+}
+
+// writeReport prints the view in a machine-readable format and exits on failure
+func writeReport(cmd *cobra.Command, format string, view reports.ReportView) {
+	if err := reports.WriteView(cmd.OutOrStdout(), format, view); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write %s output: %v\n", format, err)
+		os.Exit(exitError)
+	}
+}
+
+// enforceGate exits with exitGateFailed when the view breaks --fail-on or --max-score
+func enforceGate(view reports.ReportView) {
+	reasons := ciGate.Evaluate(view)
+	if len(reasons) == 0 {
+		return
+	}
+	for _, reason := range reasons {
+		fmt.Fprintf(os.Stderr, "Gate failed: %s\n", reason)
+	}
+	os.Exit(exitGateFailed)
+}
+
+// createRulesCmd lists the registered rules
+func createRulesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rules",
+		Short: "List the registered security rules",
+		Long:  `Lists every registered rule with its ID, resource kinds, severity and whether it is enabled.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tKINDS\tSEVERITY\tENABLED\tDESCRIPTION")
+			for _, rule := range rules.All() {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\n",
+					rule.ID,
+					strings.Join(rule.Kinds, ","),
+					rule.Severity,
+					rules.Default.Enabled(rule.ID),
+					rule.Description)
+			}
+			w.Flush()
+		},
+	}
+}
+
+// createScanCmd scans manifests on disk without a cluster
+func createScanCmd() *cobra.Command {
+	var paths, helmCharts, valuesFiles, kustomizeDirs []string
+	var releaseName string
+	var scanCmd = &cobra.Command{
+		Use:   "scan",
+		Short: "Scan Kubernetes manifests offline",
+		Long: `Scans YAML and JSON manifests from files and directories with the same checks used against a live cluster.
+Helm charts and Kustomize overlays are rendered in-process and scanned as well.`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(paths) == 0 && len(helmCharts) == 0 && len(kustomizeDirs) == 0 {
+				return fmt.Errorf("at least one input is required (-f, --helm-chart or --kustomize)")
+			}
+			return nil
+		},
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Keep per-check console output quiet; findings are collected from the check results
+			k8s.SetSecurityEventHandler(&k8s.RecordingSecurityEventHandler{})
+
+			objects, errs := manifest.Load(paths)
+			for _, chart := range helmCharts {
+				rendered, renderErrs := manifest.RenderHelm(chart, manifest.HelmOptions{
+					ValuesFiles: valuesFiles,
+					ReleaseName: releaseName,
+					Namespace:   namespace,
+				})
+				objects = append(objects, rendered...)
+				errs = append(errs, renderErrs...)
+			}
+			for _, dir := range kustomizeDirs {
+				rendered, renderErrs := manifest.RenderKustomize(dir)
+				objects = append(objects, rendered...)
+				errs = append(errs, renderErrs...)
+			}
+			for _, err := range errs {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+			result := manifest.Scan(objects)
+
+			view := postureView("Manifest Scan", result.Findings, nil)
+			view.Resources = result.Resources
+
+			if outputFormat != reports.FormatTable {
+				writeReport(cmd, outputFormat, view)
+				enforceGate(view)
+				return
+			}
+
+			out := cmd.OutOrStdout()
+			for _, f := range view.Findings {
+				location := ""
+				if f.Location != nil && f.Location.Line > 0 {
+					location = fmt.Sprintf("%s:%d ", f.Location.File, f.Location.Line)
+				} else if f.Location != nil {
+					// Rendered templates have no meaningful line
+					location = f.Location.File + " "
+				}
+				fmt.Fprintf(out, "%s%s %s %s: %s\n", location, severityColor(f.Severity).Sprintf("[%s]", f.Severity), f.RuleID, f.Resource, f.Message)
+			}
+			fmt.Fprintf(out, "\nScanned %d objects (%d skipped): %d findings (%d suppressed), risk score %d/100\n",
+				len(objects), len(result.Skipped), len(view.Findings), len(view.Suppressed), view.RiskScore)
+			enforceGate(view)
+		},
+	}
+	scanCmd.Flags().StringSliceVarP(&paths, "filename", "f", nil, "Manifest file or directory to scan (repeatable)")
+	scanCmd.Flags().StringSliceVar(&helmCharts, "helm-chart", nil, "Helm chart directory to render and scan (repeatable)")
+	scanCmd.Flags().StringSliceVar(&valuesFiles, "values", nil, "Values file applied to every --helm-chart (repeatable, later files win)")
+	scanCmd.Flags().StringVar(&releaseName, "release", "paranoia", "Release name used when rendering --helm-chart")
+	scanCmd.Flags().StringSliceVar(&kustomizeDirs, "kustomize", nil, "Kustomization directory to build and scan (repeatable)")
+	return scanCmd
+}
+
+// createPSSCmd evaluates workloads against the Pod Security Standards
+func createPSSCmd() *cobra.Command {
+	var paths []string
+	var pssCmd = &cobra.Command{
+		Use:   "pss",
+		Short: "Evaluate workloads against the Pod Security Standards",
+		Long: `Evaluates every Pod and workload template against the baseline and restricted Pod Security Standards,
+reporting the most restrictive profile each workload satisfies and every control it violates.
+Reads the cluster (limited by -n) or, with -f, manifests on disk.`,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			var workloads []podsecurity.Workload
+			if len(paths) > 0 {
+				objects, errs := manifest.Load(paths)
+				for _, err := range errs {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				}
+				for _, o := range objects {
+					if w, ok := podsecurity.WorkloadFromObject(o.Object); ok {
+						workloads = append(workloads, w)
+					}
+				}
+			} else {
+				clientset, err := initClient()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error initializing Kubernetes client: %v\n", err)
+					os.Exit(exitError)
+				}
+				var errs []error
+				workloads, errs = podsecurity.ListWorkloads(cmd.Context(), clientset, namespace)
+				for _, err := range errs {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				}
+			}
+
+			var pssFindings []findings.Finding
+			var scanned []findings.Resource
+			results := make([]podsecurity.Result, len(workloads))
+			for i, w := range workloads {
+				results[i] = w.Evaluate()
+				pssFindings = append(pssFindings, podsecurity.Findings(w.Resource, results[i])...)
+				scanned = append(scanned, w.Resource)
+			}
+			view := postureView("Pod Security Standards", pssFindings, nil)
+			view.Resources = scanned
+
+			if outputFormat != reports.FormatTable {
+				writeReport(cmd, outputFormat, view)
+				enforceGate(view)
+				return
+			}
+
+			out := cmd.OutOrStdout()
+			levels := map[string]int{}
+			for i, w := range workloads {
+				level := string(results[i].Level)
+				levels[level]++
+				fmt.Fprintf(out, "%s %s\n", pssLevelColor(level).Sprintf("%-10s", level), w.Resource)
+				for _, v := range results[i].Violations {
+					fmt.Fprintf(out, "    [%s] %s\n", v.Level, v)
+				}
+			}
+			fmt.Fprintf(out, "\nEvaluated %d workloads: %d restricted, %d baseline, %d privileged\n",
+				len(workloads), levels["restricted"], levels["baseline"], levels["privileged"])
+			enforceGate(view)
+		},
+	}
+	pssCmd.Flags().StringSliceVarP(&paths, "filename", "f", nil, "Manifest file or directory to evaluate instead of the cluster (repeatable)")
+	return pssCmd
+}
+
+// createPSACmd reports Pod Security Admission readiness per namespace
+func createPSACmd() *cobra.Command {
+	var paths []string
+	var psaCmd = &cobra.Command{
+		Use:   "psa",
+		Short: "Report Pod Security Admission readiness per namespace",
+		Long: `Compares each namespace's pod-security.kubernetes.io/enforce, audit and warn labels with the
+Pod Security Standards level its Pods and workload templates satisfy. Reports which namespaces could
+enforce restricted and which workloads the enforced or restricted level would reject.
+Reads the cluster (limited by -n) or, with -f, Namespace and workload manifests on disk.`,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			var namespaces []corev1.Namespace
+			var workloads []podsecurity.Workload
+			if len(paths) > 0 {
+				objects, errs := manifest.Load(paths)
+				for _, err := range errs {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				}
+				for _, o := range objects {
+					if ns, ok := o.Object.(*corev1.Namespace); ok {
+						namespaces = append(namespaces, *ns)
+					} else if w, ok := podsecurity.WorkloadFromObject(o.Object); ok {
+						workloads = append(workloads, w)
+					}
+				}
+			} else {
+				clientset, err := initClient()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error initializing Kubernetes client: %v\n", err)
+					os.Exit(exitError)
+				}
+				ctx := cmd.Context()
+				if namespace != "" {
+					ns, err := clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error fetching namespace %s: %v\n", namespace, err)
+						os.Exit(exitError)
+					}
+					namespaces = []corev1.Namespace{*ns}
+				} else {
+					list, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error listing namespaces: %v\n", err)
+						os.Exit(exitError)
+					}
+					namespaces = list.Items
+				}
+				var errs []error
+				workloads, errs = podsecurity.ListWorkloads(ctx, clientset, namespace)
+				for _, err := range errs {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				}
+			}
+
+			readiness := podsecurity.Readiness(namespaces, workloads)
+			var scanned []findings.Resource
+			for _, w := range workloads {
+				scanned = append(scanned, w.Resource)
+			}
+			view := postureView("Pod Security Admission Readiness", podsecurity.ReadinessFindings(readiness), nil)
+			view.Resources = scanned
+
+			if outputFormat != reports.FormatTable {
+				writeReport(cmd, outputFormat, view)
+				enforceGate(view)
+				return
+			}
+
+			out := cmd.OutOrStdout()
+			label := func(level psaapi.Level) string {
+				if level == "" {
+					return "-"
+				}
+				return string(level)
+			}
+			w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAMESPACE\tENFORCE\tAUDIT\tWARN\tWORKLOADS\tACHIEVABLE\tRESTRICTED READY")
+			ready := 0
+			for _, n := range readiness {
+				status := color.GreenString("yes")
+				if n.ReadyFor(psaapi.LevelRestricted) {
+					ready++
+				} else {
+					status = color.RedString("no (%d rejected)", len(n.Rejected(psaapi.LevelRestricted)))
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+					n.Namespace, label(n.Enforce), label(n.Audit), label(n.Warn), len(n.Workloads),
+					pssLevelColor(string(n.Achievable)).Sprint(n.Achievable), status)
+			}
+			w.Flush()
+
+			for _, n := range readiness {
+				enforce := n.EffectiveEnforce()
+				if rejected := n.Rejected(enforce); len(rejected) > 0 {
+					fmt.Fprintf(out, "\n%s: rejected by the enforced %s level\n", n.Namespace, enforce)
+					printRejected(out, rejected, enforce)
+				}
+				if enforce != psaapi.LevelRestricted {
+					if rejected := n.Rejected(psaapi.LevelRestricted); len(rejected) > 0 {
+						fmt.Fprintf(out, "\n%s: would be rejected by restricted\n", n.Namespace)
+						printRejected(out, rejected, psaapi.LevelRestricted)
+					}
+				}
+			}
+			fmt.Fprintf(out, "\n%d of %d namespaces could enforce restricted\n", ready, len(readiness))
+			enforceGate(view)
+		},
+	}
+	psaCmd.Flags().StringSliceVarP(&paths, "filename", "f", nil, "Manifest file or directory to evaluate instead of the cluster (repeatable)")
+	return psaCmd
+}
+
+// createNetpolCmd reports the NetworkPolicy coverage of the pods
+func createNetpolCmd() *cobra.Command {
+	var netpolCmd = &cobra.Command{
+		Use:   "netpol",
+		Short: "Analyze NetworkPolicy coverage of the pods",
+		Long: `Computes which pods the NetworkPolicies select for ingress and egress, and flags namespaces without
+a default deny policy, workloads no policy restricts ingress or egress for, policies whose selectors match
+no pods and egress rules that reach the 169.254.169.254 metadata endpoint. Suggests a default deny
+NetworkPolicy for every namespace missing one. Reads the cluster (limited by -n).`,
+		SilenceUsage: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			clientset, err := initClient()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error initializing Kubernetes client: %v\n", err)
+				os.Exit(exitError)
+			}
+			snapshot, err := netpol.Fetch(cmd.Context(), clientset, namespace)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(exitError)
+			}
+			netpolFindings, scanned := snapshot.Analyze()
+			view := postureView("NetworkPolicy Coverage", netpolFindings, nil)
+			view.Resources = scanned
+
+			if outputFormat != reports.FormatTable {
+				writeReport(cmd, outputFormat, view)
+				enforceGate(view)
+				return
+			}
+
+			out := cmd.OutOrStdout()
+			for _, f := range view.Findings {
+				fmt.Fprintf(out, "%s %s %s: %s\n", severityColor(f.Severity).Sprintf("[%s]", f.Severity), f.RuleID, f.Resource, f.Message)
+			}
+			for _, fx := range view.Remediations {
+				fmt.Fprintf(out, "\n# %s\n%s", fx.Title, strings.TrimPrefix(fx.YAML, "\n"))
+			}
+			fmt.Fprintf(out, "\nAnalyzed %d pods and %d policies: %d findings (%d suppressed), risk score %d/100\n",
+				len(snapshot.Pods), len(snapshot.Policies), len(view.Findings), len(view.Suppressed), view.RiskScore)
+			enforceGate(view)
+		},
+	}
+	return netpolCmd
+}
+
+// printRejected lists workloads and the controls that fail at level
+func printRejected(out io.Writer, rejected []podsecurity.WorkloadResult, level psaapi.Level) {
+	for _, r := range rejected {
+		fmt.Fprintf(out, "  %s\n", r.Workload.Resource)
+		for _, v := range r.Result.ViolationsAt(level) {
+			fmt.Fprintf(out, "    [%s] %s\n", v.Level, v)
+		}
+	}
+}
+
+// pssLevelColor returns the console color used for a Pod Security Standards level
+func pssLevelColor(level string) *color.Color {
+	switch level {
+	case "restricted":
+		return color.New(color.FgGreen)
+	case "baseline":
+		return color.New(color.FgYellow)
+	default:
+		return color.New(color.FgRed, color.Bold)
+	}
+}
+
+// severityColor returns the console color used for a severity
+func severityColor(sev findings.Severity) *color.Color {
+	switch sev {
+	case findings.SeverityCritical:
+		return color.New(color.FgHiRed)
+	case findings.SeverityHigh:
+		return color.New(color.FgRed)
+	case findings.SeverityMedium:
+		return color.New(color.FgYellow)
+	default:
+		return color.New(color.FgCyan)
+	}
 }
 
 // Define the watch command in the init to be accessible from the root command
@@ -110,14 +604,17 @@ func createWatchCmd() *cobra.Command {
 			resourceSelected := watchPodsFlag ||
 				watchDeploymentsFlag ||
 				watchSecretsFlag ||
-				watchClusterRolesFlag
+				watchClusterRolesFlag ||
+				watchWorkloadsFlag
 
 			if !resourceSelected {
-				color.Yellow("No resources selected for watch....Please specify at least one resource")
-				color.Yellow("  --watch-pods")
-				color.Yellow("  --watch-deployments")
-				color.Yellow("  --watch-secrets")
-				color.Yellow("  --watch-clusterroles")
+				yellow := color.New(color.FgYellow)
+				yellow.Fprintln(cmd.OutOrStdout(), "No resources selected for watch....Please specify at least one resource")
+				yellow.Fprintln(cmd.OutOrStdout(), "  --watch-pods")
+				yellow.Fprintln(cmd.OutOrStdout(), "  --watch-deployments")
+				yellow.Fprintln(cmd.OutOrStdout(), "  --watch-secrets")
+				yellow.Fprintln(cmd.OutOrStdout(), "  --watch-clusterroles")
+				yellow.Fprintln(cmd.OutOrStdout(), "  --watch-workloads")
 				return
 			}
 
@@ -136,6 +633,7 @@ func createWatchCmd() *cobra.Command {
 					"deployments":  watchDeploymentsFlag,
 					"secrets":      watchSecretsFlag,
 					"clusterRoles": watchClusterRolesFlag,
+					"workloads":    watchWorkloadsFlag,
 				}
 
 				// Print the reosurces that are selected for watch
@@ -206,58 +704,62 @@ func createCheckCmd() *cobra.Command {
 				fmt.Fprintf(os.Stderr, "Error initializing Kubernetes client: %v\n", err)
 				os.Exit(1)
 			}
+			ctx := cmd.Context()
+			table := outputFormat == reports.FormatTable
+			requiredRoles := activePolicy.RequiredClusterRoles
+			requiredNodeCount := activePolicy.RequiredNodeCount
+
 			// Execute checks if flag is true
-			color.Green("Running control checks...")
-			clusterRoles, err := clientset.RbacV1().ClusterRoles().List(cmd.Context(), metav1.ListOptions{})
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error fetching cluster roles: %v\n", err)
-				os.Exit(1)
-			}
-			requiredRoles := []string{
-				"system:auth-delegator",
-				"system:certificates.k8s.io:certificatesigningrequests:nodeclient",
-				"system:aggregate-to-admin",
-			}
-			color.New(color.BgHiYellow).Printf("Searching for required roles: %v\n", requiredRoles)
-			for _, requiredRole := range requiredRoles {
-				found := false
-				for _, clusterRole := range clusterRoles.Items {
-					if clusterRole.Name == requiredRole {
-						found = true
-						break
-					}
-				}
-				if found {
-					color.New(color.BgGreen).Printf("Required cluster role %s found\n", requiredRole)
-				} else {
-					color.New(color.BgHiMagenta).Printf("Required cluster role %s not found\n", requiredRole)
-				}
+			if table {
+				color.Green("Running control checks...")
+				color.New(color.BgHiYellow).Printf("Searching for required roles: %v\n", requiredRoles)
 			}
+			roleFindings := controlchecks.CheckRequiredClusterRoles(ctx, clientset, requiredRoles)
+
 			// check for pods in a certain state
-			pods, err := clientset.CoreV1().Pods("").List(cmd.Context(), metav1.ListOptions{})
+			podFindings, err := controlchecks.CheckPodsRunning(ctx, clientset)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error fetching pods: %v\n", err)
 				os.Exit(1)
 			}
-			for _, pod := range pods.Items {
-				if pod.Status.Phase != corev1.PodRunning {
-					fmt.Printf("Pod %s is not running\n", pod.Name)
-					os.Exit(1)
-				}
-			}
 
 			// Checks for available nodes
-			nodes, err := clientset.CoreV1().Nodes().List(cmd.Context(), metav1.ListOptions{})
+			nodeFindings, err := controlchecks.CheckNodeCount(ctx, clientset, requiredNodeCount)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error fetching nodes: %v\n", err)
 				os.Exit(1)
 			}
-			requiredNodeCount := 3
-			if len(nodes.Items) < requiredNodeCount {
-				fmt.Printf("Insufficient nodes: %d available, %d required\n", len(nodes.Items), requiredNodeCount)
-				os.Exit(1)
+
+			var all []findings.Finding
+			all = append(all, roleFindings...)
+			all = append(all, podFindings...)
+			all = append(all, nodeFindings...)
+			view := postureView("Control Checks", all, nil)
+
+			if !table {
+				writeReport(cmd, outputFormat, view)
+				enforceGate(view)
+				return
+			}
+
+			missing := map[string]bool{}
+			for _, f := range roleFindings {
+				missing[f.Resource.Name] = true
+			}
+			for _, requiredRole := range requiredRoles {
+				if missing[requiredRole] {
+					color.New(color.BgHiMagenta).Printf("Required cluster role %s not found\n", requiredRole)
+				} else {
+					color.New(color.BgGreen).Printf("Required cluster role %s found\n", requiredRole)
+				}
+			}
+			for _, f := range append(podFindings, nodeFindings...) {
+				fmt.Println(f.Message)
+			}
+			enforceGate(view)
+			if len(podFindings) == 0 && len(nodeFindings) == 0 {
+				color.Green("All control checks passed successfully.")
 			}
-			color.Green("All control checks passed successfully.")
 		},
 	}
 	//checkCmd.Flags().BoolVarP(&checkFlag, "check", "c", false, "Run control checks")
@@ -279,12 +781,29 @@ func createDeploymentCmd() *cobra.Command {
 				fmt.Fprintf(os.Stderr, "Error initializing Kubernetes client: %v\n", err)
 				os.Exit(1)
 			}
-			color.Green("Running deployment checks...")
-			deploymentList, violationCount, err := entity.GetDeploymentsAndViolationCount(clientset)
+			// Keep per-check console output quiet; findings are collected from the check results
+			k8s.SetSecurityEventHandler(&k8s.RecordingSecurityEventHandler{})
+			deployments, err := entity.GetDeploymentList(clientset)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "%v\n", err)
+				fmt.Fprintf(os.Stderr, "Error fetching deployments: %v\n", err)
 				os.Exit(1)
 			}
+			var deploymentFindings []findings.Finding
+			var scanned []findings.Resource
+			for i := range deployments.Items {
+				deploymentFindings = append(deploymentFindings, k8s.CheckDeploymentSecurity(&deployments.Items[i])...)
+				scanned = append(scanned, k8s.DeploymentResources(&deployments.Items[i])...)
+			}
+			view := postureView("Deployment Checks", deploymentFindings, nil)
+			view.Resources = scanned
+
+			if outputFormat != reports.FormatTable {
+				writeReport(cmd, outputFormat, view)
+				enforceGate(view)
+				return
+			}
+			color.Green("Running deployment checks...")
+			deploymentList, violationCount := entity.NewDeploymentList(deployments)
 			green := color.New(color.FgGreen).SprintFunc()
 			red := color.New(color.FgHiRed).SprintFunc()
 			blue := color.New(color.FgHiBlue).SprintFunc()
@@ -303,9 +822,10 @@ func createDeploymentCmd() *cobra.Command {
 				fmt.Printf("Labels: %s\n", blue(strings.Join(labels, ", ")))
 				fmt.Println()
 			}
+			enforceGate(view)
 		},
 	}
-	// -- Reasses 12-14-25 -- deploymentCmd.Flags().BoolVarP(&deploymentFlag, "deployment", "d", false, "Run deployment checks")
+	deploymentCmd.Flags().BoolVarP(&deploymentFlag, "deployment", "d", false, "Run deployment checks")
 	return deploymentCmd
 }
 func createRbacCmd() *cobra.Command {
@@ -324,10 +844,33 @@ func createRbacCmd() *cobra.Command {
 				fmt.Fprintf(os.Stderr, "Error initializing Kubernetes client: %v\n", err)
 				os.Exit(1)
 			}
-			// Fetch the list of roles from the Kubernetes API
-			roleList, err := clientset.RbacV1().Roles("").List(context.Background(), metav1.ListOptions{})
+			// Fetch roles, cluster roles and their bindings from the Kubernetes API
+			snapshot, err := entity.FetchRBACSnapshot(context.Background(), clientset)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to fetch roles: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Failed to fetch RBAC objects: %v\n", err)
+				os.Exit(1)
+			}
+			roleList := &rbacv1.RoleList{Items: snapshot.Roles}
+
+			// Bindings decide who actually holds the roles below
+			rbacFindings, scanned := snapshot.EvaluateBindings()
+			for i := range roleList.Items {
+				rbacFindings = append(rbacFindings, entity.EvaluateRole(&roleList.Items[i])...)
+				scanned = append(scanned, entity.RoleResource(&roleList.Items[i]))
+			}
+			// Aggregated ClusterRoles are evaluated on their resolved rules
+			clusterRoleFindings, clusterRoleResources := snapshot.EvaluateClusterRoles()
+			rbacFindings = append(rbacFindings, clusterRoleFindings...)
+			scanned = append(scanned, clusterRoleResources...)
+			dangling := snapshot.DanglingFindings()
+			rbacFindings = append(rbacFindings, dangling...)
+			view := postureView("RBAC Checks", rbacFindings, nil)
+			view.Resources = scanned
+			view.AttackPaths = append(view.AttackPaths, snapshot.EscalationPaths()...)
+
+			if outputFormat != reports.FormatTable {
+				writeReport(cmd, outputFormat, view)
+				enforceGate(view)
 				return
 			}
 
@@ -462,13 +1005,671 @@ func createRbacCmd() *cobra.Command {
 						yellow(resources))
 				} // Closing brace for the inner for loop
 			} // Closing brace for the outer for loop
+			if len(dangling) > 0 {
+				fmt.Println("\nDangling and Unused RBAC Objects:")
+				for _, f := range dangling {
+					fmt.Printf("  - [%s] %s %s\n", f.Severity, f.Resource, f.Message)
+				}
+			}
+			printAttackPaths(view.AttackPaths)
+			enforceGate(view)
 		}, // Closing brace for the Run function
 	} // Closing brace for the rbacCmd definition
 
 	// Add the watch command to the root command
 	rbacCmd.Flags().BoolVarP(&rbacFlag, "rbac", "b", false, "Run RBAC checks")
+	rbacCmd.AddCommand(createRbacSubjectsCmd())
+	rbacCmd.AddCommand(createRbacSuggestCmd())
+	rbacCmd.AddCommand(createRbacUnusedCmd())
 	return rbacCmd
 }
+
+// createWhoCanCmd lists the subjects granted a verb on a resource
+func createWhoCanCmd() *cobra.Command {
+	var resourceName string
+	var whoCanCmd = &cobra.Command{
+		Use:   "who-can <verb> <resource>",
+		Short: "List the subjects that can perform a verb on a resource",
+		Long: `Evaluates every Role, ClusterRole (resolving aggregated ClusterRoles), RoleBinding and ClusterRoleBinding
+offline and prints each subject granted the request together with the binding path. Wildcards and
+resourceNames are honoured. The resource is written as resource[.group][/subresource], e.g. secrets,
+deployments.apps or pods/exec, or as a non-resource URL such as /metrics.
+With -n, RoleBindings in that namespace and ClusterRoleBindings are considered; without it only
+cluster-wide grants are. Supports --output table (default) or json.`,
+		Example: `  paranoia who-can get secrets -n prod
+  paranoia who-can create pods/exec
+  paranoia who-can get secrets -n prod --resource-name db-credentials`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != reports.FormatTable && outputFormat != reports.FormatJSON {
+				return fmt.Errorf("who-can supports --output %s or %s", reports.FormatTable, reports.FormatJSON)
+			}
+			req, err := entity.ParseResourceRequest(args[0], args[1])
+			if err != nil {
+				return err
+			}
+			req.Name = resourceName
+
+			clientset, err := initClient()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error initializing Kubernetes client: %v\n", err)
+				os.Exit(exitError)
+			}
+			snapshot, err := entity.FetchRBACSnapshot(cmd.Context(), clientset)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to fetch RBAC objects: %v\n", err)
+				os.Exit(exitError)
+			}
+			paths := snapshot.WhoCan(req, namespace)
+
+			out := cmd.OutOrStdout()
+			if outputFormat == reports.FormatJSON {
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				return enc.Encode(paths)
+			}
+
+			scope := "cluster-wide"
+			if namespace != "" {
+				scope = "namespace " + namespace
+			}
+			fmt.Fprintf(out, "Subjects that can %s (%s):\n", req, scope)
+			w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SUBJECT\tSCOPE\tBINDING\tROLE\tRESOURCE NAMES")
+			for _, p := range paths {
+				names := "*"
+				if len(p.ResourceNames) > 0 {
+					names = strings.Join(p.ResourceNames, ", ")
+				}
+				role := fmt.Sprintf("%s/%s", p.RoleRef.Kind, p.RoleRef.Name)
+				if p.Source != "" {
+					role += " (aggregated from " + p.Source + ")"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s/%s\t%s\t%s\n", entity.SubjectString(p.Subject), p.Scope(),
+					p.Binding.Kind, p.Binding.Name, role, names)
+			}
+			w.Flush()
+			fmt.Fprintf(out, "\n%d binding paths\n", len(paths))
+			return nil
+		},
+	}
+	whoCanCmd.Flags().StringVar(&resourceName, "resource-name", "", "Only count grants that cover this object name")
+	return whoCanCmd
+}
+
+// printAttackPaths lists attack paths with their steps
+func printAttackPaths(paths []riskposture.AttackPath) {
+	if len(paths) == 0 {
+		return
+	}
+	fmt.Println("\nAttack Paths Detected:")
+	for _, p := range paths {
+		fmt.Printf("  - [%s] %s (confidence %d%%)\n", p.Severity, p.Title, p.Confidence)
+		for i, step := range p.Steps {
+			name := step.Name
+			if step.Namespace != "" {
+				name = step.Namespace + "/" + name
+			}
+			fmt.Printf("      %d. %s %s: %s\n", i+1, step.Kind, name, step.Why)
+		}
+	}
+}
+
+// createRbacSubjectsCmd lists the effective permissions of every subject
+func createRbacSubjectsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "subjects",
+		Short: "List the effective permissions of every ServiceAccount, User and Group",
+		Long: `Resolves every RoleBinding and ClusterRoleBinding into the roles each ServiceAccount, User and Group holds,
+including the grants inherited through implicit groups such as system:authenticated and
+system:serviceaccounts:<namespace>. -n limits the list to subjects with access to that namespace.
+Supports --output table (default) or json.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != reports.FormatTable && outputFormat != reports.FormatJSON {
+				return fmt.Errorf("subjects supports --output %s or %s", reports.FormatTable, reports.FormatJSON)
+			}
+			clientset, err := initClient()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error initializing Kubernetes client: %v\n", err)
+				os.Exit(exitError)
+			}
+			snapshot, err := entity.FetchRBACSnapshot(cmd.Context(), clientset)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to fetch RBAC objects: %v\n", err)
+				os.Exit(exitError)
+			}
+
+			var inventory []entity.SubjectPermissions
+			for _, p := range snapshot.Inventory() {
+				if namespace == "" || p.Reaches(namespace) {
+					inventory = append(inventory, p)
+				}
+			}
+
+			out := cmd.OutOrStdout()
+			if outputFormat == reports.FormatJSON {
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				return enc.Encode(inventory)
+			}
+
+			for _, p := range inventory {
+				fmt.Fprintf(out, "%s", color.CyanString(entity.SubjectString(p.Subject)))
+				if risks := p.Risks(); len(risks) > 0 {
+					fmt.Fprintf(out, " %s", color.RedString("[%s]", strings.Join(risks, ", ")))
+				}
+				fmt.Fprintln(out)
+
+				w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+				for _, g := range p.Grants {
+					role := fmt.Sprintf("%s/%s", g.RoleRef.Kind, g.RoleRef.Name)
+					if g.Missing {
+						role += " (missing)"
+					}
+					via := ""
+					if g.Via != "" {
+						via = "via " + g.Via
+					}
+					fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", role, g.Scope(), g.Binding.Kind+"/"+g.Binding.Name, via)
+				}
+				w.Flush()
+			}
+			fmt.Fprintf(out, "\n%d subjects\n", len(inventory))
+			return nil
+		},
+	}
+}
+
+// createRbacSuggestCmd generates least-privilege roles from an audit log
+func createRbacSuggestCmd() *cobra.Command {
+	var auditLog, serviceAccount string
+	var suggestCmd = &cobra.Command{
+		Use:   "suggest",
+		Short: "Generate a least-privilege Role from the requests a ServiceAccount made",
+		Long: `Reads a Kubernetes audit log (JSON lines, as written by the API server's log backend), collects the
+requests the ServiceAccount made that were authorized, and prints the smallest Roles and ClusterRole,
+with their bindings, allowing exactly those requests. When the cluster is reachable the rules currently
+bound to the ServiceAccount are compared with the suggestion: "-" marks a bound rule the suggestion
+drops (unused when no logged request needed it), "+" a suggested rule and " " a rule both share.
+The diff is printed as YAML comments so the output can be applied as-is. Supports --output table
+(default) or json.`,
+		Example: `  paranoia rbac suggest --audit-log /var/log/kubernetes/audit.log --sa prod/web
+  paranoia rbac suggest --audit-log audit.jsonl --sa prod/web > web-rbac.yaml`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != reports.FormatTable && outputFormat != reports.FormatJSON {
+				return fmt.Errorf("suggest supports --output %s or %s", reports.FormatTable, reports.FormatJSON)
+			}
+			saNamespace, saName, err := audit.ParseServiceAccount(serviceAccount)
+			if err != nil {
+				return err
+			}
+			events, err := audit.ReadFile(auditLog)
+			if err != nil {
+				return fmt.Errorf("failed to read audit log: %w", err)
+			}
+			observed := audit.Observed(events, audit.ServiceAccountUsername(saNamespace, saName))
+			if len(observed) == 0 {
+				return fmt.Errorf("no authorized requests by ServiceAccount %s/%s in %s", saNamespace, saName, auditLog)
+			}
+			suggested := audit.MinimalRules(observed)
+
+			// The diff needs the bindings; without cluster access only the roles are printed
+			var diff []audit.DiffLine
+			subject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: saNamespace, Name: saName}
+			if clientset, err := initClient(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: cannot compare with the bound rules: %v\n", err)
+			} else if snapshot, err := entity.FetchRBACSnapshot(cmd.Context(), clientset); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: cannot compare with the bound rules: %v\n", err)
+			} else {
+				bound := entity.SubjectPermissions{Subject: subject}
+				for _, p := range snapshot.Inventory() {
+					if entity.SubjectString(p.Subject) == entity.SubjectString(subject) {
+						bound = p
+					}
+				}
+				diff = audit.Diff(bound, suggested, observed)
+			}
+
+			manifests := audit.Manifests(saNamespace, saName, suggested)
+			out := cmd.OutOrStdout()
+			if outputFormat == reports.FormatJSON {
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				return enc.Encode(struct {
+					Subject   rbacv1.Subject     `json:"subject"`
+					Observed  []audit.Access     `json:"observed"`
+					Rules     []audit.ScopedRule `json:"rules"`
+					Manifests []runtime.Object   `json:"manifests"`
+					Diff      []audit.DiffLine   `json:"diff,omitempty"`
+				}{subject, observed, suggested, manifests, diff})
+			}
+
+			manifest, err := audit.ManifestYAML(manifests)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "# Least-privilege RBAC for ServiceAccount %s/%s from %d observed requests\n", saNamespace, saName, len(observed))
+			fmt.Fprint(out, manifest)
+			if diff != nil {
+				fmt.Fprintln(out, "---")
+				fmt.Fprintf(out, "# Bound rules (-) compared with the suggestion (+)\n")
+				for _, line := range diff {
+					note := ""
+					if line.Source != "" {
+						note = " from " + line.Source
+					}
+					if line.Op == "-" && line.Unused {
+						note += " (unused)"
+					}
+					fmt.Fprintf(out, "# %s [%s] %s%s\n", line.Op, line.Scope(), audit.FormatRule(line.Rule), note)
+				}
+			}
+			return nil
+		},
+	}
+	suggestCmd.Flags().StringVar(&auditLog, "audit-log", "", "Path to the Kubernetes audit log (JSON lines)")
+	suggestCmd.Flags().StringVar(&serviceAccount, "sa", "", "ServiceAccount to generate the Role for, as namespace/name")
+	_ = suggestCmd.MarkFlagRequired("audit-log")
+	_ = suggestCmd.MarkFlagRequired("sa")
+	return suggestCmd
+}
+
+// createRbacUnusedCmd lists the permissions subjects hold but did not use in an audit log window
+func createRbacUnusedCmd() *cobra.Command {
+	var auditLogs []string
+	var serviceAccount string
+	var unusedCmd = &cobra.Command{
+		Use:   "unused",
+		Short: "List the permissions each subject holds but never used in the audit logs",
+		Long: `Resolves the permissions of every ServiceAccount, User and Group from the cluster's RoleBindings and
+ClusterRoleBindings, replays the Kubernetes audit logs given (JSON lines, as written by the API server's
+log backend) and lists per subject each verb on each resource no authorized request needed. Subjects
+and permissions are ranked by danger: one point each for wildcards, dangerous verbs (create, update,
+patch, delete, bind, escalate, impersonate) and secrets access. Bootstrapped bindings, system: users
+and groups and grants inherited through a group are left out; group grants are reported on the group.
+The result is only as complete as the window the logs cover, which is printed with the report.
+-n limits the list to subjects with access to that namespace. Supports --output table (default) or json.`,
+		Example: `  paranoia rbac unused --audit-log /var/log/kubernetes/audit.log
+  paranoia rbac unused --audit-log audit.log.1 --audit-log audit.log -n prod
+  paranoia rbac unused --audit-log audit.jsonl --sa prod/web --output json`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != reports.FormatTable && outputFormat != reports.FormatJSON {
+				return fmt.Errorf("unused supports --output %s or %s", reports.FormatTable, reports.FormatJSON)
+			}
+			var subject string
+			if serviceAccount != "" {
+				saNamespace, saName, err := audit.ParseServiceAccount(serviceAccount)
+				if err != nil {
+					return err
+				}
+				subject = entity.SubjectString(rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: saNamespace, Name: saName})
+			}
+			var events []audit.Event
+			for _, path := range auditLogs {
+				logEvents, err := audit.ReadFile(path)
+				if err != nil {
+					return fmt.Errorf("failed to read audit log: %w", err)
+				}
+				events = append(events, logEvents...)
+			}
+
+			clientset, err := initClient()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error initializing Kubernetes client: %v\n", err)
+				os.Exit(exitError)
+			}
+			snapshot, err := entity.FetchRBACSnapshot(cmd.Context(), clientset)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to fetch RBAC objects: %v\n", err)
+				os.Exit(exitError)
+			}
+			var inventory []entity.SubjectPermissions
+			for _, p := range snapshot.Inventory() {
+				if (namespace == "" || p.Reaches(namespace)) && (subject == "" || entity.SubjectString(p.Subject) == subject) {
+					inventory = append(inventory, p)
+				}
+			}
+			usage := audit.Unused(inventory, events)
+			from, to := audit.Window(events)
+
+			out := cmd.OutOrStdout()
+			if outputFormat == reports.FormatJSON {
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				return enc.Encode(struct {
+					From     time.Time            `json:"from"`
+					To       time.Time            `json:"to"`
+					Events   int                  `json:"events"`
+					Subjects []audit.SubjectUsage `json:"subjects"`
+				}{from, to, len(events), usage})
+			}
+
+			fmt.Fprintf(out, "Audit window: %s to %s (%d events)\n\n", from.Format(time.RFC3339), to.Format(time.RFC3339), len(events))
+			for _, u := range usage {
+				fmt.Fprintf(out, "%s (%d requests, %d unused permissions)\n", color.CyanString(entity.SubjectString(u.Subject)), u.Requests, len(u.Unused))
+				w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+				for _, p := range u.Unused {
+					risks := ""
+					if len(p.Risks) > 0 {
+						risks = severityColor(p.Severity()).Sprintf("[%s]", strings.Join(p.Risks, ", "))
+					}
+					fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", p, p.Scope(), risks, p.Source)
+				}
+				w.Flush()
+			}
+			fmt.Fprintf(out, "\n%d subjects with unused permissions\n", len(usage))
+			return nil
+		},
+	}
+	unusedCmd.Flags().StringSliceVar(&auditLogs, "audit-log", nil, "Path to a Kubernetes audit log (JSON lines), repeatable")
+	unusedCmd.Flags().StringVar(&serviceAccount, "sa", "", "Only report this ServiceAccount, as namespace/name")
+	_ = unusedCmd.MarkFlagRequired("audit-log")
+	return unusedCmd
+}
+
+// jsonEventHandler writes security events as JSON lines
+type jsonEventHandler struct {
+	enc *json.Encoder
+}
+
+func (h jsonEventHandler) HandleEvent(event k8s.SecurityEvent) {
+	_ = h.enc.Encode(event)
+}
+
+// createAuditCmd detects threats in a Kubernetes audit log
+func createAuditCmd() *cobra.Command {
+	var auditLog string
+	var follow, resolvePods bool
+	var secretReaders []string
+	var auditCmd = &cobra.Command{
+		Use:   "audit",
+		Short: "Detect threats in a Kubernetes audit log",
+		Long: `Reads a Kubernetes audit log (JSON lines, as written by the API server's log backend) and reports
+exec and attach into privileged pods, secret reads by identities other than the control plane,
+kube-system controllers and kubelets, anonymous requests, bindings granting cluster-admin and the
+creation of privileged pods. Events go to the same handlers as the watch command's.
+
+Pods are known to be privileged when the log shows their creation; --resolve-pods looks up the
+targets of exec and attach in the cluster otherwise. Pod and binding bodies are only logged by
+audit policies at the Request level or above. With --follow the log is tailed as it grows and
+reopened when rotated. Supports --output table (default) or json (alias ndjson), one event per line.`,
+		Example: `  paranoia audit --audit-log /var/log/kubernetes/audit.log
+  paranoia audit --audit-log /var/log/kubernetes/audit.log --follow --resolve-pods
+  paranoia audit --audit-log audit.jsonl --secret-reader 'system:serviceaccount:vault:*'`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var handler k8s.SecurityEventHandler = k8s.ConsoleSecurityEventHandler{}
+			switch outputFormat {
+			case reports.FormatTable:
+			case reports.FormatJSON, reports.FormatNDJSON:
+				handler = jsonEventHandler{enc: json.NewEncoder(cmd.OutOrStdout())}
+			default:
+				return fmt.Errorf("audit supports --output %s, %s or %s", reports.FormatTable, reports.FormatJSON, reports.FormatNDJSON)
+			}
+			// Events are only recorded for the final count of a log read once;
+			// when following they would pile up until the command is stopped
+			recorder := &k8s.RecordingSecurityEventHandler{}
+			handlers := []k8s.SecurityEventHandler{handler}
+			if !follow {
+				handlers = append(handlers, recorder)
+			}
+			detector := audit.NewDetector(k8s.MultiSecurityEventHandler{Handlers: handlers})
+			detector.SecretReaders = append(detector.SecretReaders, secretReaders...)
+			if resolvePods {
+				clientset, err := initClient()
+				if err != nil {
+					return fmt.Errorf("error initializing Kubernetes client: %w", err)
+				}
+				detector.Pods = func(namespace, name string) (*corev1.Pod, error) {
+					return clientset.CoreV1().Pods(namespace).Get(cmd.Context(), name, metav1.GetOptions{})
+				}
+			}
+			process := func(e audit.Event) error {
+				detector.Process(e)
+				return nil
+			}
+
+			if follow {
+				ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGINT, syscall.SIGTERM)
+				defer stop()
+				fmt.Fprintf(os.Stderr, "Following %s. Press Ctrl+C to stop\n", auditLog)
+				return audit.Follow(ctx, auditLog, time.Second, process)
+			}
+			f, err := os.Open(auditLog)
+			if err != nil {
+				return fmt.Errorf("failed to read audit log: %w", err)
+			}
+			defer f.Close()
+			if err := audit.Decode(f, process); err != nil {
+				return fmt.Errorf("failed to read audit log %s: %w", auditLog, err)
+			}
+			fmt.Fprintf(os.Stderr, "%d security events detected\n", len(recorder.SnapShot()))
+			return nil
+		},
+	}
+	auditCmd.Flags().StringVar(&auditLog, "audit-log", "", "Path to the Kubernetes audit log (JSON lines)")
+	auditCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep reading the log as it grows")
+	auditCmd.Flags().BoolVar(&resolvePods, "resolve-pods", false, "Look up exec and attach targets in the cluster")
+	auditCmd.Flags().StringSliceVar(&secretReaders, "secret-reader", nil, "Additional usernames expected to read secrets, * globs allowed (repeatable)")
+	_ = auditCmd.MarkFlagRequired("audit-log")
+	return auditCmd
+}
+
+// createWhoAmICmd reports what the current identity can do in each namespace
+func createWhoAmICmd() *cobra.Command {
+	var as string
+	var asGroups []string
+	var whoAmICmd = &cobra.Command{
+		Use:   "whoami",
+		Short: "Show what the current identity can do in each namespace",
+		Long: `Asks the API server through SelfSubjectAccessReview and SelfSubjectRulesReview what the current
+kubeconfig identity may do, so no RBAC read permissions are needed. Each namespace's effective rules
+are checked for wildcards, dangerous verbs, secrets access and privilege escalation; cluster-wide
+escalation permissions are checked as well. Without -n every namespace is reviewed when they can be
+listed, otherwise only "default". --as and --as-group review another identity through impersonation.
+Supports --output table (default) or json.`,
+		Example: `  paranoia whoami
+  paranoia whoami -n prod
+  paranoia whoami --as system:serviceaccount:ci:deployer`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != reports.FormatTable && outputFormat != reports.FormatJSON {
+				return fmt.Errorf("whoami supports --output %s or %s", reports.FormatTable, reports.FormatJSON)
+			}
+			config, err := restConfig()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error initializing Kubernetes client: %v\n", err)
+				os.Exit(exitError)
+			}
+			config.Impersonate = rest.ImpersonationConfig{UserName: as, Groups: asGroups}
+			clientset, err := kubernetes.NewForConfig(config)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error initializing Kubernetes client: %v\n", err)
+				os.Exit(exitError)
+			}
+
+			ctx := cmd.Context()
+			namespaces := []string{namespace}
+			if namespace == "" {
+				list, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: cannot list namespaces, reviewing \"default\" only: %v\n", err)
+					namespaces = []string{"default"}
+				} else {
+					namespaces = nil
+					for _, ns := range list.Items {
+						namespaces = append(namespaces, ns.Name)
+					}
+				}
+			}
+
+			access, err := entity.ReviewSelfAccess(ctx, clientset, namespaces)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to review access: %v\n", err)
+				os.Exit(exitError)
+			}
+
+			out := cmd.OutOrStdout()
+			if outputFormat == reports.FormatJSON {
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				return enc.Encode(access)
+			}
+
+			user := access.User
+			if user == "" {
+				user = "(unknown, SelfSubjectReview not served)"
+			}
+			fmt.Fprintf(out, "User: %s\n", color.CyanString(user))
+			if len(access.Groups) > 0 {
+				fmt.Fprintf(out, "Groups: %s\n", strings.Join(access.Groups, ", "))
+			}
+			if access.ClusterAdmin {
+				fmt.Fprintln(out, color.RedString("Holds every permission cluster-wide (cluster-admin)"))
+			}
+			for _, e := range access.ClusterEscalations {
+				fmt.Fprintf(out, "%s %s\n", color.RedString("[cluster-wide]"), e)
+			}
+
+			for _, ns := range access.Namespaces {
+				fmt.Fprintf(out, "\n%s", color.CyanString("Namespace %s", ns.Namespace))
+				if risks := ns.Risks(); len(risks) > 0 {
+					fmt.Fprintf(out, " %s", color.RedString("[%s]", strings.Join(risks, ", ")))
+				}
+				fmt.Fprintln(out)
+				if ns.Incomplete {
+					fmt.Fprintf(out, "  %s\n", color.YellowString("rule list is incomplete: %s", ns.EvaluationError))
+				}
+				for _, e := range ns.Escalations() {
+					fmt.Fprintf(out, "  %s %s\n", color.RedString("escalation:"), e)
+				}
+
+				w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "  VERBS\tAPI GROUPS\tRESOURCES\tRESOURCE NAMES")
+				for _, rule := range ns.Rules {
+					resources := rule.Resources
+					if len(rule.NonResourceURLs) > 0 {
+						resources = rule.NonResourceURLs
+					}
+					fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", strings.Join(rule.Verbs, ","), strings.Join(rule.APIGroups, ","),
+						strings.Join(resources, ","), strings.Join(rule.ResourceNames, ","))
+				}
+				w.Flush()
+			}
+			return nil
+		},
+	}
+	whoAmICmd.Flags().StringVar(&as, "as", "", "Username to impersonate")
+	whoAmICmd.Flags().StringSliceVar(&asGroups, "as-group", nil, "Group to impersonate, can be repeated")
+	return whoAmICmd
+}
+
+// createDoctorCmd checks the permissions every command needs
+func createDoctorCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor [command...]",
+		Short: "Check the permissions paranoia needs before running it",
+		Long: `Checks through SelfSubjectAccessReview every list, watch and get permission each command needs,
+and through discovery whether the Trivy operator CRDs are installed, then prints a readiness matrix
+listing per command the report sections that would be incomplete. Pass command names to check only
+those. -n checks namespaced commands (pss, psa, report and the vulnerability section of report-html)
+in that namespace instead of cluster-wide. Supports --output table (default) or json.`,
+		Example: `  paranoia doctor
+  paranoia doctor report-html -n prod`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if outputFormat != reports.FormatTable && outputFormat != reports.FormatJSON {
+				return fmt.Errorf("doctor supports --output %s or %s", reports.FormatTable, reports.FormatJSON)
+			}
+			commands := preflight.Commands
+			if len(args) > 0 {
+				commands = nil
+				for _, name := range args {
+					command, ok := preflight.CommandByName(name)
+					if !ok {
+						return fmt.Errorf("unknown command %q", name)
+					}
+					commands = append(commands, command)
+				}
+			}
+
+			clientset, err := initClient()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error initializing Kubernetes client: %v\n", err)
+				os.Exit(exitError)
+			}
+			report, err := preflight.Run(cmd.Context(), clientset, namespace, commands)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Preflight checks failed: %v\n", err)
+				os.Exit(exitError)
+			}
+
+			out := cmd.OutOrStdout()
+			if outputFormat == reports.FormatJSON {
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+
+			for _, crd := range report.CRDs {
+				status := color.GreenString("installed")
+				if !crd.Installed {
+					status = color.YellowString("not installed")
+				}
+				fmt.Fprintf(out, "CRD %s (%s): %s\n", crd.Resource, crd.GroupVersion, status)
+			}
+			fmt.Fprintln(out)
+
+			w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "COMMAND\tSTATUS\tINCOMPLETE SECTIONS\tMISSING")
+			for _, readiness := range report.Commands {
+				missing := readiness.Missing()
+				status := color.GreenString("ready")
+				if len(missing) == len(readiness.Checks) {
+					status = color.RedString("blocked")
+				} else if len(missing) > 0 {
+					status = color.YellowString("partial")
+				}
+				var denied []string
+				for _, c := range missing {
+					denied = append(denied, missingPermission(c))
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", readiness.Command, status,
+					strings.Join(readiness.IncompleteSections(), ", "), strings.Join(denied, ", "))
+			}
+			w.Flush()
+			return nil
+		},
+	}
+}
+
+// missingPermission renders a failed preflight check
+func missingPermission(c preflight.Check) string {
+	if c.Reason != "" {
+		return fmt.Sprintf("%s (%s)", c.Request, c.Reason)
+	}
+	return c.Request.String()
+}
+
+// incompleteSections groups the failed preflight checks per report section
+func incompleteSections(readiness preflight.Readiness) []reports.IncompleteSection {
+	var out []reports.IncompleteSection
+	for _, section := range readiness.IncompleteSections() {
+		incomplete := reports.IncompleteSection{Section: section}
+		for _, c := range readiness.Missing() {
+			if c.Section == section {
+				incomplete.Missing = append(incomplete.Missing, missingPermission(c))
+			}
+		}
+		out = append(out, incomplete)
+	}
+	return out
+}
+
 func reportCmd() *cobra.Command {
 	var namespace string
 	var kubeconfig string
@@ -477,6 +1678,14 @@ func reportCmd() *cobra.Command {
 		Use:   "report",
 		Short: "Scan images for vulnerabilities",
 		Long:  `Scans the images for vulnerabilities.`,
+		// Validate before touching the cluster so a missing namespace fails fast
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if namespace == "" {
+				return fmt.Errorf("Namespace is required")
+			}
+			return nil
+		},
+		SilenceUsage: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			cfg, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
 			if err != nil {
@@ -486,17 +1695,19 @@ func reportCmd() *cobra.Command {
 
 			ctx := context.Background()
 
-			if namespace == "" {
-				fmt.Fprintf(os.Stderr, "Namespace is required\n")
-				os.Exit(1)
-			}
-
 			vulnReports, err := controlchecks.FetchVulnerabilityReports(ctx, cfg, namespace)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error fetching vulnerability reports: %v\n", err)
 				os.Exit(1)
 			}
 
+			view := postureView("Vulnerability Report", controlchecks.VulnerabilityFindings(vulnReports), nil)
+			if outputFormat != reports.FormatTable {
+				writeReport(cmd, outputFormat, view)
+				enforceGate(view)
+				return
+			}
+
 			var vulns []trivytypes.Vulnerability
 			fmt.Printf("Number of reports: %d\n", len(vulnReports))
 			for _, trivyReport := range vulnReports {
@@ -510,6 +1721,7 @@ func reportCmd() *cobra.Command {
 			}
 			// Call PrintVulnerabilityTable inside the Run function
 			reports.PrintVulnerabilityTable(vulns)
+			enforceGate(view)
 		},
 	}
 
@@ -523,6 +1735,7 @@ func reportHTMLCmd() *cobra.Command {
 	var kubeconfig string
 	var port string
 	var namespace string
+	var preflightFlag bool
 
 	var reportHTMLCmd = &cobra.Command{
 		Use:   "report-html",
@@ -540,95 +1753,59 @@ func reportHTMLCmd() *cobra.Command {
 				os.Exit(1)
 			}
 
+			// Keep per-check console output quiet; findings are collected from the check results
 			recorder := &k8s.RecordingSecurityEventHandler{}
 			k8s.SetSecurityEventHandler(recorder)
 
-			var allFindings []string
-			var allSignals []riskposture.Signal
-			var rbacFindings, deploymentFindings, controlPlaneFindings, podFindings, secretFindings []string
-			ctx := context.Background()
-
-			// Pod Security Checks
-			pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to list pods: %v\n", err)
-			} else {
-				for _, pod := range pods.Items {
-					k8s.CheckPodSecurity(&pod)
+			// The preflight checks record which sections the identity cannot fully read
+			var incomplete []reports.IncompleteSection
+			if preflightFlag {
+				command, _ := preflight.CommandByName("report-html")
+				report, err := preflight.Run(context.Background(), clientset, cmd.Flag("namespace").Value.String(), []preflight.Command{command})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: preflight checks failed: %v\n", err)
+				} else {
+					incomplete = incompleteSections(report.Commands[0])
+					for _, section := range incomplete {
+						fmt.Fprintf(os.Stderr, "Warning: %s section is incomplete, missing %s\n", section.Section, strings.Join(section.Missing, ", "))
+					}
 				}
 			}
 
-			// RBAC Analysis
-			clusterRoles, err := clientset.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to list cluster roles for RBAC analysis: %v\n", err)
-			} else {
-				for _, role := range clusterRoles.Items {
-					// Skips system/built-in roles
-					if strings.HasPrefix(role.Name, "system:") ||
-						strings.HasPrefix(role.Name, "kubeadm:") ||
-						strings.HasPrefix(role.Name, "k8s.io:") {
-						continue
-					}
-					findings, signals, _ := entity.AnalyzeClusterRoles(clientset, role.Name)
-					rbacFindings = append(rbacFindings, findings...)
-					allFindings = append(allFindings, findings...)
-					allSignals = append(allSignals, signals...)
-				}
-			}
-
-			// Deployment label violations
-			_, violationCount, err := entity.GetDeploymentsAndViolationCount(clientset)
-			if err == nil && violationCount > 0 {
-				finding := fmt.Sprintf("[MEDIUM] %d deployments missing labels", violationCount)
-				deploymentFindings = append(deploymentFindings, finding)
-				allFindings = append(allFindings, finding)
-				allSignals = append(allSignals, riskposture.Signal{
-					Name:     "DeploymentMissingLabels",
-					Severity: "MEDIUM",
-					Weight:   10,
-				})
-			}
+			var allFindings []findings.Finding
+			var scanned []findings.Resource
+			var rbacFindings, deploymentFindings, controlPlaneFindings, podFindings, secretFindings, networkPolicyFindings []findings.Finding
+			ctx := context.Background()
 
-			// Control plane checks
-			requiredRoles := []string{
-				"system:auth-delegator",
-				"system:certificates.k8s.io:certificatesigningrequests:nodeclient",
-				"system:aggregate-to-admin",
-			}
-			for _, requiredRole := range requiredRoles {
-				_, err := clientset.RbacV1().ClusterRoles().Get(ctx, requiredRole, metav1.GetOptions{})
-				if err != nil {
-					finding := fmt.Sprintf("[HIGH] Required ClusterRole %s not found", requiredRole)
-					controlPlaneFindings = append(controlPlaneFindings, finding)
-					allFindings = append(allFindings, finding)
-					allSignals = append(allSignals, riskposture.Signal{
-						Name:     "MissingRequiredClusterRole",
-						Severity: "HIGH",
-						Weight:   15,
-					})
-				}
+			// Pod and workload security checks, attributed to the owning controller
+			workloadFindings, workloadResources, errs := k8s.ScanWorkloads(ctx, clientset, "")
+			for _, err := range errs {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 			}
-
-			// Deployment Security Checks
-			deployments, err := clientset.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to list deployments: %v\n", err)
+			allFindings = append(allFindings, workloadFindings...)
+			scanned = append(scanned, workloadResources...)
+
+			// RBAC Analysis: custom ClusterRoles, evaluated on their aggregated rules,
+			// the cluster-admin, anonymous and default ServiceAccount grants of the
+			// bindings, dangling bindings and unbound roles, together with the
+			// privilege escalation paths the bindings open
+			var escalationPaths []riskposture.AttackPath
+			if snapshot, err := entity.FetchRBACSnapshot(ctx, clientset); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: Failed to fetch RBAC objects: %v\n", err)
 			} else {
-				for _, deployment := range deployments.Items {
-					k8s.CheckDeploymentSecurity(&deployment)
-				}
+				clusterRoleFindings, clusterRoleResources := snapshot.EvaluateClusterRoles()
+				allFindings = append(allFindings, clusterRoleFindings...)
+				scanned = append(scanned, clusterRoleResources...)
+
+				bindingFindings, bindingResources := snapshot.EvaluateBindings()
+				allFindings = append(allFindings, bindingFindings...)
+				allFindings = append(allFindings, snapshot.DanglingFindings()...)
+				scanned = append(scanned, bindingResources...)
+				escalationPaths = snapshot.EscalationPaths()
 			}
 
-			// ClusterRole Security Checks
-			clusterRolesList, err := clientset.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to list cluster roles: %v\n", err)
-			} else {
-				for _, role := range clusterRolesList.Items {
-					k8s.CheckClusterRoleSecurity(&role)
-				}
-			}
+			// Control plane checks
+			allFindings = append(allFindings, controlchecks.CheckRequiredClusterRoles(ctx, clientset, activePolicy.RequiredClusterRoles)...)
 
 			// Secret Security Checks
 			secrets, err := clientset.CoreV1().Secrets("").List(ctx, metav1.ListOptions{})
@@ -636,29 +1813,18 @@ func reportHTMLCmd() *cobra.Command {
 				fmt.Fprintf(os.Stderr, "Warning: Failed to list secrets: %v\n", err)
 			} else {
 				for _, secret := range secrets.Items {
-					k8s.CheckSecretSecurity(&secret)
+					allFindings = append(allFindings, k8s.CheckSecretSecurity(&secret)...)
+					scanned = append(scanned, k8s.SecretResource(&secret))
 				}
 			}
 
-			// Convert recorded events to findings and categorize
-			events := recorder.SnapShot()
-			for _, event := range events {
-				finding := fmt.Sprintf("[%s] %s/%s in %s: %s",
-					event.Severity, event.ResourceType, event.ResourceName,
-					event.Namespace, event.Message)
-
-				switch event.ResourceType {
-				case "Pod":
-					podFindings = append(podFindings, finding)
-				case "Deployment":
-					deploymentFindings = append(deploymentFindings, finding)
-				case "ClusterRole":
-					rbacFindings = append(rbacFindings, finding)
-				case "Secret":
-					secretFindings = append(secretFindings, finding)
-				}
-
-				allFindings = append(allFindings, finding)
+			// NetworkPolicy coverage of the pods
+			if snapshot, err := netpol.Fetch(ctx, clientset, ""); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: Failed to fetch network policies: %v\n", err)
+			} else {
+				netpolFindings, netpolResources := snapshot.Analyze()
+				allFindings = append(allFindings, netpolFindings...)
+				scanned = append(scanned, netpolResources...)
 			}
 
 			// Vulnerability Report Integration
@@ -668,44 +1834,58 @@ func reportHTMLCmd() *cobra.Command {
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "Warning: Failed to fetch vulnerability reports: %v\n", err)
 				} else {
-					for _, report := range vulnReports {
-						for _, vuln := range report.Report.Vulnerabilities {
-							allFindings = append(allFindings, fmt.Sprintf("Vulnerability: %s - %s",
-								vuln.VulnerabilityID, vuln.Description))
-						}
-					}
+					allFindings = append(allFindings, controlchecks.VulnerabilityFindings(vulnReports)...)
 				}
 			}
 
-			if len(allFindings) == 0 {
-				allFindings = append(allFindings, "No security findings detected")
+			// Disabled rules and accepted risks are dropped before the sections
+			// and the score are built
+			kept, suppressed := suppressions.Apply(rules.Apply(allFindings), time.Now())
+
+			// Categorize findings per report section
+			for _, f := range kept {
+				switch f.Category {
+				case findings.CategoryPodSecurity:
+					podFindings = append(podFindings, f)
+				case findings.CategoryDeploymentSecurity:
+					deploymentFindings = append(deploymentFindings, f)
+				case findings.CategoryRBAC:
+					rbacFindings = append(rbacFindings, f)
+				case findings.CategoryControlPlane:
+					controlPlaneFindings = append(controlPlaneFindings, f)
+				case findings.CategorySecrets:
+					secretFindings = append(secretFindings, f)
+				case findings.CategoryNetworkPolicy:
+					networkPolicyFindings = append(networkPolicyFindings, f)
+				}
 			}
 
-			fmt.Printf("HTML report will be generated at %s\n", outputPath)
-			fmt.Printf("Total findings: %d\n", len(allFindings))
-
-			// Merge signals
-			//parsedSignals := riskposture.SignalsFromFindings(allFindings)
-			//allSignals = append(allSignals, parsedSignals...)
-			rp := riskposture.NewRiskPosture(allSignals)
-
-			// Risk metrics
-			counts := rp.CountRiskLevels()
-			score, drivers := rp.Score()
-			paths := rp.DeriveAttackPaths()
-			fixes := rp.Remediations()
-
-			view := reports.BuildReportView("Comprehensive Kubernetes Security Report", allFindings)
-			view.RiskScore = score
-			view.RiskCounts = counts
-			view.RiskDrivers = drivers
-			view.AttackPaths = paths
-			view.Remediations = fixes
+			// Merge signals and derive the risk posture
+			// Signals are declared by the rules, e.g. MissingRequiredClusterRole
+			view := reports.BuildPostureView("Comprehensive Kubernetes Security Report", kept, nil)
+			view.AttackPaths = append(view.AttackPaths, escalationPaths...)
 			view.RBACFindings = reports.CategorizeFindings(rbacFindings)
 			view.DeploymentFindings = reports.CategorizeFindings(deploymentFindings)
 			view.ControlPlaneFindings = reports.CategorizeFindings(controlPlaneFindings)
 			view.PodFindings = reports.CategorizeFindings(podFindings)
 			view.SecretFindings = reports.CategorizeFindings(secretFindings)
+			view.NetworkPolicyFindings = reports.CategorizeFindings(networkPolicyFindings)
+			view.Resources = scanned
+			view.Suppressed = reports.SuppressedFindings(suppressed)
+			view.IncompleteSections = incomplete
+
+			if outputFormat != reports.FormatTable {
+				writeReport(cmd, outputFormat, view)
+				enforceGate(view)
+				return
+			}
+
+			counts := view.RiskCounts
+			score, drivers := view.RiskScore, view.RiskDrivers
+			paths, fixes := view.AttackPaths, view.Remediations
+
+			fmt.Printf("HTML report will be generated at %s\n", outputPath)
+			fmt.Printf("Total findings: %d (%d suppressed)\n", len(kept), len(suppressed))
 
 			// Console output
 			fmt.Println("\n=== Risk Summary ===")
@@ -725,12 +1905,7 @@ func reportHTMLCmd() *cobra.Command {
 				}
 			}
 
-			if len(paths) > 0 {
-				fmt.Println("\nAttack Paths Detected:")
-				for _, p := range paths {
-					fmt.Printf("  - [%s] %s (confidence %d%%)\n", p.Severity, p.Title, p.Confidence)
-				}
-			}
+			printAttackPaths(paths)
 
 			if len(fixes) > 0 {
 				fmt.Println("\nSuggested Fixes:")
@@ -739,6 +1914,16 @@ func reportHTMLCmd() *cobra.Command {
 				}
 			}
 
+			// In CI the report is written without blocking on the HTTP server
+			if ciGate.Enabled() {
+				if err := reports.GenerateHTMLReportView(view, outputPath); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to generate HTML report: %v\n", err)
+					os.Exit(exitError)
+				}
+				enforceGate(view)
+				return
+			}
+
 			err = reports.ServeHTMLReportView(view, outputPath, port)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to serve HTML report: %v\n", err)
@@ -746,10 +1931,11 @@ func reportHTMLCmd() *cobra.Command {
 			}
 		},
 	}
-	reportHTMLCmd.Flags().StringVarP(&outputPath, "output", "o", "security-report.html", "Output path for the HTML report")
+	reportHTMLCmd.Flags().StringVarP(&outputPath, "out-file", "o", "security-report.html", "Output path for the HTML report")
 	reportHTMLCmd.Flags().StringVarP(&kubeconfig, "kubeconfig", "k", "", "Path to the kubeconfig file")
 	reportHTMLCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Namespace for vulnerability scanning (optional)")
 	reportHTMLCmd.Flags().StringVarP(&port, "port", "p", "8080", "Port to serve the HTML report")
+	reportHTMLCmd.Flags().BoolVar(&preflightFlag, "preflight", false, "Run the doctor checks first and mark the sections the identity cannot fully read")
 
 	return reportHTMLCmd
 }
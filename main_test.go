@@ -141,7 +141,8 @@ func TestReportHTMLCmd(t *testing.T) {
 	assert.Equal(t, "Generate comprehensive HTML security report of all findings in the cluster", cmd.Short)
 
 	// Test flags exist
-	assert.NotNil(t, cmd.Flags().Lookup("output"))
+	assert.NotNil(t, cmd.Flags().Lookup("out-file"))
+	assert.Nil(t, cmd.Flags().Lookup("output"), "the global --output selects the format")
 	assert.NotNil(t, cmd.Flags().Lookup("kubeconfig"))
 	assert.NotNil(t, cmd.Flags().Lookup("namespace"))
 	assert.NotNil(t, cmd.Flags().Lookup("port"))
@@ -222,7 +223,7 @@ func TestReportHTMLCmdFlagsDefaults(t *testing.T) {
 	cmd := reportHTMLCmd()
 
 	// Check default flag values
-	outputFlag := cmd.Flags().Lookup("output")
+	outputFlag := cmd.Flags().Lookup("out-file")
 	assert.NotNil(t, outputFlag)
 	assert.Equal(t, "security-report.html", outputFlag.DefValue)
 
@@ -257,6 +258,7 @@ func TestGlobalFlags(t *testing.T) {
 		{"watch-deployments", "watch-deployments", "bool"},
 		{"watch-secrets", "watch-secrets", "bool"},
 		{"watch-clusterroles", "watch-clusterroles", "bool"},
+		{"watch-workloads", "watch-workloads", "bool"},
 		//{"check", "check", "bool"},
 		{"deployment", "deployment", "bool"},
 		{"risk", "risk", "bool"},
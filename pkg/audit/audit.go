@@ -0,0 +1,205 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Stages an audit event is emitted at
+const (
+	StageRequestReceived  = "RequestReceived"
+	StageResponseComplete = "ResponseComplete"
+	StagePanic            = "Panic"
+)
+
+// Event is the subset of an audit.k8s.io/v1 Event the analyses read
+type Event struct {
+	Level                    string            `json:"level"`
+	AuditID                  string            `json:"auditID"`
+	Stage                    string            `json:"stage"`
+	RequestURI               string            `json:"requestURI"`
+	Verb                     string            `json:"verb"`
+	User                     UserInfo          `json:"user"`
+	ImpersonatedUser         *UserInfo         `json:"impersonatedUser,omitempty"`
+	SourceIPs                []string          `json:"sourceIPs,omitempty"`
+	UserAgent                string            `json:"userAgent,omitempty"`
+	ObjectRef                *ObjectReference  `json:"objectRef,omitempty"`
+	ResponseStatus           *Status           `json:"responseStatus,omitempty"`
+	RequestObject            json.RawMessage   `json:"requestObject,omitempty"`
+	ResponseObject           json.RawMessage   `json:"responseObject,omitempty"`
+	RequestReceivedTimestamp time.Time         `json:"requestReceivedTimestamp"`
+	StageTimestamp           time.Time         `json:"stageTimestamp"`
+	Annotations              map[string]string `json:"annotations,omitempty"`
+}
+
+// UserInfo is the identity that made a request
+type UserInfo struct {
+	Username string   `json:"username"`
+	UID      string   `json:"uid,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// ObjectReference is the object a request acted on
+type ObjectReference struct {
+	Resource    string `json:"resource,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name,omitempty"`
+	APIGroup    string `json:"apiGroup,omitempty"`
+	APIVersion  string `json:"apiVersion,omitempty"`
+	Subresource string `json:"subresource,omitempty"`
+}
+
+// Status is the response code of a request
+type Status struct {
+	Code int `json:"code"`
+}
+
+// Subject returns the identity the request was authorized as: the
+// impersonated user when impersonation was used
+func (e Event) Subject() UserInfo {
+	if e.ImpersonatedUser != nil {
+		return *e.ImpersonatedUser
+	}
+	return e.User
+}
+
+// Completed reports whether the event describes a finished request. Audit
+// policies log a request once per stage, so the analyses count only the
+// final stage.
+func (e Event) Completed() bool {
+	return e.Stage == StageResponseComplete || e.Stage == StagePanic
+}
+
+// Authorized reports whether the request passed authentication and
+// authorization; a 404 on a get was still allowed
+func (e Event) Authorized() bool {
+	if e.ResponseStatus == nil {
+		return true
+	}
+	return e.ResponseStatus.Code != 401 && e.ResponseStatus.Code != 403
+}
+
+// ServiceAccountUsername returns the username a ServiceAccount authenticates as
+func ServiceAccountUsername(namespace, name string) string {
+	return fmt.Sprintf("system:serviceaccount:%s:%s", namespace, name)
+}
+
+// ParseServiceAccount parses a ServiceAccount written as namespace/name
+func ParseServiceAccount(s string) (namespace, name string, err error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid ServiceAccount %q, expected namespace/name", s)
+	}
+	return parts[0], parts[1], nil
+}
+
+// Decode reads audit events written one JSON object per line, as the log
+// backend of the API server does, and calls fn for each. Blank lines are
+// skipped; a malformed line stops decoding with its line number.
+func Decode(r io.Reader, fn func(Event) error) error {
+	scanner := bufio.NewScanner(r)
+	// Events carrying request and response objects exceed the default 64KiB
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if err := decodeLine(scanner.Text(), line, fn); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func decodeLine(text string, line int, fn func(Event) error) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	var event Event
+	if err := json.Unmarshal([]byte(text), &event); err != nil {
+		return fmt.Errorf("line %d: %w", line, err)
+	}
+	return fn(event)
+}
+
+// Follow decodes the events of a log file as it grows, like tail -F: after
+// the existing lines it polls for new ones every interval and reopens the
+// file when it is truncated or rotated. A line is decoded once its newline
+// is written. Follow returns when ctx is done.
+func Follow(ctx context.Context, path string, interval time.Duration, fn func(Event) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+
+	reader := bufio.NewReader(f)
+	var offset int64
+	var pending string
+	line := 0
+	for {
+		chunk, err := reader.ReadString('\n')
+		offset += int64(len(chunk))
+		pending += chunk
+		if err == nil {
+			line++
+			if err := decodeLine(pending, line, fn); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			pending = ""
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+		// A missing file is being rotated; keep reading the old one until
+		// the new one appears
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		current, err := f.Stat()
+		if err == nil && os.SameFile(info, current) && info.Size() >= offset {
+			continue
+		}
+		next, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		f.Close()
+		f, reader = next, bufio.NewReader(next)
+		offset, pending, line = 0, "", 0
+	}
+}
+
+// ReadFile reads every audit event of a log file
+func ReadFile(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []Event
+	err = Decode(f, func(e Event) error {
+		events = append(events, e)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return events, nil
+}
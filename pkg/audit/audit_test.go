@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLog is an audit log of ServiceAccount prod/web, with one request of
+// alice, in the format of the API server's log backend
+const testLog = `{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","auditID":"1","stage":"RequestReceived","requestURI":"/api/v1/namespaces/prod/configmaps/app","verb":"get","user":{"username":"system:serviceaccount:prod:web","groups":["system:serviceaccounts","system:serviceaccounts:prod","system:authenticated"]},"objectRef":{"resource":"configmaps","namespace":"prod","name":"app","apiVersion":"v1"},"requestReceivedTimestamp":"2026-10-01T10:00:00.000000Z","stageTimestamp":"2026-10-01T10:00:00.000000Z"}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","auditID":"1","stage":"ResponseComplete","requestURI":"/api/v1/namespaces/prod/configmaps/app","verb":"get","user":{"username":"system:serviceaccount:prod:web","groups":["system:serviceaccounts","system:serviceaccounts:prod","system:authenticated"]},"objectRef":{"resource":"configmaps","namespace":"prod","name":"app","apiVersion":"v1"},"responseStatus":{"metadata":{},"code":200},"requestReceivedTimestamp":"2026-10-01T10:00:00.000000Z","stageTimestamp":"2026-10-01T10:00:00.010000Z"}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","auditID":"2","stage":"ResponseComplete","requestURI":"/api/v1/namespaces/prod/configmaps?watch=true","verb":"watch","user":{"username":"system:serviceaccount:prod:web"},"objectRef":{"resource":"configmaps","namespace":"prod","apiVersion":"v1"},"responseStatus":{"metadata":{},"code":200},"requestReceivedTimestamp":"2026-10-01T10:00:01.000000Z","stageTimestamp":"2026-10-01T10:05:01.000000Z"}
+
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","auditID":"3","stage":"ResponseComplete","requestURI":"/apis/apps/v1/namespaces/prod/deployments/web/scale","verb":"patch","user":{"username":"system:serviceaccount:prod:web"},"objectRef":{"resource":"deployments","namespace":"prod","name":"web","apiGroup":"apps","apiVersion":"v1","subresource":"scale"},"responseStatus":{"metadata":{},"code":200},"requestReceivedTimestamp":"2026-10-01T10:00:02.000000Z","stageTimestamp":"2026-10-01T10:00:02.010000Z"}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","auditID":"4","stage":"ResponseComplete","requestURI":"/api/v1/nodes","verb":"list","user":{"username":"system:serviceaccount:prod:web"},"objectRef":{"resource":"nodes","apiVersion":"v1"},"responseStatus":{"metadata":{},"code":200},"requestReceivedTimestamp":"2026-10-01T10:00:03.000000Z","stageTimestamp":"2026-10-01T10:00:03.010000Z"}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","auditID":"5","stage":"ResponseComplete","requestURI":"/api/v1/namespaces/prod/secrets","verb":"list","user":{"username":"system:serviceaccount:prod:web"},"objectRef":{"resource":"secrets","namespace":"prod","apiVersion":"v1"},"responseStatus":{"metadata":{},"code":403},"requestReceivedTimestamp":"2026-10-01T10:00:04.000000Z","stageTimestamp":"2026-10-01T10:00:04.010000Z"}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","auditID":"6","stage":"ResponseComplete","requestURI":"/healthz?verbose","verb":"get","user":{"username":"system:serviceaccount:prod:web"},"responseStatus":{"metadata":{},"code":200},"requestReceivedTimestamp":"2026-10-01T10:00:05.000000Z","stageTimestamp":"2026-10-01T10:00:05.010000Z"}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","auditID":"7","stage":"ResponseComplete","requestURI":"/api/v1/namespaces/prod/configmaps/app","verb":"get","user":{"username":"system:serviceaccount:prod:web"},"objectRef":{"resource":"configmaps","namespace":"prod","name":"app","apiVersion":"v1"},"responseStatus":{"metadata":{},"code":200},"requestReceivedTimestamp":"2026-10-01T10:10:00.000000Z","stageTimestamp":"2026-10-01T10:10:00.010000Z"}
+{"kind":"Event","apiVersion":"audit.k8s.io/v1","level":"Metadata","auditID":"8","stage":"ResponseComplete","requestURI":"/api/v1/namespaces/prod/pods","verb":"list","user":{"username":"alice"},"objectRef":{"resource":"pods","namespace":"prod","apiVersion":"v1"},"responseStatus":{"metadata":{},"code":200},"requestReceivedTimestamp":"2026-10-01T10:00:06.000000Z","stageTimestamp":"2026-10-01T10:00:06.010000Z"}
+`
+
+func readTestLog(t *testing.T) []Event {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(testLog), 0o600))
+	events, err := ReadFile(path)
+	require.NoError(t, err)
+	return events
+}
+
+func TestReadFile(t *testing.T) {
+	events := readTestLog(t)
+	require.Len(t, events, 9, "blank lines are skipped")
+
+	assert.False(t, events[0].Completed())
+	assert.True(t, events[1].Completed())
+	assert.Equal(t, "system:serviceaccount:prod:web", events[1].Subject().Username)
+	assert.Equal(t, "configmaps", events[1].ObjectRef.Resource)
+	assert.False(t, events[5].Authorized())
+
+	err := Decode(strings.NewReader("{}\n{broken\n"), func(Event) error { return nil })
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestEventSubjectPrefersImpersonatedUser(t *testing.T) {
+	e := Event{User: UserInfo{Username: "alice"}, ImpersonatedUser: &UserInfo{Username: "system:admin"}}
+	assert.Equal(t, "system:admin", e.Subject().Username)
+}
+
+func TestParseServiceAccount(t *testing.T) {
+	namespace, name, err := ParseServiceAccount("prod/web")
+	require.NoError(t, err)
+	assert.Equal(t, "system:serviceaccount:prod:web", ServiceAccountUsername(namespace, name))
+
+	_, _, err = ParseServiceAccount("web")
+	assert.Error(t, err)
+}
+
+func TestFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(`{"auditID":"1"}`+"\n"+`{"auditID":`), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ids := make(chan string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- Follow(ctx, path, 5*time.Millisecond, func(e Event) error {
+			ids <- e.AuditID
+			return nil
+		})
+	}()
+	next := func() string {
+		select {
+		case id := <-ids:
+			return id
+		case <-time.After(5 * time.Second):
+			t.Fatal("no event followed")
+			return ""
+		}
+	}
+	assert.Equal(t, "1", next())
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`"2"}` + "\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	assert.Equal(t, "2", next(), "a line is decoded once completed")
+
+	// Rotation replaces the file; the new one is read from the start
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, os.WriteFile(path, []byte(`{"auditID":"3"}`+"\n"), 0o600))
+	assert.Equal(t, "3", next())
+
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,374 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"kspm/pkg/entity"
+	"kspm/pkg/findings"
+	"kspm/pkg/k8s"
+	"kspm/pkg/rules"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// Rule IDs of the events raised by the Detector
+const (
+	RuleExecPrivilegedPod  = "AUDIT-EXEC-PRIVILEGED-POD"
+	RuleUnusualSecretRead  = "AUDIT-UNUSUAL-SECRET-READ"
+	RuleAnonymousRequest   = "AUDIT-ANONYMOUS-REQUEST"
+	RuleClusterAdminGrant  = "AUDIT-CLUSTER-ADMIN-GRANT"
+	RulePrivilegedPodAdded = "AUDIT-PRIVILEGED-POD-CREATED"
+)
+
+// The events are raised from audit log requests, so the rules carry no
+// Check and apply to audit events rather than cluster objects. Severities
+// are graded per request unless overridden.
+func init() {
+	for _, rule := range auditRules {
+		rules.MustDefine(rule)
+	}
+}
+
+// auditKinds is the kind the audit rules apply to
+var auditKinds = []string{"AuditEvent"}
+
+var auditRules = []rules.Rule{
+	{
+		ID:          RuleExecPrivilegedPod,
+		Kinds:       auditKinds,
+		Severity:    findings.SeverityCritical,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Exec or attach into a privileged pod",
+		Remediation: "Confirm the session was expected and restrict pods/exec to break-glass identities",
+	},
+	{
+		ID:          RuleUnusualSecretRead,
+		Kinds:       auditKinds,
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategorySecrets,
+		Description: "Secret read by an identity outside the expected secret readers",
+		Remediation: "Confirm the read was expected, rotate the secrets otherwise and narrow the granting role",
+	},
+	{
+		ID:          RuleAnonymousRequest,
+		Kinds:       auditKinds,
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryRBAC,
+		Description: "Anonymous request outside the public health and version endpoints",
+		Remediation: "Disable anonymous auth with --anonymous-auth=false or remove the bindings to system:anonymous",
+	},
+	{
+		ID:          RuleClusterAdminGrant,
+		Kinds:       auditKinds,
+		Severity:    findings.SeverityCritical,
+		Category:    findings.CategoryRBAC,
+		Description: "Binding granting cluster-admin was written",
+		Remediation: "Confirm the grant was expected and delete the binding otherwise",
+	},
+	{
+		ID:          RulePrivilegedPodAdded,
+		Kinds:       auditKinds,
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Privileged pod was created",
+		Remediation: "Enforce the baseline Pod Security Standard on the namespace",
+	},
+}
+
+// DefaultSecretReaders are the identities expected to read secrets: the
+// control plane components, kube-system controllers and the kubelets, whose
+// reads the node authorizer already restricts
+var DefaultSecretReaders = []string{
+	"system:kube-controller-manager",
+	"system:kube-scheduler",
+	"system:apiserver",
+	"system:serviceaccount:kube-system:*",
+	"system:node:*",
+}
+
+// publicPaths are the non-resource URLs the API server serves anonymously by default
+var publicPaths = []string{"/healthz", "/livez", "/readyz", "/version"}
+
+// privilegedSignals are the risk signals of the pod rules that make a pod privileged
+var privilegedSignals = map[string]bool{"PrivilegedPod": true, "PrivilegedWorkload": true}
+
+// PodLookup fetches a pod the log does not show the creation of
+type PodLookup func(namespace, name string) (*corev1.Pod, error)
+
+// Detector raises security events for suspicious requests in an audit log:
+// exec and attach into privileged pods, secret reads by identities outside
+// SecretReaders, anonymous requests, bindings granting cluster-admin and the
+// creation of privileged pods. The audit policy must log pod and binding
+// writes at the Request level or above for their bodies to be inspected.
+type Detector struct {
+	// Handler receives the events, as it does from the watchers
+	Handler k8s.SecurityEventHandler
+	// SecretReaders lists the usernames allowed to read secrets, with * globs
+	SecretReaders []string
+	// Pods resolves exec targets created before the log starts; optional
+	Pods PodLookup
+
+	// privileged maps namespace/name of the pods known to be privileged to
+	// the reasons, nil for pods known not to be
+	privileged  map[string][]string
+	secretReads map[string]bool
+}
+
+// NewDetector creates a Detector reporting to handler with the default secret readers
+func NewDetector(handler k8s.SecurityEventHandler) *Detector {
+	return &Detector{
+		Handler:       handler,
+		SecretReaders: DefaultSecretReaders,
+		privileged:    map[string][]string{},
+		secretReads:   map[string]bool{},
+	}
+}
+
+// Process inspects one audit event. Only the final stage of a request is
+// inspected so that a request logged at several stages is reported once.
+func (d *Detector) Process(e Event) {
+	if !e.Completed() {
+		return
+	}
+	d.checkAnonymous(e)
+	if e.ObjectRef == nil || !e.Authorized() {
+		return
+	}
+	ref := e.ObjectRef
+	switch {
+	case ref.Resource == "pods" && ref.APIGroup == "" && ref.Subresource == "":
+		d.trackPod(e)
+	case ref.Resource == "pods" && (ref.Subresource == "exec" || ref.Subresource == "attach"):
+		d.checkExec(e)
+	case ref.Resource == "secrets" && ref.APIGroup == "":
+		d.checkSecretRead(e)
+	case (ref.Resource == "clusterrolebindings" || ref.Resource == "rolebindings") && ref.APIGroup == rbacv1.GroupName:
+		d.checkClusterAdminGrant(e)
+	}
+}
+
+func (d *Detector) report(e Event, severity findings.Severity, ruleID, resourceType, name, namespace, message string) {
+	if !rules.Default.Enabled(ruleID) {
+		return
+	}
+	timestamp := e.StageTimestamp
+	if timestamp.IsZero() {
+		timestamp = e.RequestReceivedTimestamp
+	}
+	if namespace == "" {
+		namespace = "cluster-wide"
+	}
+	d.Handler.HandleEvent(k8s.SecurityEvent{
+		Timestamp:    timestamp,
+		Severity:     string(rules.EffectiveSeverity(ruleID, severity)),
+		RuleID:       ruleID,
+		ResourceType: resourceType,
+		ResourceName: name,
+		Namespace:    namespace,
+		Message:      message + actor(e),
+	})
+}
+
+// actor describes who made a request, for event messages
+func actor(e Event) string {
+	s := " by " + e.Subject().Username
+	if e.ImpersonatedUser != nil {
+		s += " (impersonated by " + e.User.Username + ")"
+	}
+	if len(e.SourceIPs) > 0 {
+		s += " from " + e.SourceIPs[0]
+	}
+	return s
+}
+
+// checkAnonymous flags requests from unauthenticated clients other than to
+// the public health and version endpoints
+func (d *Detector) checkAnonymous(e Event) {
+	subject := e.Subject()
+	anonymous := subject.Username == "system:anonymous"
+	for _, g := range subject.Groups {
+		anonymous = anonymous || g == "system:unauthenticated"
+	}
+	if !anonymous {
+		return
+	}
+	namespace, req := requestOf(e)
+	if req.NonResourceURL != "" {
+		for _, p := range publicPaths {
+			if req.NonResourceURL == p || strings.HasPrefix(req.NonResourceURL, p+"/") {
+				return
+			}
+		}
+	}
+
+	resourceType, name := "NonResourceURL", req.NonResourceURL
+	if e.ObjectRef != nil {
+		resourceType, name = e.ObjectRef.Resource, e.ObjectRef.Name
+	}
+	if e.Authorized() {
+		d.report(e, findings.SeverityHigh, RuleAnonymousRequest, resourceType, name, namespace,
+			fmt.Sprintf("anonymous request allowed: %s", req))
+		return
+	}
+	d.report(e, findings.SeverityLow, RuleAnonymousRequest, resourceType, name, namespace,
+		fmt.Sprintf("anonymous request denied: %s", req))
+}
+
+// trackPod records whether created pods are privileged, reporting the
+// privileged ones, and forgets deleted pods
+func (d *Detector) trackPod(e Event) {
+	ref := e.ObjectRef
+	switch e.Verb {
+	case "delete":
+		delete(d.privileged, ref.Namespace+"/"+ref.Name)
+		return
+	case "create", "update", "patch":
+	default:
+		return
+	}
+
+	// The response carries the name of a pod created with generateName and
+	// the full object of a patch; the request object is the fallback
+	pod := decodePod(e.ResponseObject)
+	if pod == nil && e.Verb != "patch" {
+		pod = decodePod(e.RequestObject)
+	}
+	if pod == nil {
+		return
+	}
+	if pod.Namespace == "" {
+		pod.Namespace = ref.Namespace
+	}
+	if pod.Name == "" {
+		pod.Name = ref.Name
+	}
+	reasons := privilegedReasons(pod)
+	d.privileged[pod.Namespace+"/"+pod.Name] = reasons
+	if e.Verb == "create" && len(reasons) > 0 {
+		name := pod.Name
+		if name == "" {
+			name = pod.GenerateName + "*"
+		}
+		d.report(e, findings.SeverityHigh, RulePrivilegedPodAdded, "Pod", name, pod.Namespace,
+			fmt.Sprintf("privileged pod created (%s)", strings.Join(reasons, "; ")))
+	}
+}
+
+func decodePod(raw json.RawMessage) *corev1.Pod {
+	if len(raw) == 0 {
+		return nil
+	}
+	var pod corev1.Pod
+	if err := json.Unmarshal(raw, &pod); err != nil || len(pod.Spec.Containers) == 0 {
+		return nil
+	}
+	return &pod
+}
+
+// privilegedReasons lists the violations of the pod rules mapped onto a
+// privileged risk signal
+func privilegedReasons(pod *corev1.Pod) []string {
+	var out []string
+	for _, f := range rules.Evaluate(k8s.PodResource(pod), pod) {
+		if rule, ok := rules.Get(f.RuleID); ok && privilegedSignals[rule.Signal] {
+			out = append(out, f.Message)
+		}
+	}
+	return out
+}
+
+// checkExec flags exec and attach into pods known to be privileged
+func (d *Detector) checkExec(e Event) {
+	ref := e.ObjectRef
+	key := ref.Namespace + "/" + ref.Name
+	reasons, known := d.privileged[key]
+	if !known && d.Pods != nil {
+		if pod, err := d.Pods(ref.Namespace, ref.Name); err == nil {
+			reasons = privilegedReasons(pod)
+			d.privileged[key] = reasons
+		}
+	}
+	if len(reasons) == 0 {
+		return
+	}
+	command := ""
+	if u, err := url.Parse(e.RequestURI); err == nil && len(u.Query()["command"]) > 0 {
+		command = fmt.Sprintf(" running %q", strings.Join(u.Query()["command"], " "))
+	}
+	d.report(e, findings.SeverityCritical, RuleExecPrivilegedPod, "Pod", ref.Name, ref.Namespace,
+		fmt.Sprintf("%s into privileged pod%s (%s)", ref.Subresource, command, strings.Join(reasons, "; ")))
+}
+
+// checkSecretRead flags secret reads by identities outside SecretReaders,
+// once per identity, verb and namespace. Listing or watching all the secrets
+// of a namespace is rated above reading a single one.
+func (d *Detector) checkSecretRead(e Event) {
+	if e.Verb != "get" && e.Verb != "list" && e.Verb != "watch" {
+		return
+	}
+	username := e.Subject().Username
+	for _, pattern := range d.SecretReaders {
+		if ok, _ := path.Match(pattern, username); ok {
+			return
+		}
+	}
+	ref := e.ObjectRef
+	key := username + "\x00" + e.Verb + "\x00" + ref.Namespace
+	if d.secretReads[key] {
+		return
+	}
+	d.secretReads[key] = true
+
+	severity := findings.SeverityMedium
+	if e.Verb != "get" {
+		severity = findings.SeverityHigh
+	}
+	d.report(e, severity, RuleUnusualSecretRead, "Secret", ref.Name, ref.Namespace,
+		fmt.Sprintf("unusual secret %s", e.Verb))
+}
+
+// checkClusterAdminGrant flags bindings written with cluster-admin as their role
+func (d *Detector) checkClusterAdminGrant(e Event) {
+	if e.Verb != "create" && e.Verb != "update" && e.Verb != "patch" {
+		return
+	}
+	binding, ok := decodeBinding(e.ResponseObject)
+	if !ok {
+		binding, ok = decodeBinding(e.RequestObject)
+	}
+	if !ok || binding.RoleRef.Kind != "ClusterRole" || binding.RoleRef.Name != "cluster-admin" {
+		return
+	}
+	var subjects []string
+	for _, s := range binding.Subjects {
+		subjects = append(subjects, entity.SubjectString(s))
+	}
+	sort.Strings(subjects)
+
+	kind := "ClusterRoleBinding"
+	if e.ObjectRef.Resource == "rolebindings" {
+		kind = "RoleBinding"
+	}
+	name := binding.Name
+	if name == "" {
+		name = e.ObjectRef.Name
+	}
+	d.report(e, findings.SeverityCritical, RuleClusterAdminGrant, kind, name, e.ObjectRef.Namespace,
+		fmt.Sprintf("cluster-admin granted to %s", strings.Join(subjects, ", ")))
+}
+
+// decodeBinding reads the roleRef and subjects of a RoleBinding or
+// ClusterRoleBinding, which share their layout
+func decodeBinding(raw json.RawMessage) (rbacv1.ClusterRoleBinding, bool) {
+	var binding rbacv1.ClusterRoleBinding
+	if len(raw) == 0 || json.Unmarshal(raw, &binding) != nil || binding.RoleRef.Name == "" {
+		return binding, false
+	}
+	return binding, true
+}
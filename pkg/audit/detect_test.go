@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"kspm/pkg/k8s"
+	"kspm/pkg/rules"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const detectLog = `
+{"stage":"ResponseComplete","verb":"create","user":{"username":"alice"},"sourceIPs":["10.0.0.7"],"objectRef":{"resource":"pods","namespace":"prod","name":"debug"},"responseStatus":{"code":201},"requestObject":{"kind":"Pod","metadata":{"name":"debug"},"spec":{"hostPID":true,"containers":[{"name":"sh","image":"busybox"}]}}}
+{"stage":"RequestReceived","verb":"create","user":{"username":"alice"},"objectRef":{"resource":"pods","namespace":"prod","name":"debug","subresource":"exec"}}
+{"stage":"ResponseComplete","verb":"create","requestURI":"/api/v1/namespaces/prod/pods/debug/exec?command=sh&stdin=true","user":{"username":"alice"},"objectRef":{"resource":"pods","namespace":"prod","name":"debug","subresource":"exec"},"responseStatus":{"code":101}}
+{"stage":"ResponseComplete","verb":"create","user":{"username":"alice"},"objectRef":{"resource":"pods","namespace":"prod","name":"web-1","subresource":"exec"},"responseStatus":{"code":101}}
+{"stage":"ResponseComplete","verb":"create","user":{"username":"alice"},"objectRef":{"resource":"pods","namespace":"prod","name":"node-agent","subresource":"attach"},"responseStatus":{"code":101}}
+{"stage":"ResponseComplete","verb":"list","user":{"username":"system:serviceaccount:prod:web"},"objectRef":{"resource":"secrets","namespace":"prod"},"responseStatus":{"code":200}}
+{"stage":"ResponseComplete","verb":"list","user":{"username":"system:serviceaccount:prod:web"},"objectRef":{"resource":"secrets","namespace":"prod"},"responseStatus":{"code":200}}
+{"stage":"ResponseComplete","verb":"get","user":{"username":"system:serviceaccount:kube-system:replicaset-controller"},"objectRef":{"resource":"secrets","namespace":"prod","name":"tls"},"responseStatus":{"code":200}}
+{"stage":"ResponseComplete","verb":"get","requestURI":"/healthz","user":{"username":"system:anonymous","groups":["system:unauthenticated"]},"responseStatus":{"code":200}}
+{"stage":"ResponseComplete","verb":"list","requestURI":"/api/v1/namespaces/prod/configmaps","user":{"username":"system:anonymous","groups":["system:unauthenticated"]},"objectRef":{"resource":"configmaps","namespace":"prod"},"responseStatus":{"code":200}}
+{"stage":"ResponseComplete","verb":"create","user":{"username":"bob"},"impersonatedUser":{"username":"admin"},"objectRef":{"resource":"clusterrolebindings","apiGroup":"rbac.authorization.k8s.io","name":"backdoor"},"responseStatus":{"code":201},"requestObject":{"kind":"ClusterRoleBinding","metadata":{"name":"backdoor"},"roleRef":{"apiGroup":"rbac.authorization.k8s.io","kind":"ClusterRole","name":"cluster-admin"},"subjects":[{"kind":"ServiceAccount","name":"web","namespace":"prod"}]}}
+{"stage":"ResponseComplete","verb":"create","user":{"username":"bob"},"objectRef":{"resource":"rolebindings","apiGroup":"rbac.authorization.k8s.io","namespace":"prod","name":"viewers"},"responseStatus":{"code":201},"requestObject":{"kind":"RoleBinding","metadata":{"name":"viewers"},"roleRef":{"apiGroup":"rbac.authorization.k8s.io","kind":"ClusterRole","name":"view"},"subjects":[{"kind":"Group","name":"devs"}]}}
+`
+
+func TestDetector(t *testing.T) {
+	recorder := &k8s.RecordingSecurityEventHandler{}
+	detector := NewDetector(recorder)
+	detector.Pods = func(namespace, name string) (*corev1.Pod, error) {
+		privileged := name == "node-agent"
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:            "agent",
+				SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+			}}},
+		}, nil
+	}
+	require.NoError(t, Decode(strings.NewReader(detectLog), func(e Event) error {
+		detector.Process(e)
+		return nil
+	}))
+
+	var got []string
+	for _, e := range recorder.SnapShot() {
+		got = append(got, fmt.Sprintf("%s %s %s/%s/%s", e.Severity, e.RuleID, e.Namespace, e.ResourceType, e.ResourceName))
+	}
+	assert.Equal(t, []string{
+		"HIGH AUDIT-PRIVILEGED-POD-CREATED prod/Pod/debug",
+		"CRITICAL AUDIT-EXEC-PRIVILEGED-POD prod/Pod/debug",
+		"CRITICAL AUDIT-EXEC-PRIVILEGED-POD prod/Pod/node-agent",
+		"HIGH AUDIT-UNUSUAL-SECRET-READ prod/Secret/",
+		"HIGH AUDIT-ANONYMOUS-REQUEST prod/configmaps/",
+		"CRITICAL AUDIT-CLUSTER-ADMIN-GRANT cluster-wide/ClusterRoleBinding/backdoor",
+	}, got)
+
+	events := recorder.SnapShot()
+	assert.Contains(t, events[1].Message, `exec into privileged pod running "sh" (Pod has hostPID access`)
+	assert.Contains(t, events[1].Message, "by alice")
+	assert.Equal(t, "cluster-admin granted to ServiceAccount/prod/web by admin (impersonated by bob)", events[5].Message)
+}
+
+func TestDetectorDisabledRule(t *testing.T) {
+	require.NoError(t, rules.Disable(RuleAnonymousRequest))
+	t.Cleanup(func() { require.NoError(t, rules.Enable(RuleAnonymousRequest)) })
+
+	recorder := &k8s.RecordingSecurityEventHandler{}
+	detector := NewDetector(recorder)
+	require.NoError(t, Decode(strings.NewReader(detectLog), func(e Event) error {
+		detector.Process(e)
+		return nil
+	}))
+
+	require.NotEmpty(t, recorder.SnapShot())
+	for _, e := range recorder.SnapShot() {
+		assert.NotEqual(t, RuleAnonymousRequest, e.RuleID)
+		_, ok := rules.Get(e.RuleID)
+		assert.True(t, ok, "%s is defined in the rule registry", e.RuleID)
+	}
+}
@@ -0,0 +1,317 @@
+package audit
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"kspm/pkg/entity"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// Access is a request a subject was observed making, and how often
+type Access struct {
+	// Namespace is empty for cluster-scoped and all-namespace requests
+	Namespace string                 `json:"namespace,omitempty"`
+	Request   entity.ResourceRequest `json:"request"`
+	Count     int                    `json:"count"`
+}
+
+// requestOf converts an event into the access it needed
+func requestOf(e Event) (string, entity.ResourceRequest) {
+	if e.ObjectRef == nil {
+		path := e.RequestURI
+		if u, err := url.Parse(e.RequestURI); err == nil {
+			path = u.Path
+		}
+		return "", entity.ResourceRequest{Verb: e.Verb, NonResourceURL: path}
+	}
+	return e.ObjectRef.Namespace, entity.ResourceRequest{
+		Verb:        e.Verb,
+		APIGroup:    e.ObjectRef.APIGroup,
+		Resource:    e.ObjectRef.Resource,
+		Subresource: e.ObjectRef.Subresource,
+	}
+}
+
+// Observed lists the distinct authorized requests the user made, sorted by
+// namespace and request
+func Observed(events []Event, username string) []Access {
+	return observedBy(events, func(u UserInfo) bool { return u.Username == username })
+}
+
+// observedBy lists the distinct authorized requests of the identities match accepts
+func observedBy(events []Event, match func(UserInfo) bool) []Access {
+	index := map[string]*Access{}
+	for _, e := range events {
+		if !e.Completed() || !e.Authorized() || !match(e.Subject()) {
+			continue
+		}
+		namespace, req := requestOf(e)
+		key := namespace + "\x00" + req.APIGroup + "\x00" + req.String()
+		if a, ok := index[key]; ok {
+			a.Count++
+			continue
+		}
+		index[key] = &Access{Namespace: namespace, Request: req, Count: 1}
+	}
+
+	out := make([]Access, 0, len(index))
+	for _, a := range index {
+		out = append(out, *a)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Request.APIGroup != b.Request.APIGroup {
+			return a.Request.APIGroup < b.Request.APIGroup
+		}
+		return a.Request.String() < b.Request.String()
+	})
+	return out
+}
+
+// ScopedRule is a rule and the namespace it is granted in, empty for
+// cluster-wide
+type ScopedRule struct {
+	Namespace string            `json:"namespace,omitempty"`
+	Rule      entity.PolicyRule `json:"rule"`
+}
+
+// MinimalRules computes the smallest rule set allowing every observed
+// access: namespaced accesses become rules of their namespace, cluster-scoped
+// and all-namespace accesses and non-resource URLs cluster-wide rules.
+// Resources of one API group used with the same verbs share a rule.
+func MinimalRules(observed []Access) []ScopedRule {
+	type target struct{ namespace, group, resource string }
+	verbs := map[target]map[string]bool{}
+	urls := map[string]map[string]bool{}
+	for _, a := range observed {
+		if a.Request.NonResourceURL != "" {
+			if urls[a.Request.NonResourceURL] == nil {
+				urls[a.Request.NonResourceURL] = map[string]bool{}
+			}
+			urls[a.Request.NonResourceURL][a.Request.Verb] = true
+			continue
+		}
+		resource := a.Request.Resource
+		if a.Request.Subresource != "" {
+			resource += "/" + a.Request.Subresource
+		}
+		t := target{a.Namespace, a.Request.APIGroup, resource}
+		if verbs[t] == nil {
+			verbs[t] = map[string]bool{}
+		}
+		verbs[t][a.Request.Verb] = true
+	}
+
+	// Merge the resources sharing a namespace, API group and verb set
+	type group struct{ namespace, group, verbs string }
+	resources := map[group][]string{}
+	for t, set := range verbs {
+		g := group{t.namespace, t.group, strings.Join(sortedKeys(set), ",")}
+		resources[g] = append(resources[g], t.resource)
+	}
+	var out []ScopedRule
+	for g, list := range resources {
+		sort.Strings(list)
+		out = append(out, ScopedRule{Namespace: g.namespace, Rule: entity.PolicyRule{
+			Verbs:     strings.Split(g.verbs, ","),
+			APIGroups: []string{g.group},
+			Resources: list,
+		}})
+	}
+	nonResource := map[string][]string{}
+	for path, set := range urls {
+		key := strings.Join(sortedKeys(set), ",")
+		nonResource[key] = append(nonResource[key], path)
+	}
+	for key, paths := range nonResource {
+		sort.Strings(paths)
+		out = append(out, ScopedRule{Rule: entity.PolicyRule{Verbs: strings.Split(key, ","), NonResourceURLs: paths}})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return FormatRule(a.Rule) < FormatRule(b.Rule)
+	})
+	return out
+}
+
+func sortedKeys(set map[string]bool) []string {
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// FormatRule renders a rule on one line
+func FormatRule(rule entity.PolicyRule) string {
+	if len(rule.NonResourceURLs) > 0 {
+		return fmt.Sprintf("verbs=%v nonResourceURLs=%v", rule.Verbs, rule.NonResourceURLs)
+	}
+	s := fmt.Sprintf("verbs=%v apiGroups=%q resources=%v", rule.Verbs, rule.APIGroups, rule.Resources)
+	if len(rule.ResourceNames) > 0 {
+		s += fmt.Sprintf(" resourceNames=%v", rule.ResourceNames)
+	}
+	return s
+}
+
+// SuggestedName is the name of the generated roles and bindings
+func SuggestedName(serviceAccount string) string {
+	return serviceAccount + "-minimal"
+}
+
+// Manifests renders the rules as one Role per namespace and a ClusterRole
+// for the cluster-wide rules, each bound to the ServiceAccount
+func Manifests(namespace, name string, rules []ScopedRule) []runtime.Object {
+	subject := rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: namespace, Name: name}
+	roleName := SuggestedName(name)
+
+	var namespaces []string
+	byNamespace := map[string][]rbacv1.PolicyRule{}
+	for _, r := range rules {
+		if _, ok := byNamespace[r.Namespace]; !ok {
+			namespaces = append(namespaces, r.Namespace)
+		}
+		byNamespace[r.Namespace] = append(byNamespace[r.Namespace], rbacv1.PolicyRule(r.Rule))
+	}
+
+	var out []runtime.Object
+	for _, ns := range namespaces {
+		if ns == "" {
+			out = append(out,
+				&rbacv1.ClusterRole{
+					TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+					ObjectMeta: metav1.ObjectMeta{Name: roleName},
+					Rules:      byNamespace[ns],
+				},
+				&rbacv1.ClusterRoleBinding{
+					TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+					ObjectMeta: metav1.ObjectMeta{Name: roleName},
+					RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: roleName},
+					Subjects:   []rbacv1.Subject{subject},
+				})
+			continue
+		}
+		out = append(out,
+			&rbacv1.Role{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+				ObjectMeta: metav1.ObjectMeta{Name: roleName, Namespace: ns},
+				Rules:      byNamespace[ns],
+			},
+			&rbacv1.RoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+				ObjectMeta: metav1.ObjectMeta{Name: roleName, Namespace: ns},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: roleName},
+				Subjects:   []rbacv1.Subject{subject},
+			})
+	}
+	return out
+}
+
+// ManifestYAML renders objects as a multi-document YAML stream
+func ManifestYAML(objects []runtime.Object) (string, error) {
+	var docs []string
+	for _, obj := range objects {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", err
+		}
+		docs = append(docs, string(data))
+	}
+	return strings.Join(docs, "---\n"), nil
+}
+
+// DiffLine is one rule of the comparison between the bound and the
+// suggested rules. Op is "-" for a bound rule the suggestion drops, "+" for
+// a suggested rule not bound as-is and " " for a rule both share.
+type DiffLine struct {
+	Op        string            `json:"op"`
+	Namespace string            `json:"namespace,omitempty"`
+	Rule      entity.PolicyRule `json:"rule"`
+	// Source is the binding and role granting a bound rule
+	Source string `json:"source,omitempty"`
+	// Unused is set on bound rules no observed request needed
+	Unused bool `json:"unused,omitempty"`
+}
+
+// Scope renders the namespace the rule applies to
+func (d DiffLine) Scope() string {
+	if d.Namespace == "" {
+		return "cluster-wide"
+	}
+	return d.Namespace
+}
+
+// Diff compares the rules bound to a subject with the suggested ones
+func Diff(bound entity.SubjectPermissions, suggested []ScopedRule, observed []Access) []DiffLine {
+	matched := make([]bool, len(suggested))
+	var out []DiffLine
+	for _, g := range bound.Grants {
+		source := grantSource(g)
+		for _, rule := range g.Rules {
+			line := DiffLine{Op: "-", Namespace: g.Namespace, Rule: entity.PolicyRule(rule), Source: source, Unused: true}
+			for i, s := range suggested {
+				if s.Namespace == g.Namespace && equality.Semantic.DeepEqual(normalized(s.Rule), normalized(line.Rule)) {
+					line.Op = " "
+					matched[i] = true
+				}
+			}
+			for _, a := range observed {
+				if g.Namespace != "" && a.Namespace != g.Namespace {
+					continue
+				}
+				if ok, _ := entity.RuleAllows(rule, a.Request); ok {
+					line.Unused = false
+					break
+				}
+			}
+			out = append(out, line)
+		}
+	}
+	for i, s := range suggested {
+		if !matched[i] {
+			out = append(out, DiffLine{Op: "+", Namespace: s.Namespace, Rule: s.Rule})
+		}
+	}
+	return out
+}
+
+// grantSource names the binding and role of a grant
+func grantSource(g entity.Grant) string {
+	source := fmt.Sprintf("%s/%s -> %s/%s", g.Binding.Kind, g.Binding.Name, g.RoleRef.Kind, g.RoleRef.Name)
+	if g.Via != "" {
+		source += " via " + g.Via
+	}
+	return source
+}
+
+// normalized sorts the lists of a rule so rules differing only in order compare equal
+func normalized(rule entity.PolicyRule) entity.PolicyRule {
+	sorted := func(list []string) []string {
+		out := append([]string(nil), list...)
+		sort.Strings(out)
+		return out
+	}
+	return entity.PolicyRule{
+		Verbs:           sorted(rule.Verbs),
+		APIGroups:       sorted(rule.APIGroups),
+		Resources:       sorted(rule.Resources),
+		ResourceNames:   sorted(rule.ResourceNames),
+		NonResourceURLs: sorted(rule.NonResourceURLs),
+	}
+}
@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"testing"
+
+	"kspm/pkg/entity"
+	"kspm/pkg/findings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestObserved(t *testing.T) {
+	observed := Observed(readTestLog(t), ServiceAccountUsername("prod", "web"))
+
+	var got []string
+	for _, a := range observed {
+		got = append(got, a.Namespace+" "+a.Request.String())
+	}
+	assert.Equal(t, []string{
+		" get /healthz",
+		" list nodes",
+		"prod get configmaps",
+		"prod watch configmaps",
+		"prod patch deployments.apps/scale",
+	}, got, "denied requests and other users are left out")
+	assert.Equal(t, 2, observed[2].Count, "stages of one request are counted once")
+}
+
+func TestMinimalRules(t *testing.T) {
+	rules := MinimalRules(Observed(readTestLog(t), ServiceAccountUsername("prod", "web")))
+	assert.Equal(t, []ScopedRule{
+		{Rule: entity.PolicyRule{Verbs: []string{"get"}, NonResourceURLs: []string{"/healthz"}}},
+		{Rule: entity.PolicyRule{Verbs: []string{"list"}, APIGroups: []string{""}, Resources: []string{"nodes"}}},
+		{Namespace: "prod", Rule: entity.PolicyRule{Verbs: []string{"get", "watch"}, APIGroups: []string{""}, Resources: []string{"configmaps"}}},
+		{Namespace: "prod", Rule: entity.PolicyRule{Verbs: []string{"patch"}, APIGroups: []string{"apps"}, Resources: []string{"deployments/scale"}}},
+	}, rules)
+
+	manifest, err := ManifestYAML(Manifests("prod", "web", rules))
+	require.NoError(t, err)
+	assert.Contains(t, manifest, "kind: ClusterRole\n")
+	assert.Contains(t, manifest, "kind: Role\n")
+	assert.Contains(t, manifest, "name: web-minimal\n  namespace: prod\n")
+	assert.Contains(t, manifest, "- kind: ServiceAccount\n  name: web\n  namespace: prod\n")
+}
+
+func TestDiff(t *testing.T) {
+	observed := Observed(readTestLog(t), ServiceAccountUsername("prod", "web"))
+	suggested := MinimalRules(observed)
+	bound := entity.SubjectPermissions{
+		Subject: rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: "prod", Name: "web"},
+		Grants: []entity.Grant{{
+			Binding:   findings.Resource{Kind: "RoleBinding", Namespace: "prod", Name: "web"},
+			RoleRef:   rbacv1.RoleRef{Kind: "Role", Name: "web"},
+			Namespace: "prod",
+			Rules: []rbacv1.PolicyRule{
+				{Verbs: []string{"watch", "get"}, APIGroups: []string{""}, Resources: []string{"configmaps"}},
+				{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}},
+				{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}},
+			},
+		}},
+	}
+
+	lines := Diff(bound, suggested, observed)
+	require.Len(t, lines, 6)
+	assert.Equal(t, " ", lines[0].Op, "rules differing in order only are shared")
+	assert.Equal(t, "-", lines[1].Op)
+	assert.False(t, lines[1].Unused, "the wildcard rule allowed the scale patch")
+	assert.Equal(t, "-", lines[2].Op)
+	assert.True(t, lines[2].Unused)
+	for _, line := range lines[3:] {
+		assert.Equal(t, "+", line.Op)
+	}
+}
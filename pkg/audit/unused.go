@@ -0,0 +1,254 @@
+package audit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"kspm/pkg/entity"
+	"kspm/pkg/findings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Permission is one verb on one resource, or on one non-resource URL,
+// granted to a subject
+type Permission struct {
+	// Namespace is empty for permissions granted cluster-wide
+	Namespace      string   `json:"namespace,omitempty"`
+	Verb           string   `json:"verb"`
+	APIGroup       string   `json:"apiGroup,omitempty"`
+	Resource       string   `json:"resource,omitempty"`
+	ResourceNames  []string `json:"resourceNames,omitempty"`
+	NonResourceURL string   `json:"nonResourceURL,omitempty"`
+	// Source is the binding and role granting the permission
+	Source string `json:"source"`
+	// Risks lists what makes the permission dangerous: wildcard,
+	// dangerous-verbs and secrets
+	Risks []string `json:"risks,omitempty"`
+}
+
+// String renders the permission as verb resource[.group]
+func (p Permission) String() string {
+	if p.NonResourceURL != "" {
+		return p.Verb + " " + p.NonResourceURL
+	}
+	s := p.Verb + " " + p.Resource
+	if p.APIGroup != "" {
+		s += "." + p.APIGroup
+	}
+	if len(p.ResourceNames) > 0 {
+		s += fmt.Sprintf(" %v", p.ResourceNames)
+	}
+	return s
+}
+
+// Scope renders the namespace the permission applies to
+func (p Permission) Scope() string {
+	if p.Namespace == "" {
+		return "cluster-wide"
+	}
+	return p.Namespace
+}
+
+// Danger rates the permission by the number of risks it carries, 0 to 3
+func (p Permission) Danger() int {
+	return len(p.Risks)
+}
+
+// Severity maps the danger of the permission onto a finding severity
+func (p Permission) Severity() findings.Severity {
+	return []findings.Severity{findings.SeverityLow, findings.SeverityMedium, findings.SeverityHigh, findings.SeverityCritical}[p.Danger()]
+}
+
+func (p Permission) rule() rbacv1.PolicyRule {
+	if p.NonResourceURL != "" {
+		return rbacv1.PolicyRule{Verbs: []string{p.Verb}, NonResourceURLs: []string{p.NonResourceURL}}
+	}
+	return rbacv1.PolicyRule{
+		Verbs:         []string{p.Verb},
+		APIGroups:     []string{p.APIGroup},
+		Resources:     []string{p.Resource},
+		ResourceNames: p.ResourceNames,
+	}
+}
+
+// permissionRisks applies the RBAC risk checks to a permission. A wildcard
+// verb includes the dangerous verbs and a wildcard resource of the core
+// group includes secrets, which RuleRisks does not assume for whole rules.
+func permissionRisks(p Permission) []string {
+	if p.NonResourceURL != "" {
+		return entity.RuleRisks([]rbacv1.PolicyRule{p.rule()})
+	}
+	wildcard := p.Verb == "*" || p.Resource == "*"
+	dangerous := p.Verb == "*" || entity.HasDangerousVerbs([]string{p.Verb})
+	secrets := (p.Resource == "secrets" || p.Resource == "*") && (p.APIGroup == "" || p.APIGroup == "*")
+
+	var out []string
+	if wildcard {
+		out = append(out, "wildcard")
+	}
+	if dangerous {
+		out = append(out, "dangerous-verbs")
+	}
+	if secrets {
+		out = append(out, "secrets")
+	}
+	return out
+}
+
+// permissions expands the rules of a grant into single permissions.
+// Non-resource URLs are only granted cluster-wide.
+func permissions(g entity.Grant) []Permission {
+	var out []Permission
+	source := grantSource(g)
+	for _, rule := range g.Rules {
+		for _, verb := range rule.Verbs {
+			if g.Namespace == "" {
+				for _, url := range rule.NonResourceURLs {
+					out = append(out, Permission{Verb: verb, NonResourceURL: url, Source: source})
+				}
+			}
+			for _, group := range rule.APIGroups {
+				for _, resource := range rule.Resources {
+					out = append(out, Permission{
+						Namespace:     g.Namespace,
+						Verb:          verb,
+						APIGroup:      group,
+						Resource:      resource,
+						ResourceNames: rule.ResourceNames,
+						Source:        source,
+					})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// SubjectUsage is the permissions a subject holds but did not use
+type SubjectUsage struct {
+	Subject rbacv1.Subject `json:"subject"`
+	// Requests counts the authorized requests the subject made
+	Requests int          `json:"requests"`
+	Unused   []Permission `json:"unused"`
+}
+
+// Danger is the danger of the subject's most dangerous unused permission
+func (u SubjectUsage) Danger() int {
+	danger := 0
+	for _, p := range u.Unused {
+		danger = max(danger, p.Danger())
+	}
+	return danger
+}
+
+// subjectMatcher accepts the identities of the requests made as a subject:
+// the ServiceAccount's or user's username, or the members of a group
+func subjectMatcher(subject rbacv1.Subject) func(UserInfo) bool {
+	switch subject.Kind {
+	case rbacv1.ServiceAccountKind:
+		username := ServiceAccountUsername(subject.Namespace, subject.Name)
+		return func(u UserInfo) bool { return u.Username == username }
+	case rbacv1.GroupKind:
+		return func(u UserInfo) bool {
+			for _, g := range u.Groups {
+				if g == subject.Name {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return func(u UserInfo) bool { return u.Username == subject.Name }
+}
+
+// Unused lists per subject the permissions no request in the events needed,
+// the most dangerous first. Grants of bootstrapped bindings, the users and
+// groups of the system: namespace and grants inherited through a group, which
+// are reported on the group, are left out. Subjects are ranked by their most
+// dangerous unused permission, then by how many they hold.
+func Unused(inventory []entity.SubjectPermissions, events []Event) []SubjectUsage {
+	var out []SubjectUsage
+	for _, p := range inventory {
+		if p.Subject.Kind != rbacv1.ServiceAccountKind && strings.HasPrefix(p.Subject.Name, "system:") {
+			continue
+		}
+		observed := observedBy(events, subjectMatcher(p.Subject))
+		usage := SubjectUsage{Subject: p.Subject}
+		for _, a := range observed {
+			usage.Requests += a.Count
+		}
+
+		seen := map[string]bool{}
+		for _, g := range p.Grants {
+			if g.Via != "" || entity.IsBuiltinBinding(metav1.ObjectMeta{Namespace: g.Binding.Namespace, Name: g.Binding.Name, Labels: g.Binding.Labels}) {
+				continue
+			}
+			for _, perm := range permissions(g) {
+				key := perm.Namespace + "\x00" + perm.APIGroup + "\x00" + perm.String()
+				if seen[key] || used(perm, observed) {
+					continue
+				}
+				seen[key] = true
+				perm.Risks = permissionRisks(perm)
+				usage.Unused = append(usage.Unused, perm)
+			}
+		}
+		if len(usage.Unused) == 0 {
+			continue
+		}
+		sort.SliceStable(usage.Unused, func(i, j int) bool {
+			a, b := usage.Unused[i], usage.Unused[j]
+			if a.Danger() != b.Danger() {
+				return a.Danger() > b.Danger()
+			}
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			return a.String() < b.String()
+		})
+		out = append(out, usage)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Danger() != b.Danger() {
+			return a.Danger() > b.Danger()
+		}
+		if len(a.Unused) != len(b.Unused) {
+			return len(a.Unused) > len(b.Unused)
+		}
+		return entity.SubjectString(a.Subject) < entity.SubjectString(b.Subject)
+	})
+	return out
+}
+
+// used reports whether an observed request needed the permission
+func used(p Permission, observed []Access) bool {
+	rule := p.rule()
+	for _, a := range observed {
+		if p.Namespace != "" && a.Namespace != p.Namespace {
+			continue
+		}
+		if ok, _ := entity.RuleAllows(rule, a.Request); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Window returns the time span the events cover
+func Window(events []Event) (from, to time.Time) {
+	for _, e := range events {
+		if !e.RequestReceivedTimestamp.IsZero() && (from.IsZero() || e.RequestReceivedTimestamp.Before(from)) {
+			from = e.RequestReceivedTimestamp
+		}
+		if e.StageTimestamp.After(to) {
+			to = e.StageTimestamp
+		}
+	}
+	return from, to
+}
@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"testing"
+
+	"kspm/pkg/entity"
+	"kspm/pkg/findings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestUnused(t *testing.T) {
+	roleBinding := findings.Resource{Kind: "RoleBinding", Namespace: "prod", Name: "web"}
+	inventory := []entity.SubjectPermissions{
+		{
+			Subject: rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: "prod", Name: "web"},
+			Grants: []entity.Grant{
+				{Binding: roleBinding, RoleRef: rbacv1.RoleRef{Kind: "Role", Name: "web"}, Namespace: "prod", Rules: []rbacv1.PolicyRule{
+					{Verbs: []string{"get", "watch", "delete"}, APIGroups: []string{""}, Resources: []string{"configmaps"}},
+					{Verbs: []string{"get", "list", "delete"}, APIGroups: []string{""}, Resources: []string{"secrets"}},
+					{Verbs: []string{"patch"}, APIGroups: []string{"apps"}, Resources: []string{"deployments/scale"}},
+				}},
+				{Binding: findings.Resource{Kind: "ClusterRoleBinding", Name: "web-nodes"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "nodes"}, Rules: []rbacv1.PolicyRule{
+					{Verbs: []string{"list", "delete"}, APIGroups: []string{""}, Resources: []string{"nodes"}},
+					{Verbs: []string{"get"}, NonResourceURLs: []string{"/healthz"}},
+				}},
+				{Binding: findings.Resource{Kind: "ClusterRoleBinding", Name: "sa-view"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"}, Via: "system:serviceaccounts", Rules: []rbacv1.PolicyRule{
+					{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+				}},
+			},
+		},
+		{
+			Subject: rbacv1.Subject{Kind: rbacv1.UserKind, Name: "alice"},
+			Grants: []entity.Grant{{Binding: roleBinding, RoleRef: rbacv1.RoleRef{Kind: "Role", Name: "debug"}, Namespace: "prod", Rules: []rbacv1.PolicyRule{
+				{Verbs: []string{"list"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+				{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"pods/exec"}},
+			}}},
+		},
+		{
+			Subject: rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "devs"},
+			Grants: []entity.Grant{{Binding: roleBinding, RoleRef: rbacv1.RoleRef{Kind: "Role", Name: "read"}, Namespace: "prod", Rules: []rbacv1.PolicyRule{
+				{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}},
+			}}},
+		},
+		{
+			Subject: rbacv1.Subject{Kind: rbacv1.UserKind, Name: "system:kube-scheduler"},
+			Grants: []entity.Grant{{Binding: findings.Resource{Kind: "ClusterRoleBinding", Name: "scheduler"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "scheduler"}, Rules: []rbacv1.PolicyRule{
+				{Verbs: []string{"delete"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+			}}},
+		},
+	}
+
+	usage := Unused(inventory, readTestLog(t))
+	require.Len(t, usage, 3, "system users are left out")
+
+	web := usage[0]
+	assert.Equal(t, "web", web.Subject.Name, "ranked first for holding delete secrets")
+	assert.Equal(t, 6, web.Requests)
+	var got []string
+	for _, p := range web.Unused {
+		got = append(got, p.Scope()+" "+p.String())
+	}
+	assert.Equal(t, []string{
+		"prod delete secrets",
+		"cluster-wide delete nodes",
+		"prod delete configmaps",
+		"prod get secrets",
+		"prod list secrets",
+	}, got, "denied requests do not use a permission and inherited grants are left out")
+	assert.Equal(t, []string{"dangerous-verbs", "secrets"}, web.Unused[0].Risks)
+	assert.Equal(t, "RoleBinding/web -> Role/web", web.Unused[0].Source)
+	assert.Equal(t, 2, web.Danger())
+
+	assert.Equal(t, "devs", usage[1].Subject.Name)
+	assert.Equal(t, 0, usage[1].Requests)
+	assert.Equal(t, "alice", usage[2].Subject.Name)
+	assert.Equal(t, "create pods/exec", usage[2].Unused[0].String())
+}
+
+func TestPermissionRisksOfWildcards(t *testing.T) {
+	p := Permission{Verb: "*", APIGroup: "*", Resource: "*"}
+	assert.Equal(t, []string{"wildcard", "dangerous-verbs", "secrets"}, permissionRisks(p))
+	assert.Empty(t, permissionRisks(Permission{Verb: "get", APIGroup: "apps", Resource: "deployments"}))
+}
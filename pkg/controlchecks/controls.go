@@ -3,10 +3,12 @@ package controlchecks
 import (
 	"context"
 	"fmt"
+	"kspm/pkg/findings"
 	"os"
 	"strings"
 	"text/tabwriter"
 
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -64,3 +66,77 @@ func RBACSettings(roles []*rbac.Role) {
 }
 
 // printRoles prints the roles and their permissions in a table format.
+
+// DefaultRequiredClusterRoles are the ClusterRoles a healthy cluster is expected to have
+var DefaultRequiredClusterRoles = []string{
+	"system:auth-delegator",
+	"system:certificates.k8s.io:certificatesigningrequests:nodeclient",
+	"system:aggregate-to-admin",
+}
+
+// DefaultRequiredNodeCount is the node count below which the cluster is not highly available
+const DefaultRequiredNodeCount = 3
+
+// CheckRequiredClusterRoles reports every required ClusterRole missing from the cluster
+func CheckRequiredClusterRoles(ctx context.Context, clientset kubernetes.Interface, requiredRoles []string) []findings.Finding {
+	var out []findings.Finding
+	for _, requiredRole := range requiredRoles {
+		_, err := clientset.RbacV1().ClusterRoles().Get(ctx, requiredRole, metav1.GetOptions{})
+		if err != nil {
+			out = append(out, findings.Finding{
+				RuleID:      "CTRL-MISSING-CLUSTERROLE",
+				Severity:    findings.SeverityHigh,
+				Category:    findings.CategoryControlPlane,
+				Resource:    findings.Resource{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: requiredRole},
+				Message:     fmt.Sprintf("Required ClusterRole %s not found", requiredRole),
+				Evidence:    []string{err.Error()},
+				Remediation: "Restore the built-in ClusterRole (the API server recreates defaults on restart)",
+			})
+		}
+	}
+	return out
+}
+
+// CheckPodsRunning reports every pod that is not in the Running phase
+func CheckPodsRunning(ctx context.Context, clientset kubernetes.Interface) ([]findings.Finding, error) {
+	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	var out []findings.Finding
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning {
+			continue
+		}
+		out = append(out, findings.Finding{
+			RuleID:      "CTRL-POD-NOT-RUNNING",
+			Severity:    findings.SeverityMedium,
+			Category:    findings.CategoryControlPlane,
+			Resource:    findings.Resource{APIVersion: "v1", Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name, Labels: pod.Labels},
+			Message:     fmt.Sprintf("Pod %s is not running", pod.Name),
+			Evidence:    []string{fmt.Sprintf("phase=%s", pod.Status.Phase)},
+			Remediation: "Inspect the pod events and logs with kubectl describe",
+		})
+	}
+	return out, nil
+}
+
+// CheckNodeCount reports when fewer than requiredNodes nodes are registered
+func CheckNodeCount(ctx context.Context, clientset kubernetes.Interface, requiredNodes int) ([]findings.Finding, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	if len(nodes.Items) >= requiredNodes {
+		return nil, nil
+	}
+	return []findings.Finding{{
+		RuleID:      "CTRL-INSUFFICIENT-NODES",
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryControlPlane,
+		Resource:    findings.Resource{APIVersion: "v1", Kind: "Node", Name: "*"},
+		Message:     fmt.Sprintf("Insufficient nodes: %d available, %d required", len(nodes.Items), requiredNodes),
+		Remediation: "Add nodes to meet the availability requirement",
+	}}, nil
+}
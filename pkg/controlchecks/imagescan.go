@@ -3,6 +3,7 @@ package controlchecks
 import (
 	"context"
 	"fmt"
+	"kspm/pkg/findings"
 	"os/exec"
 	"strings"
 
@@ -40,6 +41,47 @@ func FetchVulnerabilityReports(ctx context.Context, cfg *rest.Config, namespace
 	return reportsList.Items, nil
 }
 
+// VulnerabilityFindings converts Trivy operator VulnerabilityReports into findings
+// attributed to the workload the report was generated for.
+func VulnerabilityFindings(reports []trivyv1alpha.VulnerabilityReport) []findings.Finding {
+	var out []findings.Finding
+	for _, report := range reports {
+		resource := findings.Resource{
+			Kind:      report.Labels["trivy-operator.resource.kind"],
+			Namespace: report.Namespace,
+			Name:      report.Labels["trivy-operator.resource.name"],
+		}
+		if resource.Kind == "" {
+			resource.Kind = "VulnerabilityReport"
+			resource.Name = report.Name
+		}
+		image := report.Report.Artifact.Repository
+		if report.Report.Artifact.Tag != "" {
+			image += ":" + report.Report.Artifact.Tag
+		}
+		for _, vuln := range report.Report.Vulnerabilities {
+			remediation := ""
+			if vuln.FixedVersion != "" {
+				remediation = fmt.Sprintf("Upgrade %s to %s", vuln.Resource, vuln.FixedVersion)
+			}
+			out = append(out, findings.Finding{
+				RuleID:   "IMAGE-VULNERABILITY",
+				Severity: findings.ParseSeverity(string(vuln.Severity)),
+				Category: findings.CategoryVulnerability,
+				Resource: resource,
+				Message:  fmt.Sprintf("%s in %s %s: %s", vuln.VulnerabilityID, vuln.Resource, vuln.InstalledVersion, vuln.Title),
+				Evidence: []string{
+					vuln.VulnerabilityID,
+					fmt.Sprintf("image=%s", image),
+					fmt.Sprintf("container=%s", report.Labels["trivy-operator.container.name"]),
+				},
+				Remediation: remediation,
+			})
+		}
+	}
+	return out
+}
+
 func ScanImages(clientset *kubernetes.Clientset, imageFlag string) error {
 	// Check if Docker is Installed
 	_, err := exec.LookPath("docker")
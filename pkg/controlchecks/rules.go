@@ -0,0 +1,51 @@
+package controlchecks
+
+import (
+	"kspm/pkg/findings"
+	"kspm/pkg/rules"
+)
+
+// Control plane and vulnerability rules; their findings are built by the
+// checks that query the cluster, so the rules carry no Check
+func init() {
+	for _, rule := range controlRules {
+		rules.MustDefine(rule)
+	}
+}
+
+var controlRules = []rules.Rule{
+	{
+		ID:          "CTRL-MISSING-CLUSTERROLE",
+		Kinds:       []string{"ClusterRole"},
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryControlPlane,
+		Description: "Required ClusterRole does not exist",
+		Remediation: "Restore the built-in ClusterRole (the API server recreates defaults on restart)",
+		Signal:      "MissingRequiredClusterRole",
+		Weight:      15,
+	},
+	{
+		ID:          "CTRL-POD-NOT-RUNNING",
+		Kinds:       []string{"Pod"},
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryControlPlane,
+		Description: "Pod is not in the Running phase",
+		Remediation: "Inspect the pod events and logs with kubectl describe",
+	},
+	{
+		ID:          "CTRL-INSUFFICIENT-NODES",
+		Kinds:       []string{"Node"},
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryControlPlane,
+		Description: "Cluster has fewer nodes than required",
+		Remediation: "Add nodes to meet the availability requirement",
+	},
+	{
+		ID:          "IMAGE-VULNERABILITY",
+		Kinds:       []string{"*"},
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryVulnerability,
+		Description: "Image has a known vulnerability; severity follows the Trivy report unless overridden",
+		Remediation: "Upgrade the vulnerable package to the fixed version",
+	},
+}
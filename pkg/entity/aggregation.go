@@ -0,0 +1,242 @@
+package entity
+
+import (
+	"fmt"
+	"kspm/pkg/findings"
+	"kspm/pkg/rules"
+	"strings"
+
+	v1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// builtinAggregations are the labels that add a ClusterRole's rules to the
+// built-in admin, edit and view ClusterRoles
+var builtinAggregations = []struct {
+	label string
+	role  string
+}{
+	{"rbac.authorization.k8s.io/aggregate-to-admin", "admin"},
+	{"rbac.authorization.k8s.io/aggregate-to-edit", "edit"},
+	{"rbac.authorization.k8s.io/aggregate-to-view", "view"},
+}
+
+// SourcedRule is an effective rule of a role and the role it comes from,
+// which is another ClusterRole for rules added through aggregation
+type SourcedRule struct {
+	Rule   v1.PolicyRule `json:"rule"`
+	Source string        `json:"source"`
+}
+
+// EffectiveRules returns the rules of the role a binding in namespace refers
+// to, attributed to the role that holds them. ok is false when the role does
+// not exist.
+func (s *RBACSnapshot) EffectiveRules(ref v1.RoleRef, namespace string) ([]SourcedRule, bool) {
+	switch ref.Kind {
+	case "ClusterRole":
+		return s.clusterRoleRules(ref.Name, map[string]bool{})
+	case "Role":
+		for i := range s.Roles {
+			if s.Roles[i].Name == ref.Name && s.Roles[i].Namespace == namespace {
+				return sourced(s.Roles[i].Rules, ref.Name), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// RuleSource returns the ClusterRole a rule of the referenced role was
+// aggregated from, or "" when the role holds the rule itself
+func (s *RBACSnapshot) RuleSource(ref v1.RoleRef, namespace string, rule v1.PolicyRule) string {
+	effective, _ := s.EffectiveRules(ref, namespace)
+	for _, r := range effective {
+		if r.Source != ref.Name && equality.Semantic.DeepEqual(r.Rule, rule) {
+			return r.Source
+		}
+	}
+	return ""
+}
+
+func sourced(rules []v1.PolicyRule, source string) []SourcedRule {
+	out := make([]SourcedRule, 0, len(rules))
+	for _, rule := range rules {
+		out = append(out, SourcedRule{Rule: rule, Source: source})
+	}
+	return out
+}
+
+// clusterRoleRules returns the rules of a ClusterRole. For a ClusterRole with
+// an aggregationRule the rules of every ClusterRole its selectors match are
+// added, as the aggregation controller would, and attributed to the
+// ClusterRole defining them. On a live cluster the controller has already
+// copied them into the aggregated ClusterRole.
+func (s *RBACSnapshot) clusterRoleRules(name string, visited map[string]bool) ([]SourcedRule, bool) {
+	for i := range s.ClusterRoles {
+		cr := &s.ClusterRoles[i]
+		if cr.Name != name {
+			continue
+		}
+		visited[name] = true
+		if cr.AggregationRule == nil {
+			return sourced(cr.Rules, cr.Name), true
+		}
+
+		var rules []SourcedRule
+		for _, source := range s.aggregatedClusterRoles(cr) {
+			if visited[source.Name] {
+				continue
+			}
+			sourceRules, _ := s.clusterRoleRules(source.Name, visited)
+			rules = appendMissingRules(rules, sourceRules)
+		}
+		return appendMissingRules(rules, sourced(cr.Rules, cr.Name)), true
+	}
+	return nil, false
+}
+
+// aggregatedClusterRoles returns the ClusterRoles selected by an aggregationRule
+func (s *RBACSnapshot) aggregatedClusterRoles(cr *v1.ClusterRole) []*v1.ClusterRole {
+	var out []*v1.ClusterRole
+	for _, ls := range cr.AggregationRule.ClusterRoleSelectors {
+		selector, err := metav1.LabelSelectorAsSelector(&ls)
+		if err != nil {
+			continue
+		}
+		for i := range s.ClusterRoles {
+			source := &s.ClusterRoles[i]
+			if source.Name != cr.Name && selector.Matches(labels.Set(source.Labels)) {
+				out = append(out, source)
+			}
+		}
+	}
+	return out
+}
+
+// appendMissingRules appends the rules of add that rules does not already contain
+func appendMissingRules(rules, add []SourcedRule) []SourcedRule {
+	for _, rule := range add {
+		if !containsRule(rules, rule.Rule) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func containsRule(rules []SourcedRule, rule v1.PolicyRule) bool {
+	for _, r := range rules {
+		if equality.Semantic.DeepEqual(r.Rule, rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// EvaluateClusterRoles runs the RBAC rules against every ClusterRole that
+// does not ship with Kubernetes. Aggregated ClusterRoles are evaluated on
+// their resolved rules, and findings raised by an aggregated rule name the
+// ClusterRole it came from in their evidence.
+func (s *RBACSnapshot) EvaluateClusterRoles() ([]findings.Finding, []findings.Resource) {
+	var out []findings.Finding
+	var scanned []findings.Resource
+	for i := range s.ClusterRoles {
+		cr := &s.ClusterRoles[i]
+		if IsBuiltinClusterRole(cr.Name) {
+			continue
+		}
+		scanned = append(scanned, ClusterRoleResource(cr))
+		if cr.AggregationRule == nil {
+			out = append(out, EvaluateClusterRole(cr)...)
+			continue
+		}
+
+		effective, _ := s.clusterRoleRules(cr.Name, map[string]bool{})
+		var sources []string
+		bySource := map[string][]v1.PolicyRule{}
+		for _, r := range effective {
+			if _, ok := bySource[r.Source]; !ok {
+				sources = append(sources, r.Source)
+			}
+			bySource[r.Source] = append(bySource[r.Source], r.Rule)
+		}
+		for _, source := range sources {
+			resolved := cr.DeepCopy()
+			resolved.Rules = bySource[source]
+			for _, f := range rules.Evaluate(ClusterRoleResource(cr), resolved) {
+				if source != cr.Name {
+					f.Evidence = append(f.Evidence, "aggregated from ClusterRole/"+source)
+				}
+				out = append(out, f)
+			}
+		}
+	}
+	return findings.Dedupe(out), scanned
+}
+
+// aggregationSensitive lists the permissions that must not be aggregated into
+// the built-in roles, any one request granting it suffices
+var aggregationSensitive = []struct {
+	name     string
+	requests []ResourceRequest
+}{
+	{"secrets", []ResourceRequest{
+		{Verb: "get", Resource: "secrets"},
+		{Verb: "list", Resource: "secrets"},
+		{Verb: "watch", Resource: "secrets"},
+	}},
+	{"pods/exec", []ResourceRequest{
+		{Verb: "create", Resource: "pods", Subresource: "exec"},
+		{Verb: "get", Resource: "pods", Subresource: "exec"},
+	}},
+	{"escalate", rbacRequests([]string{"escalate"}, "roles", "clusterroles")},
+	{"bind", rbacRequests([]string{"bind"}, "roles", "clusterroles")},
+	{"impersonate", []ResourceRequest{
+		{Verb: "impersonate", Resource: "users"},
+		{Verb: "impersonate", Resource: "groups"},
+		{Verb: "impersonate", Resource: "serviceaccounts"},
+	}},
+}
+
+// sensitivePermissions lists the aggregationSensitive permissions the rules grant
+func sensitivePermissions(rules []v1.PolicyRule) []string {
+	var out []string
+	for _, sensitive := range aggregationSensitive {
+	requests:
+		for _, req := range sensitive.requests {
+			for _, rule := range rules {
+				if ok, _ := RuleAllows(rule, req); ok {
+					out = append(out, sensitive.name)
+					break requests
+				}
+			}
+		}
+	}
+	return out
+}
+
+// checkBuiltinAggregation flags a custom ClusterRole whose labels aggregate
+// sensitive permissions into the built-in admin, edit or view roles
+func checkBuiltinAggregation(obj runtime.Object) []rules.Violation {
+	cr, ok := obj.(*v1.ClusterRole)
+	if !ok || IsBuiltinClusterRole(cr.Name) || cr.Labels["kubernetes.io/bootstrapping"] == "rbac-defaults" {
+		return nil
+	}
+	granted := sensitivePermissions(cr.Rules)
+	if len(granted) == 0 {
+		return nil
+	}
+
+	var out []rules.Violation
+	for _, aggregation := range builtinAggregations {
+		if cr.Labels[aggregation.label] != "true" {
+			continue
+		}
+		out = append(out, rules.Violation{
+			Message:  fmt.Sprintf("aggregates %s into built-in ClusterRole %s", strings.Join(granted, ", "), aggregation.role),
+			Evidence: []string{aggregation.label + "=true"},
+		})
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func aggregationSnapshot() *RBACSnapshot {
+	return &RBACSnapshot{ClusterRoles: []v1.ClusterRole{
+		{
+			ObjectMeta:      metav1.ObjectMeta{Name: "monitoring"},
+			AggregationRule: &v1.AggregationRule{ClusterRoleSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"example.com/aggregate-to-monitoring": "true"}}}},
+			// Filled in by the aggregation controller on a live cluster
+			Rules: []v1.PolicyRule{{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"secrets"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret-scraper", Labels: map[string]string{
+				"example.com/aggregate-to-monitoring":          "true",
+				"rbac.authorization.k8s.io/aggregate-to-admin": "true",
+				"rbac.authorization.k8s.io/aggregate-to-view":  "true",
+			}},
+			Rules: []v1.PolicyRule{{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"secrets"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "system:aggregate-to-edit", Labels: map[string]string{"rbac.authorization.k8s.io/aggregate-to-edit": "true"}},
+			Rules:      []v1.PolicyRule{{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"pods/exec"}}},
+		},
+	}}
+}
+
+func TestEffectiveRulesAttributeAggregation(t *testing.T) {
+	snapshot := aggregationSnapshot()
+	rules, ok := snapshot.EffectiveRules(v1.RoleRef{Kind: "ClusterRole", Name: "monitoring"}, "")
+	assert.True(t, ok)
+	if assert.Len(t, rules, 1, "rules copied by the controller are not counted twice") {
+		assert.Equal(t, "secret-scraper", rules[0].Source)
+	}
+	assert.Equal(t, "secret-scraper", snapshot.RuleSource(v1.RoleRef{Kind: "ClusterRole", Name: "monitoring"}, "", rules[0].Rule))
+}
+
+func TestEvaluateClusterRolesAggregation(t *testing.T) {
+	out, scanned := aggregationSnapshot().EvaluateClusterRoles()
+	assert.Len(t, scanned, 2, "built-in ClusterRoles are skipped")
+
+	var aggregated []string
+	var secretsRead []string
+	for _, f := range out {
+		switch f.RuleID {
+		case "RBAC-AGGREGATES-TO-BUILTIN":
+			aggregated = append(aggregated, f.Resource.Name+": "+f.Message)
+		case "RBAC-SECRETS-READ":
+			secretsRead = append(secretsRead, f.Resource.Name)
+			if f.Resource.Name == "monitoring" {
+				assert.Contains(t, f.Evidence, "aggregated from ClusterRole/secret-scraper")
+			}
+		}
+	}
+	assert.Equal(t, []string{
+		"secret-scraper: aggregates secrets into built-in ClusterRole admin",
+		"secret-scraper: aggregates secrets into built-in ClusterRole view",
+	}, aggregated)
+	assert.ElementsMatch(t, []string{"monitoring", "secret-scraper"}, secretsRead)
+}
@@ -0,0 +1,400 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+	"kspm/pkg/findings"
+	"kspm/pkg/rules"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Well-known groups every authenticated identity or ServiceAccount belongs to
+const (
+	GroupAuthenticated   = "system:authenticated"
+	GroupUnauthenticated = "system:unauthenticated"
+	GroupServiceAccounts = "system:serviceaccounts"
+	UserAnonymous        = "system:anonymous"
+)
+
+// RBACSnapshot holds the RBAC objects of a cluster (or of manifests on disk)
+// so bindings can be resolved offline
+type RBACSnapshot struct {
+	Roles               []v1.Role
+	ClusterRoles        []v1.ClusterRole
+	RoleBindings        []v1.RoleBinding
+	ClusterRoleBindings []v1.ClusterRoleBinding
+	// ServiceAccounts lets bindings to deleted ServiceAccounts be found
+	ServiceAccounts []corev1.ServiceAccount
+}
+
+// FetchRBACSnapshot lists every Role, ClusterRole, RoleBinding,
+// ClusterRoleBinding and ServiceAccount
+func FetchRBACSnapshot(ctx context.Context, clientset kubernetes.Interface) (*RBACSnapshot, error) {
+	rbacClient := clientset.RbacV1()
+	opts := metav1.ListOptions{}
+
+	roles, err := rbacClient.Roles("").List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	clusterRoles, err := rbacClient.ClusterRoles().List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster roles: %w", err)
+	}
+	roleBindings, err := rbacClient.RoleBindings("").List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list role bindings: %w", err)
+	}
+	clusterRoleBindings, err := rbacClient.ClusterRoleBindings().List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster role bindings: %w", err)
+	}
+	serviceAccounts, err := clientset.CoreV1().ServiceAccounts("").List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list service accounts: %w", err)
+	}
+
+	return &RBACSnapshot{
+		Roles:               roles.Items,
+		ClusterRoles:        clusterRoles.Items,
+		RoleBindings:        roleBindings.Items,
+		ClusterRoleBindings: clusterRoleBindings.Items,
+		ServiceAccounts:     serviceAccounts.Items,
+	}, nil
+}
+
+// RoleBindingResource identifies a RoleBinding in findings
+func RoleBindingResource(rb *v1.RoleBinding) findings.Resource {
+	return findings.Resource{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding", Namespace: rb.Namespace, Name: rb.Name, Labels: rb.Labels}
+}
+
+// ClusterRoleBindingResource identifies a ClusterRoleBinding in findings
+func ClusterRoleBindingResource(crb *v1.ClusterRoleBinding) findings.Resource {
+	return findings.Resource{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding", Name: crb.Name, Labels: crb.Labels}
+}
+
+// EvaluateRoleBinding runs the registered binding rules against a RoleBinding
+func EvaluateRoleBinding(rb *v1.RoleBinding) []findings.Finding {
+	return rules.Evaluate(RoleBindingResource(rb), rb)
+}
+
+// EvaluateClusterRoleBinding runs the registered binding rules against a ClusterRoleBinding
+func EvaluateClusterRoleBinding(crb *v1.ClusterRoleBinding) []findings.Finding {
+	return rules.Evaluate(ClusterRoleBindingResource(crb), crb)
+}
+
+// bootstrapDefaults lists the bindings, and the Roles they bind, the API server
+// bootstraps: ClusterRoleBindings by name, namespaced objects by namespace/name
+var bootstrapDefaults = map[string]bool{
+	"cluster-admin":                                                 true,
+	"system:basic-user":                                             true,
+	"system:discovery":                                              true,
+	"system:public-info-viewer":                                     true,
+	"system:monitoring":                                             true,
+	"system:service-account-issuer-discovery":                       true,
+	"system:kube-controller-manager":                                true,
+	"system:kube-dns":                                               true,
+	"system:kube-scheduler":                                         true,
+	"system:volume-scheduler":                                       true,
+	"system:node":                                                   true,
+	"system:node-proxier":                                           true,
+	"system:controller:attachdetach-controller":                     true,
+	"system:controller:certificate-controller":                      true,
+	"system:controller:clusterrole-aggregation-controller":          true,
+	"system:controller:cronjob-controller":                          true,
+	"system:controller:daemon-set-controller":                       true,
+	"system:controller:deployment-controller":                       true,
+	"system:controller:disruption-controller":                       true,
+	"system:controller:endpoint-controller":                         true,
+	"system:controller:endpointslice-controller":                    true,
+	"system:controller:endpointslicemirroring-controller":           true,
+	"system:controller:ephemeral-volume-controller":                 true,
+	"system:controller:expand-controller":                           true,
+	"system:controller:generic-garbage-collector":                   true,
+	"system:controller:horizontal-pod-autoscaler":                   true,
+	"system:controller:job-controller":                              true,
+	"system:controller:legacy-service-account-token-cleaner":        true,
+	"system:controller:namespace-controller":                        true,
+	"system:controller:node-controller":                             true,
+	"system:controller:persistent-volume-binder":                    true,
+	"system:controller:pod-garbage-collector":                       true,
+	"system:controller:pv-protection-controller":                    true,
+	"system:controller:pvc-protection-controller":                   true,
+	"system:controller:replicaset-controller":                       true,
+	"system:controller:replication-controller":                      true,
+	"system:controller:resourcequota-controller":                    true,
+	"system:controller:root-ca-cert-publisher":                      true,
+	"system:controller:route-controller":                            true,
+	"system:controller:selinux-warning-controller":                  true,
+	"system:controller:service-account-controller":                  true,
+	"system:controller:service-cidrs-controller":                    true,
+	"system:controller:service-controller":                          true,
+	"system:controller:statefulset-controller":                      true,
+	"system:controller:ttl-after-finished-controller":               true,
+	"system:controller:ttl-controller":                              true,
+	"system:controller:validatingadmissionpolicy-status-controller": true,
+
+	"kube-public/system:controller:bootstrap-signer":                true,
+	"kube-system/extension-apiserver-authentication-reader":         true,
+	"kube-system/system::extension-apiserver-authentication-reader": true,
+	"kube-system/system::leader-locking-kube-controller-manager":    true,
+	"kube-system/system::leader-locking-kube-scheduler":             true,
+	"kube-system/system:controller:bootstrap-signer":                true,
+	"kube-system/system:controller:cloud-provider":                  true,
+	"kube-system/system:controller:token-cleaner":                   true,
+}
+
+// IsBuiltinBinding reports whether a binding, or the Role it binds, is one of
+// the defaults the API server bootstraps, such as system:masters to
+// cluster-admin. Both the bootstrapping label and a known default name are
+// required: either alone can be set by anyone able to create a binding.
+func IsBuiltinBinding(meta metav1.ObjectMeta) bool {
+	if meta.Labels["kubernetes.io/bootstrapping"] != "rbac-defaults" {
+		return false
+	}
+	key := meta.Name
+	if meta.Namespace != "" {
+		key = meta.Namespace + "/" + meta.Name
+	}
+	return bootstrapDefaults[key]
+}
+
+// EvaluateBindings runs the binding rules against every binding that is not
+// a bootstrapped default, returning the findings and the bindings evaluated
+func (s *RBACSnapshot) EvaluateBindings() ([]findings.Finding, []findings.Resource) {
+	var out []findings.Finding
+	var scanned []findings.Resource
+	for i := range s.ClusterRoleBindings {
+		crb := &s.ClusterRoleBindings[i]
+		if IsBuiltinBinding(crb.ObjectMeta) {
+			continue
+		}
+		out = append(out, EvaluateClusterRoleBinding(crb)...)
+		scanned = append(scanned, ClusterRoleBindingResource(crb))
+	}
+	for i := range s.RoleBindings {
+		rb := &s.RoleBindings[i]
+		if IsBuiltinBinding(rb.ObjectMeta) {
+			continue
+		}
+		out = append(out, EvaluateRoleBinding(rb)...)
+		scanned = append(scanned, RoleBindingResource(rb))
+	}
+	return out, scanned
+}
+
+// RoleRules returns the rules of the role a binding in namespace refers to.
+// ok is false when the role does not exist.
+func (s *RBACSnapshot) RoleRules(ref v1.RoleRef, namespace string) ([]v1.PolicyRule, bool) {
+	sourced, ok := s.EffectiveRules(ref, namespace)
+	if !ok {
+		return nil, false
+	}
+	rules := make([]v1.PolicyRule, 0, len(sourced))
+	for _, r := range sourced {
+		rules = append(rules, r.Rule)
+	}
+	return rules, true
+}
+
+// SubjectString renders a subject as Kind/name, with the namespace of ServiceAccounts
+func SubjectString(subject v1.Subject) string {
+	if subject.Kind == v1.ServiceAccountKind {
+		return fmt.Sprintf("%s/%s/%s", subject.Kind, subject.Namespace, subject.Name)
+	}
+	return fmt.Sprintf("%s/%s", subject.Kind, subject.Name)
+}
+
+// bindingSubject fills in the namespace of a ServiceAccount subject, which
+// defaults to the RoleBinding's own namespace
+func bindingSubject(subject v1.Subject, namespace string) v1.Subject {
+	if subject.Kind == v1.ServiceAccountKind && subject.Namespace == "" {
+		subject.Namespace = namespace
+	}
+	return subject
+}
+
+// ImplicitGroups returns the groups the API server adds to a subject's
+// identity: every ServiceAccount is in system:serviceaccounts and
+// system:serviceaccounts:<namespace>, and every authenticated user is in
+// system:authenticated
+func ImplicitGroups(subject v1.Subject) []string {
+	switch subject.Kind {
+	case v1.ServiceAccountKind:
+		return []string{GroupServiceAccounts, GroupServiceAccounts + ":" + subject.Namespace, GroupAuthenticated}
+	case v1.UserKind:
+		if subject.Name == UserAnonymous {
+			return []string{GroupUnauthenticated}
+		}
+		return []string{GroupAuthenticated}
+	}
+	return nil
+}
+
+// Grant is a role granted to a subject through a binding
+type Grant struct {
+	Binding findings.Resource `json:"binding"`
+	RoleRef v1.RoleRef        `json:"roleRef"`
+	// Namespace the grant applies to, empty for cluster-wide grants
+	Namespace string          `json:"namespace,omitempty"`
+	Rules     []v1.PolicyRule `json:"rules,omitempty"`
+	// Via names the group the subject inherits the grant through
+	Via string `json:"via,omitempty"`
+	// Missing is set when the referenced role does not exist
+	Missing bool `json:"missing,omitempty"`
+}
+
+// Scope renders the namespace a grant applies to
+func (g Grant) Scope() string {
+	if g.Namespace == "" {
+		return "cluster-wide"
+	}
+	return g.Namespace
+}
+
+// SubjectPermissions is the effective access of a ServiceAccount, User or
+// Group across every binding that names it or one of its implicit groups
+type SubjectPermissions struct {
+	Subject v1.Subject `json:"subject"`
+	Grants  []Grant    `json:"grants"`
+}
+
+// ClusterAdmin reports whether the subject holds cluster-admin cluster-wide
+func (p SubjectPermissions) ClusterAdmin() bool {
+	for _, g := range p.Grants {
+		if g.Namespace == "" && g.RoleRef.Kind == "ClusterRole" && g.RoleRef.Name == "cluster-admin" {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules returns every rule granted to the subject
+func (p SubjectPermissions) Rules() []v1.PolicyRule {
+	var out []v1.PolicyRule
+	for _, g := range p.Grants {
+		out = append(out, g.Rules...)
+	}
+	return out
+}
+
+// Risks summarizes what makes the subject's access dangerous: cluster-admin,
+// wildcard verbs or resources, dangerous verbs and secrets access
+func (p SubjectPermissions) Risks() []string {
+	risks := RuleRisks(p.Rules())
+	if p.ClusterAdmin() {
+		risks = append([]string{"cluster-admin"}, risks...)
+	}
+	return risks
+}
+
+// RuleRisks summarizes what makes a rule set dangerous: wildcard verbs or
+// resources, dangerous verbs and secrets access
+func RuleRisks(rules []v1.PolicyRule) []string {
+	var wildcard, dangerous, secrets bool
+	for _, rule := range rules {
+		wildcard = wildcard || HasWildcard(rule.Verbs) || HasWildcard(rule.Resources)
+		dangerous = dangerous || HasDangerousVerbs(rule.Verbs)
+		secrets = secrets || contains(rule.Resources, "secrets")
+	}
+
+	var out []string
+	if wildcard {
+		out = append(out, "wildcard")
+	}
+	if dangerous {
+		out = append(out, "dangerous-verbs")
+	}
+	if secrets {
+		out = append(out, "secrets")
+	}
+	return out
+}
+
+// Inventory resolves the effective permissions of every ServiceAccount,
+// User and Group named by a binding. ServiceAccounts and Users also inherit
+// the grants of their implicit groups. Subjects are sorted by kind,
+// namespace and name.
+func (s *RBACSnapshot) Inventory() []SubjectPermissions {
+	direct := map[string]*SubjectPermissions{}
+	add := func(subject v1.Subject, grant Grant) {
+		key := SubjectString(subject)
+		p, ok := direct[key]
+		if !ok {
+			p = &SubjectPermissions{Subject: subject}
+			direct[key] = p
+		}
+		p.Grants = append(p.Grants, grant)
+	}
+
+	for i := range s.ClusterRoleBindings {
+		crb := &s.ClusterRoleBindings[i]
+		rules, ok := s.RoleRules(crb.RoleRef, "")
+		for _, subject := range crb.Subjects {
+			add(bindingSubject(subject, ""), Grant{
+				Binding: ClusterRoleBindingResource(crb),
+				RoleRef: crb.RoleRef,
+				Rules:   rules,
+				Missing: !ok,
+			})
+		}
+	}
+	for i := range s.RoleBindings {
+		rb := &s.RoleBindings[i]
+		rules, ok := s.RoleRules(rb.RoleRef, rb.Namespace)
+		for _, subject := range rb.Subjects {
+			add(bindingSubject(subject, rb.Namespace), Grant{
+				Binding:   RoleBindingResource(rb),
+				RoleRef:   rb.RoleRef,
+				Namespace: rb.Namespace,
+				Rules:     rules,
+				Missing:   !ok,
+			})
+		}
+	}
+
+	out := make([]SubjectPermissions, 0, len(direct))
+	for _, p := range direct {
+		perms := *p
+		for _, group := range ImplicitGroups(perms.Subject) {
+			if g, ok := direct[SubjectString(v1.Subject{Kind: v1.GroupKind, Name: group})]; ok {
+				for _, grant := range g.Grants {
+					grant.Via = group
+					perms.Grants = append(perms.Grants, grant)
+				}
+			}
+		}
+		out = append(out, perms)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].Subject, out[j].Subject
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return out
+}
+
+// Reaches reports whether the subject is a ServiceAccount of namespace or
+// holds a grant there, either namespaced or cluster-wide
+func (p SubjectPermissions) Reaches(namespace string) bool {
+	if p.Subject.Kind == v1.ServiceAccountKind && p.Subject.Namespace == namespace {
+		return true
+	}
+	for _, g := range p.Grants {
+		if g.Namespace == "" || g.Namespace == namespace {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testSnapshot() *RBACSnapshot {
+	return &RBACSnapshot{
+		Roles: []v1.Role{{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret-reader", Namespace: "prod"},
+			Rules:      []v1.PolicyRule{{Verbs: []string{"get"}, Resources: []string{"secrets"}}},
+		}},
+		ClusterRoles: []v1.ClusterRole{
+			{ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"}, Rules: []v1.PolicyRule{{Verbs: []string{"*"}, Resources: []string{"*"}}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "viewer"}, Rules: []v1.PolicyRule{{Verbs: []string{"get", "list"}, Resources: []string{"pods"}}}},
+		},
+		ClusterRoleBindings: []v1.ClusterRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin", Labels: map[string]string{"kubernetes.io/bootstrapping": "rbac-defaults"}},
+				RoleRef:    v1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+				Subjects:   []v1.Subject{{Kind: v1.GroupKind, Name: "system:masters"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "ci-admin"},
+				RoleRef:    v1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+				Subjects:   []v1.Subject{{Kind: v1.ServiceAccountKind, Namespace: "ci", Name: "deployer"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "everyone-views"},
+				RoleRef:    v1.RoleRef{Kind: "ClusterRole", Name: "viewer"},
+				Subjects:   []v1.Subject{{Kind: v1.GroupKind, Name: GroupAuthenticated}, {Kind: v1.UserKind, Name: UserAnonymous}},
+			},
+		},
+		RoleBindings: []v1.RoleBinding{{
+			ObjectMeta: metav1.ObjectMeta{Name: "read-secrets", Namespace: "prod"},
+			RoleRef:    v1.RoleRef{Kind: "Role", Name: "secret-reader"},
+			Subjects:   []v1.Subject{{Kind: v1.ServiceAccountKind, Name: "default"}},
+		}},
+	}
+}
+
+func TestEvaluateBindings(t *testing.T) {
+	out, scanned := testSnapshot().EvaluateBindings()
+	assert.Len(t, scanned, 3, "bootstrapped bindings are skipped")
+
+	messages := map[string][]string{}
+	for _, f := range out {
+		messages[f.RuleID] = append(messages[f.RuleID], f.Message)
+	}
+	assert.Equal(t, []string{"cluster-admin bound to ServiceAccount/ci/deployer"}, messages["RBAC-BINDING-CLUSTER-ADMIN"])
+	assert.Equal(t, []string{"ClusterRole/viewer bound to unauthenticated User/system:anonymous"}, messages["RBAC-BINDING-ANONYMOUS"])
+	assert.Equal(t, []string{"ClusterRole/viewer bound to every authenticated identity (system:authenticated)"}, messages["RBAC-BINDING-AUTHENTICATED"])
+	assert.Equal(t, []string{"Role/secret-reader bound to the default ServiceAccount of namespace prod"}, messages["RBAC-BINDING-DEFAULT-SA"])
+}
+
+func TestIsBuiltinBinding(t *testing.T) {
+	bootstrapped := map[string]string{"kubernetes.io/bootstrapping": "rbac-defaults"}
+	assert.True(t, IsBuiltinBinding(metav1.ObjectMeta{Name: "cluster-admin", Labels: bootstrapped}))
+	assert.True(t, IsBuiltinBinding(metav1.ObjectMeta{Namespace: "kube-system", Name: "system:controller:token-cleaner", Labels: bootstrapped}))
+	assert.False(t, IsBuiltinBinding(metav1.ObjectMeta{Name: "system:backdoor", Labels: bootstrapped}), "the system: prefix is chosen by whoever creates the binding")
+	assert.False(t, IsBuiltinBinding(metav1.ObjectMeta{Name: "system:node"}), "a default name without the label")
+	assert.False(t, IsBuiltinBinding(metav1.ObjectMeta{Namespace: "prod", Name: "system:controller:token-cleaner", Labels: bootstrapped}))
+
+	snapshot := testSnapshot()
+	snapshot.ClusterRoleBindings = append(snapshot.ClusterRoleBindings, v1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "system:backdoor", Labels: bootstrapped},
+		RoleRef:    v1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+		Subjects:   []v1.Subject{{Kind: v1.UserKind, Name: UserAnonymous}},
+	})
+	out, _ := snapshot.EvaluateBindings()
+	var rules []string
+	for _, f := range out {
+		if f.Resource.Name == "system:backdoor" {
+			rules = append(rules, f.RuleID)
+		}
+	}
+	assert.ElementsMatch(t, []string{"RBAC-BINDING-CLUSTER-ADMIN", "RBAC-BINDING-ANONYMOUS"}, rules)
+}
+
+func TestConvertRoleToSignalsIgnoresRoleName(t *testing.T) {
+	// The role name alone no longer produces the ClusterAdminBinding signal
+	for _, s := range ConvertRoleToSignals(RBACRoleList{Name: "cluster-admin"}, nil) {
+		assert.NotEqual(t, "ClusterAdminBinding", s.Name)
+	}
+}
+
+func TestInventory(t *testing.T) {
+	inventory := map[string]SubjectPermissions{}
+	for _, p := range testSnapshot().Inventory() {
+		inventory[SubjectString(p.Subject)] = p
+	}
+	assert.Len(t, inventory, 5)
+
+	deployer := inventory["ServiceAccount/ci/deployer"]
+	assert.True(t, deployer.ClusterAdmin())
+	assert.Contains(t, deployer.Risks(), "cluster-admin")
+	// Inherited from system:authenticated
+	if assert.Len(t, deployer.Grants, 2) {
+		assert.Equal(t, GroupAuthenticated, deployer.Grants[1].Via)
+		assert.Equal(t, "viewer", deployer.Grants[1].RoleRef.Name)
+	}
+
+	// RoleBinding subjects default to the binding's namespace
+	sa := inventory["ServiceAccount/prod/default"]
+	if assert.Len(t, sa.Grants, 2) {
+		assert.Equal(t, "prod", sa.Grants[0].Scope())
+		assert.Equal(t, "cluster-wide", sa.Grants[1].Scope())
+	}
+	assert.Equal(t, []string{"secrets"}, sa.Risks())
+	assert.True(t, sa.Reaches("prod"))
+
+	anonymous := inventory["User/system:anonymous"]
+	assert.Len(t, anonymous.Grants, 1, "anonymous is not in system:authenticated")
+}
@@ -52,7 +52,7 @@ func GetDeploymentList(clientset kubernetes.Interface) (*v1.DeploymentList, erro
 	return deployments, nil
 }
 
-func GetDeploymentsAndViolationCount(clientset *kubernetes.Clientset) ([]Deployment, int, error) {
+func GetDeploymentsAndViolationCount(clientset kubernetes.Interface) ([]Deployment, int, error) {
 	deployments, err := clientset.AppsV1().Deployments("").List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		return []Deployment{}, 0, fmt.Errorf("Error fetching deployments: %v", err)
@@ -0,0 +1,224 @@
+package entity
+
+import (
+	"fmt"
+	"kspm/pkg/riskposture"
+	"strings"
+
+	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// escalationCheck is a permission that lets a subject gain more access than
+// it was granted
+type escalationCheck struct {
+	id    string
+	title string
+	why   string
+	// requests lists the alternatives granting the permission, any one suffices
+	requests []ResourceRequest
+}
+
+func rbacRequests(verbs []string, resources ...string) []ResourceRequest {
+	var out []ResourceRequest
+	for _, resource := range resources {
+		for _, verb := range verbs {
+			out = append(out, ResourceRequest{Verb: verb, APIGroup: v1.GroupName, Resource: resource})
+		}
+	}
+	return out
+}
+
+var escalationChecks = []escalationCheck{
+	{
+		id:       "rbac-modify-bindings",
+		title:    "RoleBinding modification",
+		why:      "can create or patch role bindings to grant itself any role it may bind",
+		requests: rbacRequests([]string{"create", "update", "patch"}, "rolebindings", "clusterrolebindings"),
+	},
+	{
+		id:       "rbac-escalate-roles",
+		title:    "Role escalation",
+		why:      "holds the escalate verb and can add any permission to a role it can edit",
+		requests: rbacRequests([]string{"escalate"}, "roles", "clusterroles"),
+	},
+	{
+		id:       "rbac-bind-roles",
+		title:    "Role binding without holding its permissions",
+		why:      "holds the bind verb and can bind roles with permissions it does not have",
+		requests: rbacRequests([]string{"bind"}, "roles", "clusterroles"),
+	},
+	{
+		id:    "rbac-impersonate",
+		title: "Impersonation",
+		why:   "can impersonate other users, groups or ServiceAccounts",
+		requests: []ResourceRequest{
+			{Verb: "impersonate", Resource: "users"},
+			{Verb: "impersonate", Resource: "groups"},
+			{Verb: "impersonate", Resource: "serviceaccounts"},
+		},
+	},
+	{
+		id:       "rbac-serviceaccount-token",
+		title:    "ServiceAccount token minting",
+		why:      "can create tokens for any ServiceAccount in scope and act as it",
+		requests: []ResourceRequest{{Verb: "create", Resource: "serviceaccounts", Subresource: "token"}},
+	},
+	{
+		id:    "rbac-approve-csr",
+		title: "Certificate signing request approval",
+		why:   "can approve CSRs and mint client certificates for any identity",
+		requests: []ResourceRequest{
+			{Verb: "update", APIGroup: "certificates.k8s.io", Resource: "certificatesigningrequests", Subresource: "approval"},
+			{Verb: "patch", APIGroup: "certificates.k8s.io", Resource: "certificatesigningrequests", Subresource: "approval"},
+		},
+	},
+	{
+		id:    "rbac-modify-webhooks",
+		title: "Admission webhook modification",
+		why:   "can register admission webhooks that see or mutate every object sent to the API server",
+		requests: func() []ResourceRequest {
+			var out []ResourceRequest
+			for _, resource := range []string{"mutatingwebhookconfigurations", "validatingwebhookconfigurations"} {
+				for _, verb := range []string{"create", "update", "patch"} {
+					out = append(out, ResourceRequest{Verb: verb, APIGroup: "admissionregistration.k8s.io", Resource: resource})
+				}
+			}
+			return out
+		}(),
+	},
+}
+
+// escalationCandidate reports whether a subject is worth computing paths for.
+// Bootstrapped control plane identities hold these permissions by design.
+func escalationCandidate(subject v1.Subject) bool {
+	switch subject.Name {
+	case GroupAuthenticated, GroupUnauthenticated, UserAnonymous, GroupServiceAccounts:
+		return true
+	}
+	if subject.Kind == v1.ServiceAccountKind {
+		return subject.Namespace != "kube-system"
+	}
+	return !strings.HasPrefix(subject.Name, "system:")
+}
+
+// grantedBy returns the grants of the subject allowing one of the requests,
+// skipping bootstrapped bindings
+func grantedBy(p SubjectPermissions, requests []ResourceRequest) []Grant {
+	var out []Grant
+	for _, g := range p.Grants {
+		if g.Via != "" || IsBuiltinBinding(metav1.ObjectMeta{Namespace: g.Binding.Namespace, Name: g.Binding.Name, Labels: g.Binding.Labels}) {
+			continue
+		}
+	rules:
+		for _, rule := range g.Rules {
+			for _, req := range requests {
+				if ok, names := RuleAllows(rule, req); ok && len(names) == 0 {
+					out = append(out, g)
+					break rules
+				}
+			}
+		}
+	}
+	return out
+}
+
+func grantEvidence(g Grant) string {
+	return fmt.Sprintf("%s/%s -> %s/%s (%s)", g.Binding.Kind, g.Binding.Name, g.RoleRef.Kind, g.RoleRef.Name, g.Scope())
+}
+
+func subjectStep(subject v1.Subject, why string) riskposture.AttackStep {
+	return riskposture.AttackStep{Kind: subject.Kind, Namespace: subject.Namespace, Name: subject.Name, Why: why}
+}
+
+// EscalationPaths computes the privilege escalation chains the bindings
+// allow: subjects that can create pods where a ServiceAccount holds access
+// they lack, or that can modify bindings, escalate or bind roles,
+// impersonate, mint ServiceAccount tokens, approve CSRs or modify admission
+// webhooks. Subjects that already hold cluster-admin are skipped.
+func (s *RBACSnapshot) EscalationPaths() []riskposture.AttackPath {
+	inventory := s.Inventory()
+
+	var paths []riskposture.AttackPath
+	for _, p := range inventory {
+		if !escalationCandidate(p.Subject) || p.ClusterAdmin() {
+			continue
+		}
+		subject := SubjectString(p.Subject)
+
+		for _, check := range escalationChecks {
+			for _, g := range grantedBy(p, check.requests) {
+				severity := "HIGH"
+				if g.Namespace == "" {
+					severity = "CRITICAL"
+				}
+				paths = append(paths, riskposture.AttackPath{
+					ID:         fmt.Sprintf("%s:%s:%s", check.id, subject, g.Binding.Name),
+					Title:      fmt.Sprintf("%s by %s (%s)", check.title, subject, g.Scope()),
+					Severity:   severity,
+					Confidence: 80,
+					Steps: []riskposture.AttackStep{
+						subjectStep(p.Subject, fmt.Sprintf("bound to %s/%s through %s/%s", g.RoleRef.Kind, g.RoleRef.Name, g.Binding.Kind, g.Binding.Name)),
+						{Kind: g.RoleRef.Kind, Name: g.RoleRef.Name, Namespace: g.Namespace, Why: check.why},
+					},
+					Evidence: []string{grantEvidence(g)},
+				})
+			}
+		}
+
+		paths = append(paths, s.podCreationPaths(p, inventory)...)
+	}
+	return paths
+}
+
+// podCreationPaths finds the ServiceAccounts a subject can run a pod as, in
+// the namespaces it can create pods in, that hold access the subject lacks
+func (s *RBACSnapshot) podCreationPaths(p SubjectPermissions, inventory []SubjectPermissions) []riskposture.AttackPath {
+	createPods := []ResourceRequest{{Verb: "create", Resource: "pods"}}
+	subject := SubjectString(p.Subject)
+	own := map[string]bool{}
+	for _, risk := range p.Risks() {
+		own[risk] = true
+	}
+
+	var paths []riskposture.AttackPath
+	for _, g := range grantedBy(p, createPods) {
+		for _, sa := range inventory {
+			if sa.Subject.Kind != v1.ServiceAccountKind || SubjectString(sa.Subject) == subject ||
+				(g.Namespace != "" && sa.Subject.Namespace != g.Namespace) {
+				continue
+			}
+			var gained []string
+			for _, risk := range sa.Risks() {
+				if !own[risk] {
+					gained = append(gained, risk)
+				}
+			}
+			if len(gained) == 0 {
+				continue
+			}
+
+			severity := "HIGH"
+			if sa.ClusterAdmin() {
+				severity = "CRITICAL"
+			}
+			evidence := []string{grantEvidence(g)}
+			for _, sg := range sa.Grants {
+				evidence = append(evidence, fmt.Sprintf("%s: %s", SubjectString(sa.Subject), grantEvidence(sg)))
+			}
+			paths = append(paths, riskposture.AttackPath{
+				ID:         fmt.Sprintf("rbac-pod-serviceaccount:%s:%s:%s", subject, g.Binding.Name, SubjectString(sa.Subject)),
+				Title:      fmt.Sprintf("Pod creation by %s to ServiceAccount %s/%s", subject, sa.Subject.Namespace, sa.Subject.Name),
+				Severity:   severity,
+				Confidence: 85,
+				Steps: []riskposture.AttackStep{
+					subjectStep(p.Subject, fmt.Sprintf("can create pods (%s) through %s/%s", g.Scope(), g.Binding.Kind, g.Binding.Name)),
+					{Kind: "Pod", Namespace: sa.Subject.Namespace, Name: "*", Why: "a new pod can run as any ServiceAccount of its namespace and read its token"},
+					subjectStep(sa.Subject, "holds "+strings.Join(gained, ", ")),
+				},
+				Evidence: evidence,
+			})
+		}
+	}
+	return paths
+}
@@ -0,0 +1,86 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEscalationPaths(t *testing.T) {
+	snapshot := &RBACSnapshot{
+		Roles: []v1.Role{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "pod-creator", Namespace: "ci"},
+				Rules:      []v1.PolicyRule{{Verbs: []string{"create"}, APIGroups: []string{""}, Resources: []string{"pods"}}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "binder", Namespace: "dev"},
+				Rules:      []v1.PolicyRule{{Verbs: []string{"patch"}, APIGroups: []string{"rbac.authorization.k8s.io"}, Resources: []string{"rolebindings"}}},
+			},
+		},
+		ClusterRoles: []v1.ClusterRole{
+			{ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"}, Rules: []v1.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "csr-approver"}, Rules: []v1.PolicyRule{{
+				Verbs: []string{"update"}, APIGroups: []string{"certificates.k8s.io"}, Resources: []string{"certificatesigningrequests/approval"},
+			}}},
+		},
+		ClusterRoleBindings: []v1.ClusterRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "ci-deployer-admin"},
+				RoleRef:    v1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+				Subjects:   []v1.Subject{{Kind: v1.ServiceAccountKind, Namespace: "ci", Name: "deployer"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "approvers"},
+				RoleRef:    v1.RoleRef{Kind: "ClusterRole", Name: "csr-approver"},
+				Subjects:   []v1.Subject{{Kind: v1.GroupKind, Name: "platform"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "system:controller:certificate-controller", Labels: map[string]string{"kubernetes.io/bootstrapping": "rbac-defaults"}},
+				RoleRef:    v1.RoleRef{Kind: "ClusterRole", Name: "csr-approver"},
+				Subjects:   []v1.Subject{{Kind: v1.ServiceAccountKind, Namespace: "kube-system", Name: "certificate-controller"}},
+			},
+		},
+		RoleBindings: []v1.RoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "devs-create-pods", Namespace: "ci"},
+				RoleRef:    v1.RoleRef{Kind: "Role", Name: "pod-creator"},
+				Subjects:   []v1.Subject{{Kind: v1.UserKind, Name: "alice"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "alice-create-pods", Namespace: "ci"},
+				RoleRef:    v1.RoleRef{Kind: "Role", Name: "pod-creator"},
+				Subjects:   []v1.Subject{{Kind: v1.UserKind, Name: "alice"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "bob-binder", Namespace: "dev"},
+				RoleRef:    v1.RoleRef{Kind: "Role", Name: "binder"},
+				Subjects:   []v1.Subject{{Kind: v1.UserKind, Name: "bob"}},
+			},
+		},
+	}
+
+	byTitle := map[string]string{}
+	ids := map[string]bool{}
+	for _, p := range snapshot.EscalationPaths() {
+		assert.False(t, ids[p.ID], "duplicate path %s", p.ID)
+		ids[p.ID] = true
+		byTitle[p.Title] = p.Severity
+		if p.ID == "rbac-pod-serviceaccount:User/alice:devs-create-pods:ServiceAccount/ci/deployer" {
+			if assert.Len(t, p.Steps, 3) {
+				assert.Equal(t, "ServiceAccount", p.Steps[2].Kind)
+				assert.Contains(t, p.Steps[2].Why, "cluster-admin")
+			}
+			assert.Contains(t, p.Evidence, "RoleBinding/devs-create-pods -> Role/pod-creator (ci)")
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"Pod creation by User/alice to ServiceAccount ci/deployer":              "CRITICAL",
+		"RoleBinding modification by User/bob (dev)":                            "HIGH",
+		"Certificate signing request approval by Group/platform (cluster-wide)": "CRITICAL",
+	}, byTitle, "cluster-admins and bootstrapped controllers are not reported")
+	assert.True(t, ids["rbac-pod-serviceaccount:User/alice:alice-create-pods:ServiceAccount/ci/deployer"])
+	assert.Len(t, ids, 4, "one pod creation path per binding")
+}
@@ -3,7 +3,9 @@ package entity
 import (
 	"context"
 	"fmt"
+	"kspm/pkg/findings"
 	"kspm/pkg/riskposture"
+	"kspm/pkg/rules"
 	"log"
 	"os"
 	"strings"
@@ -62,7 +64,93 @@ func contains(list []string, v string) bool {
 	return false
 }
 
-func AnalyzeClusterRoles(clientset *kubernetes.Clientset, clusterRoleName string) ([]string, []riskposture.Signal, error) {
+// Rule IDs of the checks that need every binding, role and ServiceAccount
+// of the cluster, see DanglingFindings
+const (
+	RuleBindingMissingRole           = "RBAC-BINDING-MISSING-ROLE"
+	RuleBindingMissingServiceAccount = "RBAC-BINDING-MISSING-SA"
+	RuleRoleUnbound                  = "RBAC-ROLE-UNBOUND"
+)
+
+// DanglingFindings reports bindings whose role or ServiceAccount does not
+// exist, and custom Roles and ClusterRoles bound to nobody. Whoever can later
+// create the missing role or ServiceAccount inherits the binding. ClusterRoles
+// aggregated into another ClusterRole count as bound.
+func (s *RBACSnapshot) DanglingFindings() []findings.Finding {
+	serviceAccounts := map[string]bool{}
+	for _, sa := range s.ServiceAccounts {
+		serviceAccounts[sa.Namespace+"/"+sa.Name] = true
+	}
+
+	var out []findings.Finding
+	bound := map[string]bool{}
+	check := func(meta metav1.ObjectMeta, resource findings.Resource, ref v1.RoleRef, namespace string, subjects []v1.Subject) {
+		if ref.Kind == "Role" {
+			bound["Role/"+namespace+"/"+ref.Name] = true
+		} else {
+			bound["ClusterRole/"+ref.Name] = true
+		}
+		if IsBuiltinBinding(meta) {
+			return
+		}
+
+		if _, ok := s.RoleRules(ref, namespace); !ok {
+			out = append(out, rules.NewFinding(RuleBindingMissingRole, resource, rules.Violation{
+				Message:  fmt.Sprintf("references %s/%s which does not exist", ref.Kind, ref.Name),
+				Evidence: []string{fmt.Sprintf("roleRef=%s/%s", ref.Kind, ref.Name)},
+			}))
+		}
+		for _, subject := range subjects {
+			subject = bindingSubject(subject, namespace)
+			if subject.Kind != v1.ServiceAccountKind || serviceAccounts[subject.Namespace+"/"+subject.Name] {
+				continue
+			}
+			out = append(out, rules.NewFinding(RuleBindingMissingServiceAccount, resource, rules.Violation{
+				Message:  fmt.Sprintf("binds %s/%s to ServiceAccount %s/%s which does not exist", ref.Kind, ref.Name, subject.Namespace, subject.Name),
+				Evidence: []string{"subject=" + SubjectString(subject)},
+			}))
+		}
+	}
+	for i := range s.ClusterRoleBindings {
+		crb := &s.ClusterRoleBindings[i]
+		check(crb.ObjectMeta, ClusterRoleBindingResource(crb), crb.RoleRef, "", crb.Subjects)
+	}
+	for i := range s.RoleBindings {
+		rb := &s.RoleBindings[i]
+		check(rb.ObjectMeta, RoleBindingResource(rb), rb.RoleRef, rb.Namespace, rb.Subjects)
+	}
+
+	for i := range s.ClusterRoles {
+		cr := &s.ClusterRoles[i]
+		if cr.AggregationRule == nil {
+			continue
+		}
+		for _, source := range s.aggregatedClusterRoles(cr) {
+			bound["ClusterRole/"+source.Name] = true
+		}
+	}
+	unbound := func(resource findings.Resource) findings.Finding {
+		return rules.NewFinding(RuleRoleUnbound, resource, rules.Violation{
+			Message: fmt.Sprintf("%s is not bound to any subject", resource.Kind),
+		})
+	}
+	for i := range s.ClusterRoles {
+		cr := &s.ClusterRoles[i]
+		if !bound["ClusterRole/"+cr.Name] && !IsBuiltinClusterRole(cr.Name) && cr.Labels["kubernetes.io/bootstrapping"] != "rbac-defaults" {
+			out = append(out, unbound(ClusterRoleResource(cr)))
+		}
+	}
+	for i := range s.Roles {
+		role := &s.Roles[i]
+		// Bootstrapped Roles carry the same markers as bootstrapped bindings
+		if !bound["Role/"+role.Namespace+"/"+role.Name] && !IsBuiltinBinding(role.ObjectMeta) {
+			out = append(out, unbound(RoleResource(role)))
+		}
+	}
+	return out
+}
+
+func AnalyzeClusterRoles(clientset kubernetes.Interface, clusterRoleName string) ([]findings.Finding, []riskposture.Signal, error) {
 	rbacClient := clientset.RbacV1()
 
 	cr, err := rbacClient.ClusterRoles().Get(context.TODO(), clusterRoleName, metav1.GetOptions{})
@@ -70,72 +158,43 @@ func AnalyzeClusterRoles(clientset *kubernetes.Clientset, clusterRoleName string
 		return nil, nil, fmt.Errorf("failed to fetch cluster role %q: %w", clusterRoleName, err)
 	}
 
-	var findings []string
-	var signals []riskposture.Signal
+	out := EvaluateClusterRole(cr)
 
-	for _, rule := range cr.Rules {
-		// --- Wildcard verbs ---
-		if HasWildcard(rule.Verbs) {
-			findings = append(findings,
-				fmt.Sprintf("[HIGH] ClusterRole/%s: wildcard verbs '*' detected (highly privileged)", cr.Name),
-			)
-			signals = append(signals, riskposture.Signal{
-				Name:     "WildcardRBAC",
-				Severity: "HIGH",
-				Weight:   25,
-			})
+	var signals []riskposture.Signal
+	for _, f := range out {
+		if signal, ok := riskposture.SignalForFinding(f); ok {
+			signals = append(signals, signal)
 		}
+	}
 
-		// --- Dangerous verbs ---
-		if HasDangerousVerbs(rule.Verbs) {
-			findings = append(findings,
-				fmt.Sprintf("[MEDIUM] ClusterRole/%s: dangerous verbs detected (%v)", cr.Name, rule.Verbs),
-			)
-			signals = append(signals, riskposture.Signal{
-				Name:     "DangerousRBACVerbs",
-				Severity: "MEDIUM",
-				Weight:   15,
-			})
-		}
+	return out, signals, nil
+}
 
-		// --- Wildcard resources ---
-		if HasWildcard(rule.Resources) {
-			findings = append(findings,
-				fmt.Sprintf("[HIGH] ClusterRole/%s: wildcard resources '*' detected", cr.Name),
-			)
-			signals = append(signals, riskposture.Signal{
-				Name:     "WildcardRBACResources",
-				Severity: "HIGH",
-				Weight:   25,
-			})
-		}
+// ClusterRoleResource identifies a ClusterRole in findings
+func ClusterRoleResource(cr *v1.ClusterRole) findings.Resource {
+	return findings.Resource{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: cr.Name, Labels: cr.Labels}
+}
 
-		// --- Secrets access ---
-		if contains(rule.Resources, "secrets") && (contains(rule.Verbs, "get") || contains(rule.Verbs, "list") || contains(rule.Verbs, "watch")) {
-			findings = append(findings,
-				fmt.Sprintf("[HIGH] ClusterRole/%s: read access to secrets detected (verbs=%v)", cr.Name, rule.Verbs),
-			)
-			signals = append(signals, riskposture.Signal{
-				Name:     "SecretsAccess",
-				Severity: "HIGH",
-				Weight:   20,
-			})
-		}
+// RoleResource identifies a namespaced Role in findings
+func RoleResource(role *v1.Role) findings.Resource {
+	return findings.Resource{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role", Namespace: role.Namespace, Name: role.Name, Labels: role.Labels}
+}
 
-		// --- Impersonate / escalation-ish verbs (nice add) ---
-		if contains(rule.Verbs, "impersonate") || contains(rule.Verbs, "bind") || contains(rule.Verbs, "escalate") {
-			findings = append(findings,
-				fmt.Sprintf("[HIGH] ClusterRole/%s: escalation verbs detected (impersonate/bind/escalate)", cr.Name),
-			)
-			signals = append(signals, riskposture.Signal{
-				Name:     "RBACEscalationVerbs",
-				Severity: "HIGH",
-				Weight:   25,
-			})
-		}
-	}
+// EvaluateClusterRole runs the registered RBAC rules against a ClusterRole
+func EvaluateClusterRole(cr *v1.ClusterRole) []findings.Finding {
+	return rules.Evaluate(ClusterRoleResource(cr), cr)
+}
+
+// EvaluateRole runs the registered RBAC rules against a namespaced Role
+func EvaluateRole(role *v1.Role) []findings.Finding {
+	return rules.Evaluate(RoleResource(role), role)
+}
 
-	return findings, signals, nil
+// IsBuiltinClusterRole reports whether a ClusterRole ships with Kubernetes or kubeadm
+func IsBuiltinClusterRole(name string) bool {
+	return strings.HasPrefix(name, "system:") ||
+		strings.HasPrefix(name, "kubeadm:") ||
+		strings.HasPrefix(name, "k8s.io:")
 }
 
 func HasWildcard(verbs []string) bool {
@@ -295,6 +354,9 @@ func ExtractPermissionsAndResources(rule PolicyRule) ([]string, []string, []stri
 	return permissions, resources, flaggedPermissions, nil
 }
 
+// ConvertRoleToSignals derives risk signals from a role's rules. The
+// ClusterAdminBinding signal comes from real bindings, through the
+// RBAC-BINDING-CLUSTER-ADMIN rule.
 func ConvertRoleToSignals(
 	role RBACRoleList,
 	roleList [][]PolicyRule,
@@ -310,16 +372,6 @@ func ConvertRoleToSignals(
 				continue
 			}
 
-			// --- CRITICAL: cluster-admin equivalent ---
-			if role.Name == "cluster-admin" {
-				signals = append(signals, riskposture.Signal{
-					Name:     "ClusterAdminBinding",
-					Severity: "CRITICAL",
-					Weight:   40,
-				})
-				continue
-			}
-
 			// --- HIGH: wildcard RBAC ---
 			if HasWildcard(perms) || HasWildcard(resources) {
 				signals = append(signals, riskposture.Signal{
@@ -360,3 +412,112 @@ func ConvertRoleToSignals(
 
 	return signals
 }
+
+// ResourceRequest is the access being asked about, e.g. "get secrets" or
+// "create pods/exec"
+type ResourceRequest struct {
+	Verb string `json:"verb"`
+	// APIGroup is matched only when AnyGroup is false
+	APIGroup    string `json:"apiGroup"`
+	AnyGroup    bool   `json:"-"`
+	Resource    string `json:"resource,omitempty"`
+	Subresource string `json:"subresource,omitempty"`
+	Name        string `json:"name,omitempty"`
+	// NonResourceURL is set for requests such as "get /metrics"
+	NonResourceURL string `json:"nonResourceURL,omitempty"`
+}
+
+// ParseResourceRequest parses a verb and a resource written as
+// resource[.group][/subresource], or a non-resource URL starting with "/".
+// Without a group the resource matches in any API group; a trailing "."
+// selects the core group.
+func ParseResourceRequest(verb, resource string) (ResourceRequest, error) {
+	req := ResourceRequest{Verb: strings.ToLower(verb)}
+	if req.Verb == "" || resource == "" {
+		return req, fmt.Errorf("verb and resource are required")
+	}
+	if strings.HasPrefix(resource, "/") {
+		req.NonResourceURL = resource
+		return req, nil
+	}
+
+	parts := strings.SplitN(resource, "/", 2)
+	req.Resource = parts[0]
+	if len(parts) > 1 {
+		req.Subresource = parts[1]
+	}
+	if i := strings.Index(req.Resource, "."); i >= 0 {
+		req.Resource, req.APIGroup = req.Resource[:i], req.Resource[i+1:]
+	} else {
+		req.AnyGroup = true
+	}
+	if req.Resource == "" {
+		return req, fmt.Errorf("invalid resource %q", resource)
+	}
+	return req, nil
+}
+
+// String renders the request as "verb resource[/subresource]"
+func (r ResourceRequest) String() string {
+	if r.NonResourceURL != "" {
+		return r.Verb + " " + r.NonResourceURL
+	}
+	resource := r.Resource
+	if !r.AnyGroup && r.APIGroup != "" {
+		resource += "." + r.APIGroup
+	}
+	if r.Subresource != "" {
+		resource += "/" + r.Subresource
+	}
+	if r.Name != "" {
+		resource += " " + r.Name
+	}
+	return r.Verb + " " + resource
+}
+
+// RuleAllows reports whether a PolicyRule grants the request, honouring '*'
+// in verbs, apiGroups and resources, "*/subresource" and resourceNames. When
+// the request names no object, a rule limited by resourceNames still matches
+// and the names it is limited to are returned.
+func RuleAllows(rule v1.PolicyRule, req ResourceRequest) (bool, []string) {
+	if !HasWildcard(rule.Verbs) && !contains(rule.Verbs, req.Verb) {
+		return false, nil
+	}
+
+	if req.NonResourceURL != "" {
+		for _, url := range rule.NonResourceURLs {
+			if url == "*" || url == req.NonResourceURL ||
+				(strings.HasSuffix(url, "*") && strings.HasPrefix(req.NonResourceURL, strings.TrimSuffix(url, "*"))) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	if !req.AnyGroup && !HasWildcard(rule.APIGroups) && !contains(rule.APIGroups, req.APIGroup) {
+		return false, nil
+	}
+
+	resource := req.Resource
+	if req.Subresource != "" {
+		resource += "/" + req.Subresource
+	}
+	matched := false
+	for _, r := range rule.Resources {
+		if r == "*" || r == resource || (req.Subresource != "" && r == "*/"+req.Subresource) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return false, nil
+	}
+
+	if len(rule.ResourceNames) == 0 {
+		return true, nil
+	}
+	if req.Name == "" {
+		return true, rule.ResourceNames
+	}
+	return contains(rule.ResourceNames, req.Name), nil
+}
@@ -0,0 +1,292 @@
+package entity
+
+import (
+	"fmt"
+	"kspm/pkg/findings"
+	"kspm/pkg/rules"
+
+	v1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Built-in RBAC rules, evaluated against Roles and ClusterRoles
+func init() {
+	for _, rule := range rbacRules {
+		rules.MustRegister(rule)
+	}
+	for _, rule := range bindingRules {
+		rules.MustRegister(rule)
+	}
+	for _, rule := range danglingRules {
+		rules.MustDefine(rule)
+	}
+}
+
+// DefaultDangerousVerbs are the write verbs flagged by RBAC-SENSITIVE-WRITE
+var DefaultDangerousVerbs = []string{"create", "delete", "update", "patch"}
+
+var dangerousVerbs = DefaultDangerousVerbs
+
+// SetDangerousVerbs replaces the verbs RBAC-SENSITIVE-WRITE treats as writes
+func SetDangerousVerbs(verbs []string) {
+	dangerousVerbs = verbs
+}
+
+// policyRulesOf returns the rules of a Role or ClusterRole
+func policyRulesOf(obj runtime.Object) []v1.PolicyRule {
+	switch role := obj.(type) {
+	case *v1.ClusterRole:
+		return role.Rules
+	case *v1.Role:
+		return role.Rules
+	}
+	return nil
+}
+
+// ruleCheck adapts a per-PolicyRule check to a rules.CheckFunc
+func ruleCheck(check func(rule v1.PolicyRule) (string, bool)) rules.CheckFunc {
+	return func(obj runtime.Object) []rules.Violation {
+		var out []rules.Violation
+		for _, rule := range policyRulesOf(obj) {
+			if msg, ok := check(rule); ok {
+				out = append(out, rules.Violation{
+					Message:  msg,
+					Evidence: []string{fmt.Sprintf("verbs=%v resources=%v", rule.Verbs, rule.Resources)},
+				})
+			}
+		}
+		return out
+	}
+}
+
+var rbacRules = []rules.Rule{
+	{
+		ID:          "RBAC-WILDCARD-VERBS",
+		Kinds:       []string{"ClusterRole", "Role"},
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryRBAC,
+		Description: "Role grants every verb through '*'",
+		Remediation: "Replace '*' with the explicit verbs required",
+		Signal:      "WildcardRBAC",
+		Weight:      25,
+		Check: ruleCheck(func(rule v1.PolicyRule) (string, bool) {
+			return "wildcard verbs '*' detected (highly privileged)", HasWildcard(rule.Verbs)
+		}),
+	},
+	{
+		ID:          "RBAC-DANGEROUS-VERBS",
+		Kinds:       []string{"ClusterRole", "Role"},
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryRBAC,
+		Description: "Role grants write or escalation verbs",
+		Remediation: "Limit write verbs to the resources that need them",
+		Signal:      "DangerousRBACVerbs",
+		Weight:      15,
+		Check: ruleCheck(func(rule v1.PolicyRule) (string, bool) {
+			return fmt.Sprintf("dangerous verbs detected (%v)", rule.Verbs), HasDangerousVerbs(rule.Verbs)
+		}),
+	},
+	{
+		ID:          "RBAC-WILDCARD-RESOURCES",
+		Kinds:       []string{"ClusterRole", "Role"},
+		Severity:    findings.SeverityCritical,
+		Category:    findings.CategoryRBAC,
+		Description: "Role grants access to every resource through '*'",
+		Remediation: "Replace '*' with the explicit resources required",
+		Signal:      "WildcardRBACResources",
+		Weight:      25,
+		Check: ruleCheck(func(rule v1.PolicyRule) (string, bool) {
+			return "wildcard resources '*' detected", HasWildcard(rule.Resources)
+		}),
+	},
+	{
+		ID:          "RBAC-SENSITIVE-WRITE",
+		Kinds:       []string{"ClusterRole", "Role"},
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryRBAC,
+		Description: "Role can modify secrets or RBAC objects",
+		Remediation: "Restrict write access to RBAC objects and secrets",
+		Check: func(obj runtime.Object) []rules.Violation {
+			dangerousResources := []string{"secrets", "roles", "rolebindings", "clusterroles", "clusterrolebindings"}
+
+			var out []rules.Violation
+			for _, rule := range policyRulesOf(obj) {
+				for _, resource := range rule.Resources {
+					if !contains(dangerousResources, resource) {
+						continue
+					}
+					for _, verb := range rule.Verbs {
+						if contains(dangerousVerbs, verb) {
+							out = append(out, rules.Violation{
+								Message:  fmt.Sprintf("sensitive permission: %s %s", verb, resource),
+								Evidence: []string{fmt.Sprintf("%s %s", verb, resource)},
+							})
+						}
+					}
+				}
+			}
+			return out
+		},
+	},
+	{
+		ID:          "RBAC-SECRETS-READ",
+		Kinds:       []string{"ClusterRole", "Role"},
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryRBAC,
+		Description: "Role can read secrets",
+		Remediation: "Restrict secret access with resourceNames or remove it",
+		Signal:      "SecretsAccess",
+		Weight:      20,
+		Check: ruleCheck(func(rule v1.PolicyRule) (string, bool) {
+			read := contains(rule.Verbs, "get") || contains(rule.Verbs, "list") || contains(rule.Verbs, "watch")
+			return fmt.Sprintf("read access to secrets detected (verbs=%v)", rule.Verbs), contains(rule.Resources, "secrets") && read
+		}),
+	},
+	{
+		ID:          "RBAC-ESCALATION-VERBS",
+		Kinds:       []string{"ClusterRole", "Role"},
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryRBAC,
+		Description: "Role grants impersonate, bind or escalate",
+		Remediation: "Remove impersonate, bind and escalate unless strictly required",
+		Signal:      "RBACEscalationVerbs",
+		Weight:      25,
+		Check: ruleCheck(func(rule v1.PolicyRule) (string, bool) {
+			escalation := contains(rule.Verbs, "impersonate") || contains(rule.Verbs, "bind") || contains(rule.Verbs, "escalate")
+			return "escalation verbs detected (impersonate/bind/escalate)", escalation
+		}),
+	},
+	{
+		ID:          "RBAC-AGGREGATES-TO-BUILTIN",
+		Kinds:       []string{"ClusterRole"},
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryRBAC,
+		Description: "ClusterRole aggregates secrets, pods/exec or escalation permissions into admin, edit or view",
+		Remediation: "Remove the aggregate-to-* label and bind the ClusterRole only to the subjects that need it",
+		Signal:      "BuiltinRoleAggregation",
+		Weight:      25,
+		Check:       checkBuiltinAggregation,
+	},
+}
+
+// bindingOf returns the role reference and subjects of a RoleBinding or
+// ClusterRoleBinding, with ServiceAccount namespaces filled in
+func bindingOf(obj runtime.Object) (v1.RoleRef, []v1.Subject) {
+	var subjects []v1.Subject
+	switch binding := obj.(type) {
+	case *v1.ClusterRoleBinding:
+		return binding.RoleRef, binding.Subjects
+	case *v1.RoleBinding:
+		for _, subject := range binding.Subjects {
+			subjects = append(subjects, bindingSubject(subject, binding.Namespace))
+		}
+		return binding.RoleRef, subjects
+	}
+	return v1.RoleRef{}, nil
+}
+
+// subjectCheck adapts a per-subject binding check to a rules.CheckFunc
+func subjectCheck(check func(ref v1.RoleRef, subject v1.Subject) (string, bool)) rules.CheckFunc {
+	return func(obj runtime.Object) []rules.Violation {
+		ref, subjects := bindingOf(obj)
+		var out []rules.Violation
+		for _, subject := range subjects {
+			if msg, ok := check(ref, subject); ok {
+				out = append(out, rules.Violation{
+					Message:  msg,
+					Evidence: []string{fmt.Sprintf("roleRef=%s/%s subject=%s", ref.Kind, ref.Name, SubjectString(subject))},
+				})
+			}
+		}
+		return out
+	}
+}
+
+var bindingRules = []rules.Rule{
+	{
+		ID:          "RBAC-BINDING-CLUSTER-ADMIN",
+		Kinds:       []string{"ClusterRoleBinding", "RoleBinding"},
+		Severity:    findings.SeverityCritical,
+		Category:    findings.CategoryRBAC,
+		Description: "Binding grants the cluster-admin ClusterRole",
+		Remediation: "Bind a role scoped to the permissions the subject needs instead of cluster-admin",
+		Signal:      "ClusterAdminBinding",
+		Weight:      40,
+		Check: subjectCheck(func(ref v1.RoleRef, subject v1.Subject) (string, bool) {
+			return fmt.Sprintf("cluster-admin bound to %s", SubjectString(subject)),
+				ref.Kind == "ClusterRole" && ref.Name == "cluster-admin"
+		}),
+	},
+	{
+		ID:          "RBAC-BINDING-ANONYMOUS",
+		Kinds:       []string{"ClusterRoleBinding", "RoleBinding"},
+		Severity:    findings.SeverityCritical,
+		Category:    findings.CategoryRBAC,
+		Description: "Binding grants a role to unauthenticated requests",
+		Remediation: "Remove system:anonymous and system:unauthenticated from the binding",
+		Signal:      "AnonymousRBACBinding",
+		Weight:      35,
+		Check: subjectCheck(func(ref v1.RoleRef, subject v1.Subject) (string, bool) {
+			anonymous := (subject.Kind == v1.UserKind && subject.Name == UserAnonymous) ||
+				(subject.Kind == v1.GroupKind && subject.Name == GroupUnauthenticated)
+			return fmt.Sprintf("%s/%s bound to unauthenticated %s", ref.Kind, ref.Name, SubjectString(subject)), anonymous
+		}),
+	},
+	{
+		ID:          "RBAC-BINDING-AUTHENTICATED",
+		Kinds:       []string{"ClusterRoleBinding", "RoleBinding"},
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryRBAC,
+		Description: "Binding grants a role to every authenticated identity",
+		Remediation: "Bind the role to the specific users, groups or ServiceAccounts that need it",
+		Signal:      "AuthenticatedRBACBinding",
+		Weight:      20,
+		Check: subjectCheck(func(ref v1.RoleRef, subject v1.Subject) (string, bool) {
+			return fmt.Sprintf("%s/%s bound to every authenticated identity (%s)", ref.Kind, ref.Name, GroupAuthenticated),
+				subject.Kind == v1.GroupKind && subject.Name == GroupAuthenticated
+		}),
+	},
+	{
+		ID:          "RBAC-BINDING-DEFAULT-SA",
+		Kinds:       []string{"ClusterRoleBinding", "RoleBinding"},
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryRBAC,
+		Description: "Binding grants a role to a namespace's default ServiceAccount",
+		Remediation: "Create a dedicated ServiceAccount for the workload and bind the role to it",
+		Signal:      "DefaultServiceAccountBinding",
+		Weight:      10,
+		Check: subjectCheck(func(ref v1.RoleRef, subject v1.Subject) (string, bool) {
+			return fmt.Sprintf("%s/%s bound to the default ServiceAccount of namespace %s", ref.Kind, ref.Name, subject.Namespace),
+				subject.Kind == v1.ServiceAccountKind && subject.Name == "default"
+		}),
+	},
+}
+
+// danglingRules are reported by DanglingFindings, which looks across the
+// bindings, roles and ServiceAccounts, so they carry no Check
+var danglingRules = []rules.Rule{
+	{
+		ID:          RuleBindingMissingRole,
+		Kinds:       []string{"ClusterRoleBinding", "RoleBinding"},
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryRBAC,
+		Description: "Binding references a role that does not exist",
+		Remediation: "Delete the binding, or create the role it should grant",
+	},
+	{
+		ID:          RuleBindingMissingServiceAccount,
+		Kinds:       []string{"ClusterRoleBinding", "RoleBinding"},
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryRBAC,
+		Description: "Binding grants a role to a ServiceAccount that does not exist",
+		Remediation: "Remove the subject from the binding; anyone able to create the ServiceAccount inherits its permissions",
+	},
+	{
+		ID:          RuleRoleUnbound,
+		Kinds:       []string{"ClusterRole", "Role"},
+		Severity:    findings.SeverityLow,
+		Category:    findings.CategoryRBAC,
+		Description: "Role is not bound to any subject",
+		Remediation: "Delete the role if it is no longer needed",
+	},
+}
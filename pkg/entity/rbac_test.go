@@ -0,0 +1,98 @@
+package entity
+
+import (
+	"testing"
+
+	"kspm/pkg/rules"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseResourceRequest(t *testing.T) {
+	req, err := ParseResourceRequest("CREATE", "pods/exec")
+	require.NoError(t, err)
+	assert.Equal(t, ResourceRequest{Verb: "create", Resource: "pods", Subresource: "exec", AnyGroup: true}, req)
+
+	req, err = ParseResourceRequest("patch", "deployments.apps/scale")
+	require.NoError(t, err)
+	assert.Equal(t, ResourceRequest{Verb: "patch", APIGroup: "apps", Resource: "deployments", Subresource: "scale"}, req)
+	assert.Equal(t, "patch deployments.apps/scale", req.String())
+
+	req, err = ParseResourceRequest("get", "secrets.")
+	require.NoError(t, err)
+	assert.False(t, req.AnyGroup, "a trailing dot selects the core group")
+
+	req, err = ParseResourceRequest("get", "/metrics")
+	require.NoError(t, err)
+	assert.Equal(t, "/metrics", req.NonResourceURL)
+
+	_, err = ParseResourceRequest("get", "")
+	assert.Error(t, err)
+}
+
+func TestRuleAllows(t *testing.T) {
+	secrets := ResourceRequest{Verb: "get", Resource: "secrets", AnyGroup: true}
+	exec := ResourceRequest{Verb: "create", Resource: "pods", Subresource: "exec", AnyGroup: true}
+
+	tests := []struct {
+		name  string
+		rule  v1.PolicyRule
+		req   ResourceRequest
+		ok    bool
+		names []string
+	}{
+		{"exact", v1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}}, secrets, true, nil},
+		{"wrong verb", v1.PolicyRule{Verbs: []string{"list"}, Resources: []string{"secrets"}}, secrets, false, nil},
+		{"wildcards", v1.PolicyRule{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}, exec, true, nil},
+		{"parent resource is not the subresource", v1.PolicyRule{Verbs: []string{"create"}, Resources: []string{"pods"}}, exec, false, nil},
+		{"any subresource", v1.PolicyRule{Verbs: []string{"create"}, Resources: []string{"*/exec"}}, exec, true, nil},
+		{"resource names", v1.PolicyRule{Verbs: []string{"get"}, Resources: []string{"secrets"}, ResourceNames: []string{"tls"}}, secrets, true, []string{"tls"}},
+		{"other group", v1.PolicyRule{Verbs: []string{"get"}, APIGroups: []string{"apps"}, Resources: []string{"secrets"}},
+			ResourceRequest{Verb: "get", Resource: "secrets"}, false, nil},
+		{"non-resource prefix", v1.PolicyRule{Verbs: []string{"get"}, NonResourceURLs: []string{"/metrics*"}},
+			ResourceRequest{Verb: "get", NonResourceURL: "/metrics/cadvisor"}, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, names := RuleAllows(tt.rule, tt.req)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.names, names)
+		})
+	}
+
+	named := secrets
+	named.Name = "db"
+	ok, _ := RuleAllows(v1.PolicyRule{Verbs: []string{"get"}, Resources: []string{"secrets"}, ResourceNames: []string{"tls"}}, named)
+	assert.False(t, ok)
+}
+
+func TestDanglingFindings(t *testing.T) {
+	snapshot := testSnapshot()
+	snapshot.ServiceAccounts = []corev1.ServiceAccount{{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "prod"}}}
+	snapshot.Roles = append(snapshot.Roles, v1.Role{ObjectMeta: metav1.ObjectMeta{Name: "leftover", Namespace: "prod"}})
+	snapshot.RoleBindings = append(snapshot.RoleBindings, v1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "old-app", Namespace: "prod"},
+		RoleRef:    v1.RoleRef{Kind: "Role", Name: "deleted-role"},
+		Subjects:   []v1.Subject{{Kind: v1.ServiceAccountKind, Name: "old-app"}},
+	})
+
+	var got []string
+	for _, f := range snapshot.DanglingFindings() {
+		got = append(got, f.RuleID+" "+f.Resource.String()+": "+f.Message)
+		rule, ok := rules.Get(f.RuleID)
+		if assert.True(t, ok, "%s is defined in the rule registry", f.RuleID) {
+			assert.Equal(t, rule.Severity, f.Severity)
+			assert.Equal(t, rule.Remediation, f.Remediation)
+		}
+	}
+	assert.Equal(t, []string{
+		"RBAC-BINDING-MISSING-SA ClusterRoleBinding/ci-admin: binds ClusterRole/cluster-admin to ServiceAccount ci/deployer which does not exist",
+		"RBAC-BINDING-MISSING-ROLE RoleBinding/old-app (prod): references Role/deleted-role which does not exist",
+		"RBAC-BINDING-MISSING-SA RoleBinding/old-app (prod): binds Role/deleted-role to ServiceAccount prod/old-app which does not exist",
+		"RBAC-ROLE-UNBOUND Role/leftover (prod): Role is not bound to any subject",
+	}, got)
+}
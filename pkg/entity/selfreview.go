@@ -0,0 +1,157 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// clusterAdminRequest is allowed only for identities holding every verb on
+// every resource
+var clusterAdminRequest = ResourceRequest{Verb: "*", AnyGroup: true, Resource: "*"}
+
+// NamespaceAccess is what the calling identity may do in one namespace, as
+// reported by a SelfSubjectRulesReview
+type NamespaceAccess struct {
+	Namespace string          `json:"namespace"`
+	Rules     []v1.PolicyRule `json:"rules"`
+	// Incomplete is set when an authorizer could not enumerate its rules, the
+	// identity may be allowed more than listed
+	Incomplete      bool   `json:"incomplete,omitempty"`
+	EvaluationError string `json:"evaluationError,omitempty"`
+}
+
+// Risks summarizes what makes the access dangerous, see RuleRisks
+func (a NamespaceAccess) Risks() []string {
+	return RuleRisks(a.Rules)
+}
+
+// Escalations lists the titles of the escalation checks the rules allow
+func (a NamespaceAccess) Escalations() []string {
+	var out []string
+	for _, check := range escalationChecks {
+	requests:
+		for _, req := range check.requests {
+			for _, rule := range a.Rules {
+				if ok, names := RuleAllows(rule, req); ok && len(names) == 0 {
+					out = append(out, check.title)
+					break requests
+				}
+			}
+		}
+	}
+	return out
+}
+
+// SelfAccess is the effective access of the calling identity, resolved by
+// the API server so no RBAC read permissions are needed
+type SelfAccess struct {
+	User   string   `json:"user,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+	// ClusterAdmin is set when the identity may perform any verb on any
+	// resource cluster-wide
+	ClusterAdmin bool `json:"clusterAdmin"`
+	// ClusterEscalations lists the escalation checks allowed cluster-wide
+	ClusterEscalations []string          `json:"clusterEscalations,omitempty"`
+	Namespaces         []NamespaceAccess `json:"namespaces"`
+}
+
+// ReviewSelfAccess resolves the identity of the client and what it may do
+// cluster-wide and in each namespace. The identity is left empty when the
+// API server does not serve SelfSubjectReviews.
+func ReviewSelfAccess(ctx context.Context, clientset kubernetes.Interface, namespaces []string) (*SelfAccess, error) {
+	access := &SelfAccess{}
+	if review, err := clientset.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{}); err == nil {
+		access.User = review.Status.UserInfo.Username
+		access.Groups = review.Status.UserInfo.Groups
+	}
+
+	var err error
+	if access.ClusterAdmin, err = CheckAccess(ctx, clientset, clusterAdminRequest, ""); err != nil {
+		return nil, err
+	}
+	for _, check := range escalationChecks {
+		for _, req := range check.requests {
+			allowed, err := CheckAccess(ctx, clientset, req, "")
+			if err != nil {
+				return nil, err
+			}
+			if allowed {
+				access.ClusterEscalations = append(access.ClusterEscalations, check.title)
+				break
+			}
+		}
+	}
+
+	for _, ns := range namespaces {
+		nsAccess, err := ReviewSelfRules(ctx, clientset, ns)
+		if err != nil {
+			return nil, err
+		}
+		access.Namespaces = append(access.Namespaces, nsAccess)
+	}
+	return access, nil
+}
+
+// ReviewSelfRules lists the rules the calling identity holds in a namespace,
+// including those granted cluster-wide
+func ReviewSelfRules(ctx context.Context, clientset kubernetes.Interface, namespace string) (NamespaceAccess, error) {
+	review, err := clientset.AuthorizationV1().SelfSubjectRulesReviews().Create(ctx, &authorizationv1.SelfSubjectRulesReview{
+		Spec: authorizationv1.SelfSubjectRulesReviewSpec{Namespace: namespace},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return NamespaceAccess{}, fmt.Errorf("failed to review rules in namespace %s: %w", namespace, err)
+	}
+
+	access := NamespaceAccess{
+		Namespace:       namespace,
+		Incomplete:      review.Status.Incomplete,
+		EvaluationError: review.Status.EvaluationError,
+	}
+	for _, r := range review.Status.ResourceRules {
+		access.Rules = append(access.Rules, v1.PolicyRule{
+			Verbs:         r.Verbs,
+			APIGroups:     r.APIGroups,
+			Resources:     r.Resources,
+			ResourceNames: r.ResourceNames,
+		})
+	}
+	for _, r := range review.Status.NonResourceRules {
+		access.Rules = append(access.Rules, v1.PolicyRule{Verbs: r.Verbs, NonResourceURLs: r.NonResourceURLs})
+	}
+	return access, nil
+}
+
+// CheckAccess asks the API server, through a SelfSubjectAccessReview, whether
+// the calling identity may perform the request. An empty namespace checks
+// cluster-wide access.
+func CheckAccess(ctx context.Context, clientset kubernetes.Interface, req ResourceRequest, namespace string) (bool, error) {
+	spec := authorizationv1.SelfSubjectAccessReviewSpec{}
+	if req.NonResourceURL != "" {
+		spec.NonResourceAttributes = &authorizationv1.NonResourceAttributes{Path: req.NonResourceURL, Verb: req.Verb}
+	} else {
+		group := req.APIGroup
+		if req.AnyGroup {
+			group = "*"
+		}
+		spec.ResourceAttributes = &authorizationv1.ResourceAttributes{
+			Namespace:   namespace,
+			Verb:        req.Verb,
+			Group:       group,
+			Resource:    req.Resource,
+			Subresource: req.Subresource,
+			Name:        req.Name,
+		}
+	}
+
+	review, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{Spec: spec}, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to review access to %s: %w", req, err)
+	}
+	return review.Status.Allowed, nil
+}
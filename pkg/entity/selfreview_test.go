@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// selfReviewClient answers SelfSubjectRulesReviews with the rules of each
+// namespace and SelfSubjectAccessReviews with the allowed requests
+func selfReviewClient(rules map[string][]authorizationv1.ResourceRule, allowed func(*authorizationv1.ResourceAttributes) bool) *fake.Clientset {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("create", "selfsubjectrulesreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectRulesReview)
+		review.Status.ResourceRules = rules[review.Spec.Namespace]
+		return true, review, nil
+	})
+	clientset.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = review.Spec.ResourceAttributes != nil && allowed(review.Spec.ResourceAttributes)
+		return true, review, nil
+	})
+	return clientset
+}
+
+func TestReviewSelfAccess(t *testing.T) {
+	clientset := selfReviewClient(map[string][]authorizationv1.ResourceRule{
+		"dev": {
+			{Verbs: []string{"*"}, APIGroups: []string{""}, Resources: []string{"pods"}},
+			{Verbs: []string{"get"}, APIGroups: []string{""}, Resources: []string{"secrets"}},
+			{Verbs: []string{"patch"}, APIGroups: []string{"rbac.authorization.k8s.io"}, Resources: []string{"rolebindings"}},
+		},
+		"prod": {{Verbs: []string{"get", "list"}, APIGroups: []string{""}, Resources: []string{"pods"}}},
+	}, func(attrs *authorizationv1.ResourceAttributes) bool {
+		return attrs.Verb == "impersonate" && attrs.Resource == "users"
+	})
+
+	access, err := ReviewSelfAccess(context.Background(), clientset, []string{"dev", "prod"})
+	assert.NoError(t, err)
+	assert.False(t, access.ClusterAdmin)
+	assert.Equal(t, []string{"Impersonation"}, access.ClusterEscalations)
+
+	if assert.Len(t, access.Namespaces, 2) {
+		dev, prod := access.Namespaces[0], access.Namespaces[1]
+		assert.Equal(t, []string{"wildcard", "dangerous-verbs", "secrets"}, dev.Risks())
+		assert.Equal(t, []string{"RoleBinding modification"}, dev.Escalations())
+		assert.Empty(t, prod.Risks())
+		assert.Empty(t, prod.Escalations())
+	}
+}
+
+func TestCheckAccess(t *testing.T) {
+	var seen *authorizationv1.ResourceAttributes
+	clientset := selfReviewClient(nil, func(attrs *authorizationv1.ResourceAttributes) bool {
+		seen = attrs
+		return true
+	})
+
+	allowed, err := CheckAccess(context.Background(), clientset, ResourceRequest{Verb: "get", AnyGroup: true, Resource: "secrets"}, "prod")
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, &authorizationv1.ResourceAttributes{Namespace: "prod", Verb: "get", Group: "*", Resource: "secrets"}, seen)
+}
@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"fmt"
+	"kspm/pkg/findings"
+
+	v1 "k8s.io/api/rbac/v1"
+)
+
+// AccessPath is one binding through which a subject is granted a request
+type AccessPath struct {
+	Subject v1.Subject        `json:"subject"`
+	Binding findings.Resource `json:"binding"`
+	RoleRef v1.RoleRef        `json:"roleRef"`
+	// Namespace the grant applies to, empty for cluster-wide grants
+	Namespace string        `json:"namespace,omitempty"`
+	Rule      v1.PolicyRule `json:"rule"`
+	// ResourceNames limits the grant to the named objects, empty for all
+	ResourceNames []string `json:"resourceNames,omitempty"`
+	// Source is the ClusterRole the rule was aggregated from, empty when the
+	// bound role holds it itself
+	Source string `json:"source,omitempty"`
+}
+
+// String renders the path as subject <- binding -> role
+func (p AccessPath) String() string {
+	return fmt.Sprintf("%s <- %s/%s -> %s/%s", SubjectString(p.Subject), p.Binding.Kind, p.Binding.Name, p.RoleRef.Kind, p.RoleRef.Name)
+}
+
+// Scope renders the namespace the path applies to
+func (p AccessPath) Scope() string {
+	if p.Namespace == "" {
+		return "cluster-wide"
+	}
+	return p.Namespace
+}
+
+// WhoCan lists every subject granted the request and the binding that grants
+// it. With a namespace, RoleBindings in that namespace and ClusterRoleBindings
+// are considered; without one only cluster-wide grants count. Subjects are
+// listed as bound, members of a bound Group are not expanded.
+func (s *RBACSnapshot) WhoCan(req ResourceRequest, namespace string) []AccessPath {
+	var out []AccessPath
+	for _, p := range s.Inventory() {
+		for _, g := range p.Grants {
+			if g.Via != "" || (g.Namespace != "" && g.Namespace != namespace) {
+				continue
+			}
+			// Non-resource URLs are only granted cluster-wide
+			if req.NonResourceURL != "" && g.Namespace != "" {
+				continue
+			}
+			// Prefer a rule that is not limited by resourceNames
+			var match *AccessPath
+			for _, rule := range g.Rules {
+				ok, names := RuleAllows(rule, req)
+				if !ok || (match != nil && len(names) > 0) {
+					continue
+				}
+				match = &AccessPath{
+					Subject:       p.Subject,
+					Binding:       g.Binding,
+					RoleRef:       g.RoleRef,
+					Namespace:     g.Namespace,
+					Rule:          rule,
+					ResourceNames: names,
+				}
+				if len(names) == 0 {
+					break
+				}
+			}
+			if match != nil {
+				match.Source = s.RuleSource(g.RoleRef, g.Namespace, match.Rule)
+				out = append(out, *match)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWhoCan(t *testing.T) {
+	snapshot := testSnapshot()
+	secrets := ResourceRequest{Verb: "get", Resource: "secrets", AnyGroup: true}
+
+	var subjects []string
+	for _, p := range snapshot.WhoCan(secrets, "prod") {
+		subjects = append(subjects, p.String())
+	}
+	assert.Equal(t, []string{
+		"Group/system:masters <- ClusterRoleBinding/cluster-admin -> ClusterRole/cluster-admin",
+		"ServiceAccount/ci/deployer <- ClusterRoleBinding/ci-admin -> ClusterRole/cluster-admin",
+		"ServiceAccount/prod/default <- RoleBinding/read-secrets -> Role/secret-reader",
+	}, subjects)
+
+	// Without a namespace only cluster-wide grants count
+	assert.Len(t, snapshot.WhoCan(secrets, ""), 2)
+	assert.Len(t, snapshot.WhoCan(secrets, "dev"), 2)
+}
+
+func TestWhoCanResolvesAggregation(t *testing.T) {
+	snapshot := &RBACSnapshot{
+		ClusterRoles: []v1.ClusterRole{
+			{
+				ObjectMeta:      metav1.ObjectMeta{Name: "admin"},
+				AggregationRule: &v1.AggregationRule{ClusterRoleSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"rbac.authorization.k8s.io/aggregate-to-admin": "true"}}}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "exec-extension", Labels: map[string]string{"rbac.authorization.k8s.io/aggregate-to-admin": "true"}},
+				Rules:      []v1.PolicyRule{{Verbs: []string{"create"}, Resources: []string{"pods/exec"}}},
+			},
+		},
+		RoleBindings: []v1.RoleBinding{{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-admin", Namespace: "dev"},
+			RoleRef:    v1.RoleRef{Kind: "ClusterRole", Name: "admin"},
+			Subjects:   []v1.Subject{{Kind: v1.UserKind, Name: "alice"}},
+		}},
+	}
+
+	paths := snapshot.WhoCan(ResourceRequest{Verb: "create", Resource: "pods", Subresource: "exec", AnyGroup: true}, "dev")
+	if assert.Len(t, paths, 1) {
+		assert.Equal(t, "User/alice <- RoleBinding/team-admin -> ClusterRole/admin", paths[0].String())
+		assert.Equal(t, "dev", paths[0].Scope())
+		assert.Equal(t, "exec-extension", paths[0].Source)
+	}
+}
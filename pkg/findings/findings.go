@@ -0,0 +1,143 @@
+// Package findings defines the typed finding model shared by every check.
+package findings
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity of a finding (mirrors the trivytypes severity constants)
+type Severity string
+
+// Severity Constants
+const (
+	SeverityCritical Severity = "CRITICAL"
+	SeverityHigh     Severity = "HIGH"
+	SeverityMedium   Severity = "MEDIUM"
+	SeverityLow      Severity = "LOW"
+	SeverityInfo     Severity = "INFO"
+)
+
+// Severities lists every severity from most to least severe
+var Severities = []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo}
+
+// ParseSeverity normalizes a severity string, folding aliases such as
+// "WARNING" and "MED" into MEDIUM. Unknown values fall back to INFO.
+func ParseSeverity(s string) Severity {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "CRITICAL":
+		return SeverityCritical
+	case "HIGH":
+		return SeverityHigh
+	case "MEDIUM", "MED", "WARNING", "WARN":
+		return SeverityMedium
+	case "LOW":
+		return SeverityLow
+	default:
+		return SeverityInfo
+	}
+}
+
+// Rank orders severities, higher is more severe
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityCritical:
+		return 4
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// AtLeast reports whether s is as severe as min or more
+func (s Severity) AtLeast(min Severity) bool {
+	return s.Rank() >= min.Rank()
+}
+
+// Finding categories
+const (
+	CategoryPodSecurity        = "PodSecurity"
+	CategoryDeploymentSecurity = "DeploymentSecurity"
+	CategoryRBAC               = "RBAC"
+	CategorySecrets            = "Secrets"
+	CategoryControlPlane       = "ControlPlane"
+	CategoryVulnerability      = "Vulnerability"
+	CategoryNetworkPolicy      = "NetworkPolicy"
+)
+
+// Resource identifies the Kubernetes object a finding is about
+type Resource struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	// Labels of the object, used to match suppressions by label selector
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// String renders the resource as Kind/name (namespace)
+func (r Resource) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s/%s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s/%s (%s)", r.Kind, r.Name, r.Namespace)
+}
+
+// Location points at the manifest a finding was read from (offline scans only)
+type Location struct {
+	File string `json:"file"`
+	Line int    `json:"line,omitempty"`
+}
+
+// Finding is a single result produced by a check
+type Finding struct {
+	RuleID      string    `json:"ruleId"`
+	Severity    Severity  `json:"severity"`
+	Category    string    `json:"category"`
+	Resource    Resource  `json:"resource"`
+	Message     string    `json:"message"`
+	Evidence    []string  `json:"evidence,omitempty"`
+	Remediation string    `json:"remediation,omitempty"`
+	Location    *Location `json:"location,omitempty"`
+}
+
+// String renders the finding as a single human readable line
+func (f Finding) String() string {
+	return fmt.Sprintf("[%s] %s: %s", f.Severity, f.Resource, f.Message)
+}
+
+// CountBySeverity tallies findings for every known severity
+func CountBySeverity(list []Finding) map[Severity]int {
+	counts := make(map[Severity]int, len(Severities))
+	for _, s := range Severities {
+		counts[s] = 0
+	}
+	for _, f := range list {
+		counts[ParseSeverity(string(f.Severity))]++
+	}
+	return counts
+}
+
+// Key identifies a finding by rule, resource and message
+func Key(f Finding) string {
+	return strings.Join([]string{f.RuleID, f.Resource.Kind, f.Resource.Namespace, f.Resource.Name, f.Message}, "\x00")
+}
+
+// Dedupe drops findings repeating an earlier finding's rule, resource and
+// message, keeping the first occurrence
+func Dedupe(list []Finding) []Finding {
+	seen := make(map[string]bool, len(list))
+	out := make([]Finding, 0, len(list))
+	for _, f := range list {
+		if key := Key(f); !seen[key] {
+			seen[key] = true
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package findings
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSeverity(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected Severity
+	}{
+		{"CRITICAL", SeverityCritical},
+		{"high", SeverityHigh},
+		{"MEDIUM", SeverityMedium},
+		{"MED", SeverityMedium},
+		{"WARNING", SeverityMedium},
+		{" low ", SeverityLow},
+		{"INFO", SeverityInfo},
+		{"UNKNOWN", SeverityInfo},
+		{"", SeverityInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseSeverity(tt.in))
+		})
+	}
+}
+
+func TestSeverityAtLeast(t *testing.T) {
+	assert.True(t, SeverityCritical.AtLeast(SeverityHigh))
+	assert.True(t, SeverityHigh.AtLeast(SeverityHigh))
+	assert.False(t, SeverityMedium.AtLeast(SeverityHigh))
+	assert.False(t, SeverityInfo.AtLeast(SeverityLow))
+}
+
+func TestCountBySeverity(t *testing.T) {
+	counts := CountBySeverity([]Finding{
+		{Severity: SeverityHigh},
+		{Severity: "WARNING"},
+		{Severity: "MED"},
+		{Severity: SeverityMedium},
+	})
+
+	assert.Equal(t, 1, counts[SeverityHigh])
+	assert.Equal(t, 3, counts[SeverityMedium])
+	assert.Equal(t, 0, counts[SeverityCritical])
+}
+
+func TestFindingString(t *testing.T) {
+	f := Finding{
+		RuleID:   "POD-PRIVILEGED",
+		Severity: SeverityCritical,
+		Resource: Resource{Kind: "Pod", Namespace: "default", Name: "web"},
+		Message:  "container app is privileged",
+	}
+	assert.Equal(t, "[CRITICAL] Pod/web (default): container app is privileged", f.String())
+}
+
+func TestDedupe(t *testing.T) {
+	ds := Resource{Kind: "DaemonSet", Namespace: "kube-system", Name: "agent"}
+	list := []Finding{
+		{RuleID: "POD-PRIVILEGED", Resource: ds, Message: "Container agent is privileged", Evidence: []string{"first"}},
+		{RuleID: "POD-PRIVILEGED", Resource: ds, Message: "Container agent is privileged", Evidence: []string{"second"}},
+		{RuleID: "POD-HOST-PID", Resource: ds, Message: "Pod uses host PID namespace"},
+	}
+
+	out := Dedupe(list)
+	assert.Len(t, out, 2)
+	assert.Equal(t, []string{"first"}, out[0].Evidence)
+	assert.Equal(t, "POD-HOST-PID", out[1].RuleID)
+}
@@ -32,6 +32,12 @@ func NewTestClientset(objs ...runtime.Object) (*fake.Clientset, *Watchers) {
 		Deployments: watch.NewFake(),
 	}
 
+	// Secrets, ClusterRoles, etc. as needed (prepended first so the
+	// resource specific reactors below take precedence)
+	client.Fake.PrependWatchReactor("*", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		return true, watch.NewFake(), nil
+	})
+
 	// Pods
 	client.Fake.PrependWatchReactor("pods",
 		func(action k8stesting.Action) (bool, watch.Interface, error) {
@@ -44,10 +50,6 @@ func NewTestClientset(objs ...runtime.Object) (*fake.Clientset, *Watchers) {
 			return true, w.Deployments, nil
 		},
 	)
-	// Secrets, ClusterRoles, etc. as needed
-	client.Fake.PrependWatchReactor("*", func(action k8stesting.Action) (bool, watch.Interface, error) {
-		return true, watch.NewFake(), nil
-	})
 
 	return client, w
 }
@@ -0,0 +1,89 @@
+package k8s
+
+import (
+	"kspm/pkg/entity"
+	"kspm/pkg/findings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// CheckObject runs the checks that apply to a decoded object, returning its
+// findings and the resources that were evaluated. ok is false when the kind
+// is not supported.
+func CheckObject(obj runtime.Object) (out []findings.Finding, scanned []findings.Resource, ok bool) {
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		return CheckPodSecurity(o), []findings.Resource{PodResource(o)}, true
+	case *rbacv1.ClusterRole:
+		return CheckClusterRoleSecurity(o), []findings.Resource{entity.ClusterRoleResource(o)}, true
+	case *rbacv1.Role:
+		return evaluateRules(entity.RoleResource(o), o), []findings.Resource{entity.RoleResource(o)}, true
+	case *corev1.Secret:
+		return CheckSecretSecurity(o), []findings.Resource{SecretResource(o)}, true
+	}
+	if resource, ok := WorkloadResource(obj); ok {
+		return CheckWorkloadSecurity(obj), []findings.Resource{resource}, true
+	}
+	return nil, nil, false
+}
+
+// podTemplate returns the metadata and pod template of a workload controller
+func podTemplate(obj runtime.Object) (apiVersion, kind string, meta metav1.ObjectMeta, template corev1.PodTemplateSpec, ok bool) {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		return "apps/v1", "Deployment", o.ObjectMeta, o.Spec.Template, true
+	case *appsv1.StatefulSet:
+		return "apps/v1", "StatefulSet", o.ObjectMeta, o.Spec.Template, true
+	case *appsv1.DaemonSet:
+		return "apps/v1", "DaemonSet", o.ObjectMeta, o.Spec.Template, true
+	case *appsv1.ReplicaSet:
+		return "apps/v1", "ReplicaSet", o.ObjectMeta, o.Spec.Template, true
+	case *batchv1.Job:
+		return "batch/v1", "Job", o.ObjectMeta, o.Spec.Template, true
+	case *batchv1.CronJob:
+		return "batch/v1", "CronJob", o.ObjectMeta, o.Spec.JobTemplate.Spec.Template, true
+	}
+	return "", "", metav1.ObjectMeta{}, corev1.PodTemplateSpec{}, false
+}
+
+// WorkloadResource identifies a Deployment, StatefulSet, DaemonSet,
+// ReplicaSet, Job or CronJob in findings. ok is false for other kinds.
+func WorkloadResource(obj runtime.Object) (findings.Resource, bool) {
+	apiVersion, kind, meta, _, ok := podTemplate(obj)
+	if !ok {
+		return findings.Resource{}, false
+	}
+	return findings.Resource{APIVersion: apiVersion, Kind: kind, Namespace: meta.Namespace, Name: meta.Name, Labels: meta.Labels}, true
+}
+
+// CheckWorkloadSecurity runs the workload rules against a controller and the
+// pod rules against its pod template. Every finding is attributed to the
+// controller, so a pod template issue is reported once however many replicas
+// it runs.
+func CheckWorkloadSecurity(obj runtime.Object) []findings.Finding {
+	resource, ok := WorkloadResource(obj)
+	if !ok {
+		return nil
+	}
+	_, _, meta, template, _ := podTemplate(obj)
+
+	out := evaluateRules(resource, obj)
+	return append(out, evaluatePodRulesAs(resource, podFromTemplate(meta, template))...)
+}
+
+// podFromTemplate creates a Pod named after its workload from a PodTemplateSpec
+func podFromTemplate(meta metav1.ObjectMeta, template corev1.PodTemplateSpec) *corev1.Pod {
+	return &corev1.Pod{
+		Spec: template.Spec,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      meta.Name,
+			Namespace: meta.Namespace,
+			Labels:    template.Labels,
+		},
+	}
+}
@@ -0,0 +1,238 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"kspm/pkg/findings"
+	"kspm/pkg/rules"
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxOwnerDepth bounds ownerReference chains so a cycle cannot loop forever
+const maxOwnerDepth = 8
+
+// OwnerIndex resolves pods to the top-level controller that owns them by
+// following controller ownerReferences, e.g. Pod -> ReplicaSet -> Deployment
+type OwnerIndex struct {
+	mu     sync.Mutex
+	owners map[string]indexedOwner
+	// lookup fetches an intermediate controller missing from the index, may be nil
+	lookup func(kind, namespace, name string) (runtime.Object, error)
+}
+
+type indexedOwner struct {
+	resource   findings.Resource
+	controller *metav1.OwnerReference
+}
+
+// NewOwnerIndex returns an empty index, populated with Add
+func NewOwnerIndex() *OwnerIndex {
+	return &OwnerIndex{owners: map[string]indexedOwner{}}
+}
+
+// NewLiveOwnerIndex returns an index that fetches ReplicaSets and Jobs from
+// the cluster the first time a pod references them (watch mode)
+func NewLiveOwnerIndex(clientset kubernetes.Interface) *OwnerIndex {
+	x := NewOwnerIndex()
+	x.lookup = func(kind, namespace, name string) (runtime.Object, error) {
+		switch kind {
+		case "ReplicaSet":
+			return clientset.AppsV1().ReplicaSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		case "Job":
+			return clientset.BatchV1().Jobs(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		}
+		return nil, fmt.Errorf("no lookup for %s", kind)
+	}
+	return x
+}
+
+func ownerKey(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+// Add records a workload controller so the pods it owns are attributed to
+// it, or to its own controller when it has one
+func (x *OwnerIndex) Add(obj runtime.Object) {
+	resource, ok := WorkloadResource(obj)
+	if !ok {
+		return
+	}
+	_, _, meta, _, _ := podTemplate(obj)
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.owners[ownerKey(resource.Kind, resource.Namespace, resource.Name)] = indexedOwner{
+		resource:   resource,
+		controller: metav1.GetControllerOf(&meta),
+	}
+}
+
+func (x *OwnerIndex) get(kind, namespace, name string) (indexedOwner, bool) {
+	key := ownerKey(kind, namespace, name)
+	x.mu.Lock()
+	o, ok := x.owners[key]
+	x.mu.Unlock()
+	if ok || x.lookup == nil {
+		return o, ok
+	}
+
+	obj, err := x.lookup(kind, namespace, name)
+	if err != nil {
+		return indexedOwner{}, false
+	}
+	x.Add(obj)
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	o, ok = x.owners[key]
+	return o, ok
+}
+
+// Owner returns the top-level controller of an object. ok is false when the
+// object has no controller, or when it is a static pod mirrored by a Node.
+func (x *OwnerIndex) Owner(meta metav1.ObjectMeta) (findings.Resource, bool) {
+	ref := metav1.GetControllerOf(&meta)
+	if ref == nil || ref.Kind == "Node" {
+		return findings.Resource{}, false
+	}
+
+	resource := findings.Resource{APIVersion: ref.APIVersion, Kind: ref.Kind, Namespace: meta.Namespace, Name: ref.Name}
+	for depth := 0; depth < maxOwnerDepth; depth++ {
+		o, ok := x.get(ref.Kind, meta.Namespace, ref.Name)
+		if !ok {
+			break
+		}
+		resource = o.resource
+		if o.controller == nil {
+			break
+		}
+		ref = o.controller
+		resource = findings.Resource{APIVersion: ref.APIVersion, Kind: ref.Kind, Namespace: meta.Namespace, Name: ref.Name}
+	}
+	return resource, true
+}
+
+// podScopedRules are the pod rules about one running pod rather than the pod
+// spec its controller creates, e.g. a kubectl debug session. Their findings
+// stay on the pod.
+var podScopedRules = map[string]bool{
+	"POD-EPHEMERAL-CONTAINER": true,
+}
+
+// CheckOwnedPodSecurity runs the pod rules against a pod, attributing the
+// findings to its top-level controller when it has one
+func CheckOwnedPodSecurity(pod *corev1.Pod, owners *OwnerIndex) []findings.Finding {
+	if owner, ok := owners.Owner(pod.ObjectMeta); ok {
+		return evaluatePodRulesAs(owner, pod)
+	}
+	return CheckPodSecurity(pod)
+}
+
+// reportedFindings remembers the findings already reported in watch mode so
+// the replicas of a controller do not repeat them. Findings are grouped by
+// the pod template revision they were raised for, and a group is forgotten
+// once its last pod is deleted, so a finding fixed by a rollout and
+// reintroduced later is reported again.
+type reportedFindings struct {
+	mu sync.Mutex
+	// seen holds the keys of the findings reported per group
+	seen map[string]map[string]bool
+	// pods holds the pods of each group, groups the groups of each pod
+	pods   map[string]map[string]bool
+	groups map[string][]string
+}
+
+// track records that pod belongs to group
+func (r *reportedFindings) track(pod, group string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pods == nil {
+		r.seen = map[string]map[string]bool{}
+		r.pods = map[string]map[string]bool{}
+		r.groups = map[string][]string{}
+	}
+	if r.pods[group] == nil {
+		r.pods[group] = map[string]bool{}
+	}
+	if !r.pods[group][pod] {
+		r.pods[group][pod] = true
+		r.groups[pod] = append(r.groups[pod], group)
+	}
+}
+
+// first reports whether f has not been reported for group before, and records it
+func (r *reportedFindings) first(group string, f findings.Finding) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seen[group] == nil {
+		r.seen[group] = map[string]bool{}
+	}
+	key := findings.Key(f)
+	if r.seen[group][key] {
+		return false
+	}
+	r.seen[group][key] = true
+	return true
+}
+
+// forget drops a deleted pod, and the findings of the groups it was the last pod of
+func (r *reportedFindings) forget(pod string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, group := range r.groups[pod] {
+		delete(r.pods[group], pod)
+		if len(r.pods[group]) == 0 {
+			delete(r.pods, group)
+			delete(r.seen, group)
+		}
+	}
+	delete(r.groups, pod)
+}
+
+// templateRevision returns the label controllers set on the pods of one
+// revision of their pod template, "" for controllers without one
+func templateRevision(pod *corev1.Pod) string {
+	if hash, ok := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; ok {
+		return hash
+	}
+	return pod.Labels[appsv1.ControllerRevisionHashLabelKey]
+}
+
+// checkWatchedPod checks a pod seen by the pod watcher. Findings on pods
+// with a controller are attributed to it and reported once per controller
+// revision, except those of pod scoped rules, reported once per pod.
+func checkWatchedPod(pod *corev1.Pod, owners *OwnerIndex, reported *reportedFindings) []findings.Finding {
+	owner, ok := owners.Owner(pod.ObjectMeta)
+	if !ok {
+		return CheckPodSecurity(pod)
+	}
+
+	podKey := ownerKey("Pod", pod.Namespace, pod.Name)
+	ownerGroup := ownerKey(owner.Kind, owner.Namespace, owner.Name) + "@" + templateRevision(pod)
+	reported.track(podKey, podKey)
+	reported.track(podKey, ownerGroup)
+
+	out := rules.Evaluate(PodResource(pod), pod)
+	for i := range out {
+		group := podKey
+		if !podScopedRules[out[i].RuleID] {
+			out[i].Resource = owner
+			group = ownerGroup
+		}
+		if reported.first(group, out[i]) {
+			reportFinding(out[i])
+		}
+	}
+	return out
+}
+
+// forgetWatchedPod drops the findings reported for a deleted pod, and for
+// its controller revision when it was the last pod of it
+func forgetWatchedPod(pod *corev1.Pod, reported *reportedFindings) {
+	reported.forget(ownerKey("Pod", pod.Namespace, pod.Name))
+}
@@ -0,0 +1,211 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"kspm/pkg/findings"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func controllerRef(apiVersion, kind, name string) []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{APIVersion: apiVersion, Kind: kind, Name: name, Controller: &controller}}
+}
+
+func privilegedDaemonSet() *appsv1.DaemonSet {
+	privileged := true
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "kube-system"},
+		Spec: appsv1.DaemonSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:            "agent",
+						Image:           "agent:1.0",
+						SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func TestCheckObjectAttributesTemplateToWorkload(t *testing.T) {
+	SetSecurityEventHandler(&RecordingSecurityEventHandler{})
+
+	out, scanned, ok := CheckObject(privilegedDaemonSet())
+	assert.True(t, ok)
+	daemonSet := findings.Resource{APIVersion: "apps/v1", Kind: "DaemonSet", Namespace: "kube-system", Name: "agent"}
+	assert.Equal(t, []findings.Resource{daemonSet}, scanned)
+
+	byRule := map[string]findings.Finding{}
+	for _, f := range out {
+		assert.Equal(t, daemonSet, f.Resource)
+		byRule[f.RuleID] = f
+	}
+	assert.Equal(t, "container agent is privileged", byRule["POD-PRIVILEGED"].Message)
+	assert.Equal(t, "Container agent has no resource limits defined", byRule["DEPLOY-NO-LIMITS"].Message)
+	assert.Equal(t, "DaemonSet has no pod security context defined", byRule["DEPLOY-NO-SECURITY-CONTEXT"].Message)
+	assert.NotContains(t, byRule, "DEPLOY-MISSING-LABELS")
+}
+
+func TestOwnerIndex(t *testing.T) {
+	owners := NewOwnerIndex()
+	owners.Add(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: map[string]string{"app": "web"}}})
+	owners.Add(&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name: "web-5d4f", Namespace: "default", OwnerReferences: controllerRef("apps/v1", "Deployment", "web"),
+	}})
+
+	owner, ok := owners.Owner(metav1.ObjectMeta{Namespace: "default", OwnerReferences: controllerRef("apps/v1", "ReplicaSet", "web-5d4f")})
+	assert.True(t, ok)
+	assert.Equal(t, findings.Resource{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web", Labels: map[string]string{"app": "web"}}, owner)
+
+	// Controllers the index does not know are used as-is
+	owner, ok = owners.Owner(metav1.ObjectMeta{Namespace: "default", OwnerReferences: controllerRef("argoproj.io/v1alpha1", "Rollout", "canary")})
+	assert.True(t, ok)
+	assert.Equal(t, findings.Resource{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Namespace: "default", Name: "canary"}, owner)
+
+	_, ok = owners.Owner(metav1.ObjectMeta{Namespace: "kube-system", OwnerReferences: controllerRef("v1", "Node", "node-1")})
+	assert.False(t, ok, "static pods stay attributed to the pod")
+	_, ok = owners.Owner(metav1.ObjectMeta{Namespace: "default"})
+	assert.False(t, ok)
+}
+
+func TestScanWorkloadsReportsOncePerController(t *testing.T) {
+	SetSecurityEventHandler(&RecordingSecurityEventHandler{})
+
+	daemonSet := privilegedDaemonSet()
+	objects := []runtime.Object{daemonSet}
+	for _, name := range []string{"agent-a", "agent-b", "agent-c"} {
+		objects = append(objects, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kube-system", OwnerReferences: controllerRef("apps/v1", "DaemonSet", "agent")},
+			Spec:       daemonSet.Spec.Template.Spec,
+		})
+	}
+	objects = append(objects, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "standalone", Namespace: "default"},
+		Spec:       daemonSet.Spec.Template.Spec,
+	})
+
+	out, scanned, errs := ScanWorkloads(context.Background(), fake.NewSimpleClientset(objects...), "")
+	assert.Empty(t, errs)
+
+	var privileged []findings.Resource
+	for _, f := range out {
+		if f.RuleID == "POD-PRIVILEGED" {
+			privileged = append(privileged, f.Resource)
+		}
+	}
+	assert.Equal(t, []findings.Resource{
+		{APIVersion: "apps/v1", Kind: "DaemonSet", Namespace: "kube-system", Name: "agent"},
+		{APIVersion: "v1", Kind: "Pod", Namespace: "default", Name: "standalone"},
+	}, privileged)
+	assert.Len(t, scanned, 2)
+}
+
+func TestCheckWatchedPodReportsOncePerController(t *testing.T) {
+	recorder := &RecordingSecurityEventHandler{}
+	SetSecurityEventHandler(recorder)
+
+	owners := NewOwnerIndex()
+	reported := &reportedFindings{}
+	for _, name := range []string{"agent-a", "agent-b"} {
+		checkWatchedPod(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kube-system", OwnerReferences: controllerRef("apps/v1", "DaemonSet", "agent")},
+			Spec:       privilegedDaemonSet().Spec.Template.Spec,
+		}, owners, reported)
+	}
+
+	var privileged []SecurityEvent
+	for _, event := range recorder.SnapShot() {
+		if event.RuleID == "POD-PRIVILEGED" {
+			privileged = append(privileged, event)
+		}
+	}
+	if assert.Len(t, privileged, 1) {
+		assert.Equal(t, "DaemonSet", privileged[0].ResourceType)
+		assert.Equal(t, "agent", privileged[0].ResourceName)
+	}
+}
+
+func TestCheckWatchedPodKeepsPodScopedFindings(t *testing.T) {
+	recorder := &RecordingSecurityEventHandler{}
+	SetSecurityEventHandler(recorder)
+
+	debugged := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-5d9c-abcde", Namespace: "prod", OwnerReferences: controllerRef("apps/v1", "ReplicaSet", "web-5d9c")},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "web", Image: "web:1.0"}},
+			EphemeralContainers: []corev1.EphemeralContainer{{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger", Image: "busybox:1.36"},
+			}},
+		},
+	}
+	out := checkWatchedPod(debugged, NewOwnerIndex(), &reportedFindings{})
+
+	var resources []string
+	for _, f := range out {
+		if f.RuleID == "POD-EPHEMERAL-CONTAINER" {
+			resources = append(resources, f.Resource.Kind+"/"+f.Resource.Name)
+		}
+	}
+	assert.Equal(t, []string{"Pod/web-5d9c-abcde"}, resources, "the debugged pod is named")
+
+	var events []string
+	for _, event := range recorder.SnapShot() {
+		if event.RuleID == "POD-EPHEMERAL-CONTAINER" {
+			events = append(events, event.ResourceType+"/"+event.ResourceName)
+		}
+	}
+	assert.Equal(t, []string{"Pod/web-5d9c-abcde"}, events)
+}
+
+func TestCheckWatchedPodForgetsDeletedRevisions(t *testing.T) {
+	recorder := &RecordingSecurityEventHandler{}
+	SetSecurityEventHandler(recorder)
+
+	owners := NewOwnerIndex()
+	reported := &reportedFindings{}
+	replica := func(name, revision string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name, Namespace: "kube-system",
+				Labels:          map[string]string{appsv1.ControllerRevisionHashLabelKey: revision},
+				OwnerReferences: controllerRef("apps/v1", "DaemonSet", "agent"),
+			},
+			Spec: privilegedDaemonSet().Spec.Template.Spec,
+		}
+	}
+	privilegedEvents := func() int {
+		n := 0
+		for _, event := range recorder.SnapShot() {
+			if event.RuleID == "POD-PRIVILEGED" {
+				n++
+			}
+		}
+		return n
+	}
+
+	a, b := replica("agent-a", "1"), replica("agent-b", "1")
+	checkWatchedPod(a, owners, reported)
+	checkWatchedPod(b, owners, reported)
+	forgetWatchedPod(a, reported)
+	checkWatchedPod(b, owners, reported)
+	assert.Equal(t, 1, privilegedEvents(), "the revision still has a pod")
+
+	forgetWatchedPod(b, reported)
+	assert.Empty(t, reported.seen, "deleted revisions are forgotten")
+	assert.Empty(t, reported.groups)
+
+	checkWatchedPod(replica("agent-c", "1"), owners, reported)
+	assert.Equal(t, 2, privilegedEvents(), "a reintroduced finding is reported again")
+	checkWatchedPod(replica("agent-d", "2"), owners, reported)
+	assert.Equal(t, 3, privilegedEvents(), "each revision is reported")
+}
@@ -1,46 +1,41 @@
 package k8s
 
 import (
-	"fmt"
+	"kspm/pkg/findings"
 
 	"github.com/fatih/color"
 )
 
 // Declare type
 type Reporter struct {
-	Findings []string
+	Findings []findings.Finding
 	Quiet    bool
 }
 
 func NewReporter(quiet bool) *Reporter {
 	return &Reporter{
-		Findings: []string{},
+		Findings: []findings.Finding{},
 		Quiet:    quiet,
 	}
 }
 
-func (r *Reporter) Emit(sev, cat, ns, kind, name, msg string) {
+func (r *Reporter) Emit(f findings.Finding) {
 	// 1) Append structured finding
-	r.Findings = append(r.Findings,
-		fmt.Sprintf(
-			"[SEV=%s] [CAT=%s] ns=%s kind=%s name=%s msg=%q",
-			sev, cat, ns, kind, name, msg,
-		),
-	)
+	r.Findings = append(r.Findings, f)
 
 	// 2) Optional console output
 	if r.Quiet {
 		return
 	}
 
-	switch sev {
-	case "CRITICAL":
-		color.New(color.FgHiRed).Printf("[CRITICAL] %s/%s (%s): %s\n", kind, name, ns, msg)
-	case "HIGH":
-		color.New(color.FgRed).Printf("[HIGH] %s/%s (%s): %s\n", kind, name, ns, msg)
-	case "MED":
-		color.New(color.FgYellow).Printf("[MED] %s/%s (%s): %s\n", kind, name, ns, msg)
+	switch f.Severity {
+	case findings.SeverityCritical:
+		color.New(color.FgHiRed).Printf("[CRITICAL] %s: %s\n", f.Resource, f.Message)
+	case findings.SeverityHigh:
+		color.New(color.FgRed).Printf("[HIGH] %s: %s\n", f.Resource, f.Message)
+	case findings.SeverityMedium:
+		color.New(color.FgYellow).Printf("[MEDIUM] %s: %s\n", f.Resource, f.Message)
 	default:
-		color.New(color.FgCyan).Printf("[INFO] %s/%s (%s): %s\n", kind, name, ns, msg)
+		color.New(color.FgCyan).Printf("[%s] %s: %s\n", f.Severity, f.Resource, f.Message)
 	}
 }
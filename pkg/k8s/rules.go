@@ -0,0 +1,534 @@
+package k8s
+
+import (
+	"fmt"
+	"kspm/pkg/findings"
+	"kspm/pkg/rules"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Built-in pod, workload and secret rules
+func init() {
+	for _, rule := range podRules {
+		rules.MustRegister(rule)
+	}
+	for _, rule := range deploymentRules {
+		rules.MustRegister(rule)
+	}
+	for _, rule := range secretRules {
+		rules.MustRegister(rule)
+	}
+}
+
+// DefaultSensitiveHostPaths are the host path prefixes flagged by POD-SENSITIVE-HOSTPATH
+var DefaultSensitiveHostPaths = []string{"/etc", "/var/run/docker.sock", "/proc", "/var/log"}
+
+// DefaultSensitiveSecretKeys are the key substrings flagged by SECRET-SENSITIVE-KEY
+var DefaultSensitiveSecretKeys = []string{"password", "token", "key", "secret", "credential", "cert"}
+
+var (
+	sensitiveHostPaths  = DefaultSensitiveHostPaths
+	sensitiveSecretKeys = DefaultSensitiveSecretKeys
+)
+
+// SetSensitiveHostPaths replaces the host path prefixes treated as sensitive
+func SetSensitiveHostPaths(paths []string) {
+	sensitiveHostPaths = paths
+}
+
+// SetSensitiveSecretKeys replaces the secret key substrings treated as sensitive
+func SetSensitiveSecretKeys(keys []string) {
+	sensitiveSecretKeys = keys
+}
+
+// podContainer is a regular, init or ephemeral container with the fields the
+// container rules inspect
+type podContainer struct {
+	// field is the pod spec field holding the container, e.g. "initContainers"
+	field           string
+	kind            string
+	Name            string
+	Image           string
+	SecurityContext *corev1.SecurityContext
+	Resources       corev1.ResourceRequirements
+}
+
+// String names the container with its type, e.g. "init container setup"
+func (c podContainer) String() string {
+	return fmt.Sprintf("%s %s", c.kind, c.Name)
+}
+
+// ref is the evidence path of the container, e.g. "initContainers[setup]"
+func (c podContainer) ref() string {
+	return fmt.Sprintf("%s[%s]", c.field, c.Name)
+}
+
+// ephemeral reports whether the container was attached with kubectl debug
+func (c podContainer) ephemeral() bool {
+	return c.field == "ephemeralContainers"
+}
+
+// allContainers lists the init, regular and ephemeral containers of a pod spec
+func allContainers(spec *corev1.PodSpec) []podContainer {
+	out := make([]podContainer, 0, len(spec.InitContainers)+len(spec.Containers)+len(spec.EphemeralContainers))
+	for _, c := range spec.InitContainers {
+		out = append(out, podContainer{field: "initContainers", kind: "init container", Name: c.Name, Image: c.Image, SecurityContext: c.SecurityContext, Resources: c.Resources})
+	}
+	for _, c := range spec.Containers {
+		out = append(out, podContainer{field: "containers", kind: "container", Name: c.Name, Image: c.Image, SecurityContext: c.SecurityContext, Resources: c.Resources})
+	}
+	for _, c := range spec.EphemeralContainers {
+		out = append(out, podContainer{field: "ephemeralContainers", kind: "ephemeral container", Name: c.Name, Image: c.Image, SecurityContext: c.SecurityContext, Resources: c.Resources})
+	}
+	return out
+}
+
+// capitalize upper-cases the first letter of a message
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// podCheck adapts a Pod check to a rules.CheckFunc
+func podCheck(check func(pod *corev1.Pod) []rules.Violation) rules.CheckFunc {
+	return func(obj runtime.Object) []rules.Violation {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			return nil
+		}
+		return check(pod)
+	}
+}
+
+var podRules = []rules.Rule{
+	{
+		ID:          "POD-PRIVILEGED",
+		Kinds:       []string{"Pod"},
+		Severity:    findings.SeverityCritical,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Container runs in privileged mode with full access to the host",
+		Remediation: "Set securityContext.privileged to false",
+		Signal:      "PrivilegedPod",
+		Weight:      30,
+		Check: podCheck(func(pod *corev1.Pod) []rules.Violation {
+			var out []rules.Violation
+			for _, container := range allContainers(&pod.Spec) {
+				if container.SecurityContext != nil &&
+					container.SecurityContext.Privileged != nil &&
+					*container.SecurityContext.Privileged {
+					out = append(out, rules.Violation{
+						Message:  fmt.Sprintf("%s is privileged", container),
+						Evidence: []string{fmt.Sprintf("%s.securityContext.privileged=true", container.ref())},
+					})
+				}
+			}
+			return out
+		}),
+	},
+	{
+		ID:          "POD-DANGEROUS-CAPABILITY",
+		Kinds:       []string{"Pod"},
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Container adds ALL, NET_ADMIN or SYS_ADMIN capabilities",
+		Remediation: "Drop the capability and add only what the workload needs",
+		Signal:      "PrivilegedWorkload",
+		Weight:      25,
+		Check: podCheck(func(pod *corev1.Pod) []rules.Violation {
+			var out []rules.Violation
+			for _, container := range allContainers(&pod.Spec) {
+				if container.SecurityContext != nil && container.SecurityContext.Capabilities != nil {
+					for _, cap := range container.SecurityContext.Capabilities.Add {
+						if cap == "ALL" || cap == "NET_ADMIN" || cap == "SYS_ADMIN" {
+							out = append(out, rules.Violation{
+								Message:  fmt.Sprintf("%s adds insecure capability %s", container, cap),
+								Evidence: []string{fmt.Sprintf("%s.securityContext.capabilities.add=%s", container.ref(), cap)},
+							})
+						}
+					}
+				}
+			}
+			return out
+		}),
+	},
+	{
+		ID:          "POD-HOST-NETWORK",
+		Kinds:       []string{"Pod"},
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Pod shares the host network namespace",
+		Remediation: "Set hostNetwork to false",
+		Signal:      "PrivilegedWorkload",
+		Weight:      25,
+		Check: podCheck(func(pod *corev1.Pod) []rules.Violation {
+			if !pod.Spec.HostNetwork {
+				return nil
+			}
+			return []rules.Violation{{Message: "pod has hostNetwork=true", Evidence: []string{"spec.hostNetwork=true"}}}
+		}),
+	},
+	{
+		ID:          "POD-HOST-PID",
+		Kinds:       []string{"Pod"},
+		Severity:    findings.SeverityCritical,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Pod shares the host PID namespace",
+		Remediation: "Set hostPID to false",
+		Signal:      "PrivilegedWorkload",
+		Weight:      25,
+		Check: podCheck(func(pod *corev1.Pod) []rules.Violation {
+			if !pod.Spec.HostPID {
+				return nil
+			}
+			return []rules.Violation{{Message: "Pod has hostPID access which can expose host processes", Evidence: []string{"spec.hostPID=true"}}}
+		}),
+	},
+	{
+		ID:          "POD-HOST-IPC",
+		Kinds:       []string{"Pod"},
+		Severity:    findings.SeverityCritical,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Pod shares the host IPC namespace",
+		Remediation: "Set hostIPC to false",
+		Signal:      "PrivilegedWorkload",
+		Weight:      25,
+		Check: podCheck(func(pod *corev1.Pod) []rules.Violation {
+			if !pod.Spec.HostIPC {
+				return nil
+			}
+			return []rules.Violation{{Message: "Pod has hostIPC access which can expose host IPC namespace", Evidence: []string{"spec.hostIPC=true"}}}
+		}),
+	},
+	{
+		ID:          "POD-HOSTPATH",
+		Kinds:       []string{"Pod"},
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Pod mounts a hostPath volume",
+		Remediation: "Replace the hostPath volume with a PersistentVolumeClaim or emptyDir",
+		Check: podCheck(func(pod *corev1.Pod) []rules.Violation {
+			var out []rules.Violation
+			for _, volume := range pod.Spec.Volumes {
+				if volume.HostPath != nil {
+					out = append(out, rules.Violation{
+						Message:  fmt.Sprintf("Pod mounts host path: %s", volume.HostPath.Path),
+						Evidence: []string{fmt.Sprintf("volumes[%s].hostPath.path=%s", volume.Name, volume.HostPath.Path)},
+					})
+				}
+			}
+			return out
+		}),
+	},
+	{
+		ID:          "POD-SENSITIVE-HOSTPATH",
+		Kinds:       []string{"Pod"},
+		Severity:    findings.SeverityCritical,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Pod mounts a sensitive host directory such as /etc or the Docker socket",
+		Remediation: "Remove the hostPath mount of sensitive host directories",
+		Signal:      "SecretsExposure",
+		Weight:      20,
+		Check: podCheck(func(pod *corev1.Pod) []rules.Violation {
+			var out []rules.Violation
+			for _, volume := range pod.Spec.Volumes {
+				if volume.HostPath == nil {
+					continue
+				}
+				for _, sensitive := range sensitiveHostPaths {
+					if strings.HasPrefix(volume.HostPath.Path, sensitive) {
+						out = append(out, rules.Violation{
+							Message:  fmt.Sprintf("Pod mounts sensitive host path: %s", volume.HostPath.Path),
+							Evidence: []string{fmt.Sprintf("volumes[%s].hostPath.path=%s", volume.Name, volume.HostPath.Path)},
+						})
+					}
+				}
+			}
+			return out
+		}),
+	},
+	{
+		ID:          "POD-NO-SECURITY-CONTEXT",
+		Kinds:       []string{"Pod"},
+		Severity:    findings.SeverityInfo,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Pod defines no pod-level security context",
+		Remediation: "Define spec.securityContext with runAsNonRoot and a seccompProfile",
+		Check: podCheck(func(pod *corev1.Pod) []rules.Violation {
+			if pod.Spec.SecurityContext != nil {
+				return nil
+			}
+			return []rules.Violation{{Message: "Pod has no security context defined"}}
+		}),
+	},
+	{
+		ID:          "POD-PRIVILEGE-ESCALATION",
+		Kinds:       []string{"Pod"},
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Container explicitly allows privilege escalation",
+		Remediation: "Set securityContext.allowPrivilegeEscalation to false",
+		Check: podCheck(func(pod *corev1.Pod) []rules.Violation {
+			if pod.Spec.SecurityContext == nil {
+				return nil
+			}
+			var out []rules.Violation
+			for _, container := range allContainers(&pod.Spec) {
+				if container.SecurityContext != nil &&
+					container.SecurityContext.AllowPrivilegeEscalation != nil &&
+					*container.SecurityContext.AllowPrivilegeEscalation {
+					out = append(out, rules.Violation{
+						Message:  fmt.Sprintf("%s allows privilege escalation", capitalize(container.String())),
+						Evidence: []string{fmt.Sprintf("%s.securityContext.allowPrivilegeEscalation=true", container.ref())},
+					})
+				}
+			}
+			return out
+		}),
+	},
+	{
+		ID:          "POD-RUN-AS-ROOT",
+		Kinds:       []string{"Pod"},
+		Severity:    findings.SeverityInfo,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Container does not enforce runAsNonRoot",
+		Remediation: "Set securityContext.runAsNonRoot to true",
+		Check: podCheck(func(pod *corev1.Pod) []rules.Violation {
+			if pod.Spec.SecurityContext == nil {
+				return nil
+			}
+			var out []rules.Violation
+			for _, container := range allContainers(&pod.Spec) {
+				if container.SecurityContext == nil ||
+					container.SecurityContext.RunAsNonRoot == nil ||
+					!*container.SecurityContext.RunAsNonRoot {
+					out = append(out, rules.Violation{Message: fmt.Sprintf("%s may run as root", capitalize(container.String()))})
+				}
+			}
+			return out
+		}),
+	},
+	{
+		ID:          "POD-WRITABLE-ROOTFS",
+		Kinds:       []string{"Pod"},
+		Severity:    findings.SeverityInfo,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Container root filesystem is writable",
+		Remediation: "Set securityContext.readOnlyRootFilesystem to true",
+		Check: podCheck(func(pod *corev1.Pod) []rules.Violation {
+			if pod.Spec.SecurityContext == nil {
+				return nil
+			}
+			var out []rules.Violation
+			for _, container := range allContainers(&pod.Spec) {
+				if container.SecurityContext == nil ||
+					container.SecurityContext.ReadOnlyRootFilesystem == nil ||
+					!*container.SecurityContext.ReadOnlyRootFilesystem {
+					out = append(out, rules.Violation{Message: fmt.Sprintf("%s has writable root filesystem", capitalize(container.String()))})
+				}
+			}
+			return out
+		}),
+	},
+	{
+		ID:          "POD-LATEST-TAG",
+		Kinds:       []string{"Pod"},
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Container image uses the mutable 'latest' tag or no tag",
+		Remediation: "Pin the image to a version tag or digest",
+		Check: podCheck(func(pod *corev1.Pod) []rules.Violation {
+			var out []rules.Violation
+			for _, container := range allContainers(&pod.Spec) {
+				if strings.HasSuffix(container.Image, ":latest") || !strings.Contains(container.Image, ":") {
+					out = append(out, rules.Violation{
+						Message:  fmt.Sprintf("%s uses 'latest' tag which is mutable", capitalize(container.String())),
+						Evidence: []string{fmt.Sprintf("%s.image=%s", container.ref(), container.Image)},
+					})
+				}
+			}
+			return out
+		}),
+	},
+	{
+		ID:          "POD-EPHEMERAL-CONTAINER",
+		Kinds:       []string{"Pod"},
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Ephemeral debug container attached to a running pod (kubectl debug)",
+		Remediation: "Confirm the debug session was authorized and restart the pod to remove the container",
+		Check: podCheck(func(pod *corev1.Pod) []rules.Violation {
+			var out []rules.Violation
+			for _, container := range allContainers(&pod.Spec) {
+				if !container.ephemeral() {
+					continue
+				}
+				out = append(out, rules.Violation{
+					Message:  fmt.Sprintf("%s attached with image %s", capitalize(container.String()), container.Image),
+					Evidence: []string{fmt.Sprintf("%s.image=%s", container.ref(), container.Image)},
+				})
+			}
+			return out
+		}),
+	},
+}
+
+// deploymentCheck adapts a Deployment check to a rules.CheckFunc
+func deploymentCheck(check func(deployment *appsv1.Deployment) []rules.Violation) rules.CheckFunc {
+	return func(obj runtime.Object) []rules.Violation {
+		deployment, ok := obj.(*appsv1.Deployment)
+		if !ok {
+			return nil
+		}
+		return check(deployment)
+	}
+}
+
+// workloadKinds are the controllers whose pod templates the workload rules inspect
+var workloadKinds = []string{"Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job", "CronJob"}
+
+// templateCheck adapts a pod template check to a rules.CheckFunc for every workload kind
+func templateCheck(check func(kind string, spec *corev1.PodSpec) []rules.Violation) rules.CheckFunc {
+	return func(obj runtime.Object) []rules.Violation {
+		_, kind, _, template, ok := podTemplate(obj)
+		if !ok {
+			return nil
+		}
+		return check(kind, &template.Spec)
+	}
+}
+
+var deploymentRules = []rules.Rule{
+	{
+		ID:          "DEPLOY-MISSING-LABELS",
+		Kinds:       []string{"Deployment"},
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryDeploymentSecurity,
+		Description: "Deployment has no labels",
+		Remediation: "Label deployments with app.kubernetes.io/name and ownership metadata",
+		Signal:      "DeploymentMissingLabels",
+		Weight:      10,
+		Check: deploymentCheck(func(deployment *appsv1.Deployment) []rules.Violation {
+			if len(deployment.Labels) > 0 {
+				return nil
+			}
+			return []rules.Violation{{Message: "Deployment has no labels"}}
+		}),
+	},
+	{
+		ID:          "DEPLOY-NO-SECURITY-CONTEXT",
+		Kinds:       workloadKinds,
+		Severity:    findings.SeverityInfo,
+		Category:    findings.CategoryDeploymentSecurity,
+		Description: "Pod template defines no pod-level security context",
+		Remediation: "Define spec.template.spec.securityContext",
+		Check: templateCheck(func(kind string, spec *corev1.PodSpec) []rules.Violation {
+			if spec.SecurityContext != nil {
+				return nil
+			}
+			return []rules.Violation{{Message: fmt.Sprintf("%s has no pod security context defined", kind)}}
+		}),
+	},
+	{
+		ID:          "DEPLOY-NO-LIMITS",
+		Kinds:       workloadKinds,
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryDeploymentSecurity,
+		Description: "Container has no resource limits",
+		Remediation: "Set resources.limits for cpu and memory",
+		Check: templateCheck(func(_ string, spec *corev1.PodSpec) []rules.Violation {
+			var out []rules.Violation
+			for _, container := range allContainers(spec) {
+				// Ephemeral containers cannot set resources
+				if len(container.Resources.Limits) == 0 && !container.ephemeral() {
+					out = append(out, rules.Violation{Message: fmt.Sprintf("%s has no resource limits defined", capitalize(container.String()))})
+				}
+			}
+			return out
+		}),
+	},
+	{
+		ID:          "DEPLOY-NO-REQUESTS",
+		Kinds:       workloadKinds,
+		Severity:    findings.SeverityInfo,
+		Category:    findings.CategoryDeploymentSecurity,
+		Description: "Container has no resource requests",
+		Remediation: "Set resources.requests for cpu and memory",
+		Check: templateCheck(func(_ string, spec *corev1.PodSpec) []rules.Violation {
+			var out []rules.Violation
+			for _, container := range allContainers(spec) {
+				if len(container.Resources.Requests) == 0 && !container.ephemeral() {
+					out = append(out, rules.Violation{Message: fmt.Sprintf("%s has no resource requests defined", capitalize(container.String()))})
+				}
+			}
+			return out
+		}),
+	},
+}
+
+// secretCheck adapts a Secret check to a rules.CheckFunc
+func secretCheck(check func(secret *corev1.Secret) []rules.Violation) rules.CheckFunc {
+	return func(obj runtime.Object) []rules.Violation {
+		secret, ok := obj.(*corev1.Secret)
+		if !ok {
+			return nil
+		}
+		return check(secret)
+	}
+}
+
+var secretRules = []rules.Rule{
+	{
+		ID:          "SECRET-DEFAULT-SA-TOKEN",
+		Kinds:       []string{"Secret"},
+		Severity:    findings.SeverityInfo,
+		Category:    findings.CategorySecrets,
+		Description: "Long-lived token secret for the default ServiceAccount",
+		Remediation: "Use projected service account tokens and set automountServiceAccountToken to false",
+		Check: secretCheck(func(secret *corev1.Secret) []rules.Violation {
+			if secret.Type == corev1.SecretTypeServiceAccountToken &&
+				strings.HasPrefix(secret.Name, "default-token-") {
+				return []rules.Violation{{Message: "Default service account token created"}}
+			}
+			return nil
+		}),
+	},
+	{
+		ID:          "SECRET-SENSITIVE-KEY",
+		Kinds:       []string{"Secret"},
+		Severity:    findings.SeverityInfo,
+		Category:    findings.CategorySecrets,
+		Description: "Opaque secret holds keys that look like credentials",
+		Remediation: "Store credentials in an external secret manager",
+		Check: secretCheck(func(secret *corev1.Secret) []rules.Violation {
+			if secret.Type != corev1.SecretTypeOpaque {
+				return nil
+			}
+			var out []rules.Violation
+			keys := make([]string, 0, len(secret.Data))
+			for key := range secret.Data {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				keyLower := strings.ToLower(key)
+				for _, sensitiveKey := range sensitiveSecretKeys {
+					if strings.Contains(keyLower, strings.ToLower(sensitiveKey)) {
+						out = append(out, rules.Violation{
+							Message:  fmt.Sprintf("Secret contains potentially sensitive key: %s", key),
+							Evidence: []string{key},
+						})
+						break
+					}
+				}
+			}
+			return out
+		}),
+	},
+}
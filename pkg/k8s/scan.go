@@ -0,0 +1,103 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"kspm/pkg/findings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ScanWorkloads checks the pods and pod-template-bearing workloads in a
+// namespace ("" for all). Workloads created by another controller, such as a
+// Deployment's ReplicaSets, are covered by that controller's template, and
+// findings on controller-owned pods are attributed to the top-level
+// controller, so every issue is reported once per workload.
+func ScanWorkloads(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]findings.Finding, []findings.Resource, []error) {
+	var objects []runtime.Object
+	var errs []error
+	opts := metav1.ListOptions{}
+
+	if list, err := clientset.AppsV1().Deployments(namespace).List(ctx, opts); err != nil {
+		errs = append(errs, fmt.Errorf("failed to list deployments: %w", err))
+	} else {
+		for i := range list.Items {
+			objects = append(objects, &list.Items[i])
+		}
+	}
+	if list, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, opts); err != nil {
+		errs = append(errs, fmt.Errorf("failed to list statefulsets: %w", err))
+	} else {
+		for i := range list.Items {
+			objects = append(objects, &list.Items[i])
+		}
+	}
+	if list, err := clientset.AppsV1().DaemonSets(namespace).List(ctx, opts); err != nil {
+		errs = append(errs, fmt.Errorf("failed to list daemonsets: %w", err))
+	} else {
+		for i := range list.Items {
+			objects = append(objects, &list.Items[i])
+		}
+	}
+	if list, err := clientset.AppsV1().ReplicaSets(namespace).List(ctx, opts); err != nil {
+		errs = append(errs, fmt.Errorf("failed to list replicasets: %w", err))
+	} else {
+		for i := range list.Items {
+			objects = append(objects, &list.Items[i])
+		}
+	}
+	if list, err := clientset.BatchV1().CronJobs(namespace).List(ctx, opts); err != nil {
+		errs = append(errs, fmt.Errorf("failed to list cronjobs: %w", err))
+	} else {
+		for i := range list.Items {
+			objects = append(objects, &list.Items[i])
+		}
+	}
+	if list, err := clientset.BatchV1().Jobs(namespace).List(ctx, opts); err != nil {
+		errs = append(errs, fmt.Errorf("failed to list jobs: %w", err))
+	} else {
+		for i := range list.Items {
+			objects = append(objects, &list.Items[i])
+		}
+	}
+
+	owners := NewOwnerIndex()
+	for _, obj := range objects {
+		owners.Add(obj)
+	}
+
+	var out []findings.Finding
+	var scanned []findings.Resource
+	seen := map[string]bool{}
+	for _, obj := range objects {
+		_, _, meta, _, _ := podTemplate(obj)
+		if metav1.GetControllerOf(&meta) != nil {
+			continue
+		}
+		resource, _ := WorkloadResource(obj)
+		out = append(out, CheckWorkloadSecurity(obj)...)
+		seen[ownerKey(resource.Kind, resource.Namespace, resource.Name)] = true
+		scanned = append(scanned, resource)
+	}
+
+	if list, err := clientset.CoreV1().Pods(namespace).List(ctx, opts); err != nil {
+		errs = append(errs, fmt.Errorf("failed to list pods: %w", err))
+	} else {
+		for i := range list.Items {
+			pod := &list.Items[i]
+			resource, owned := owners.Owner(pod.ObjectMeta)
+			if !owned {
+				resource = PodResource(pod)
+			}
+			if key := ownerKey(resource.Kind, resource.Namespace, resource.Name); !seen[key] {
+				seen[key] = true
+				scanned = append(scanned, resource)
+			}
+			out = append(out, CheckOwnedPodSecurity(pod, owners)...)
+		}
+	}
+
+	return findings.Dedupe(out), scanned, errs
+}
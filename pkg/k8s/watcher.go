@@ -3,19 +3,23 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"kspm/pkg/entity"
+	"kspm/pkg/findings"
+	"kspm/pkg/rules"
 	"os"
 	"os/exec"
 	"regexp"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/fatih/color"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 )
@@ -23,7 +27,8 @@ import (
 // SecurityEvent represents a detected security issue
 type SecurityEvent struct {
 	Timestamp    time.Time
-	Severity     string // "INFO", "WARNING", "CRITICAL"
+	Severity     string // "INFO", "LOW", "MEDIUM", "HIGH", "CRITICAL"
+	RuleID       string // empty for lifecycle events (added/updated)
 	ResourceType string
 	ResourceName string
 	Namespace    string
@@ -41,12 +46,14 @@ type ConsoleSecurityEventHandler struct{}
 func (h ConsoleSecurityEventHandler) HandleEvent(event SecurityEvent) {
 	var output func(format string, a ...interface{})
 
-	switch event.Severity {
-	case "CRITICAL":
+	switch findings.Severity(event.Severity) {
+	case findings.SeverityCritical:
 		output = color.New(color.FgHiRed, color.Bold).PrintfFunc()
-	case "WARNING":
+	case findings.SeverityHigh:
+		output = color.New(color.FgHiRed).PrintfFunc()
+	case findings.SeverityMedium, findings.SeverityLow:
 		output = color.New(color.FgHiYellow).PrintfFunc()
-	case "INFO":
+	case findings.SeverityInfo:
 		output = color.New(color.FgHiCyan).PrintfFunc()
 	default:
 		// Directly use fmt.Printf instead of assigning it to output
@@ -92,6 +99,19 @@ func reportSecurityEvent(severity, resourceType, resourceName, namespace, messag
 	eventHandler.HandleEvent(event)
 }
 
+// reportFinding forwards a check finding to the security event handler
+func reportFinding(f findings.Finding) {
+	eventHandler.HandleEvent(SecurityEvent{
+		Timestamp:    time.Now(),
+		Severity:     string(f.Severity),
+		RuleID:       f.RuleID,
+		ResourceType: f.Resource.Kind,
+		ResourceName: f.Resource.Name,
+		Namespace:    f.Resource.Namespace,
+		Message:      f.Message,
+	})
+}
+
 // Multisecurity event handler fans out to multiple handlers
 type MultiSecurityEventHandler struct {
 	Handlers []SecurityEventHandler
@@ -131,36 +151,19 @@ func (r *RecordingSecurityEventHandler) SnapShot() []SecurityEvent {
 
 // checkRole checks if a Role has excessive permissions
 func checkRole(role rbacv1.Role) bool {
-	// Check for dangerous permissions
-	for _, rule := range role.Rules {
-		// Check for wildcard resources
-		for _, resource := range rule.Resources {
-			if resource == "*" {
-				reportSecurityEvent("WARNING", "Role", role.Name, role.Namespace,
-					"Role has wildcard resource permissions")
-				return false
-			}
-		}
-
-		// Check for wildcard verbs
-		if contains(rule.Verbs, "*") {
-			reportSecurityEvent("WARNING", "Role", role.Name, role.Namespace,
-				"Role has wildcard verb permissions")
-			return false
-		}
-	}
-
-	return true
+	return len(evaluateRules(entity.RoleResource(&role), &role)) == 0
 }
 
 // WatchClusterRoles monitors ClusterRole resources
-func WatchClusterRoles(clientset *kubernetes.Clientset) (cache.Controller, chan struct{}) {
-	watchlist := cache.NewListWatchFromClient(
-		clientset.RbacV1().RESTClient(),
-		"clusterroles",
-		metav1.NamespaceAll,
-		fields.Everything(),
-	)
+func WatchClusterRoles(clientset kubernetes.Interface) (cache.Controller, chan struct{}) {
+	watchlist := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			return clientset.RbacV1().ClusterRoles().List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			return clientset.RbacV1().ClusterRoles().Watch(ctx, options)
+		},
+	}
 
 	_, controller := cache.NewInformer(
 		watchlist,
@@ -189,13 +192,15 @@ func WatchClusterRoles(clientset *kubernetes.Clientset) (cache.Controller, chan
 }
 
 // WatchDeployments monitors Deployment resources
-func WatchDeployments(clientset *kubernetes.Clientset) (cache.Controller, chan struct{}) {
-	watchlist := cache.NewListWatchFromClient(
-		clientset.AppsV1().RESTClient(),
-		"deployments",
-		metav1.NamespaceAll,
-		fields.Everything(),
-	)
+func WatchDeployments(clientset kubernetes.Interface) (cache.Controller, chan struct{}) {
+	watchlist := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			return clientset.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			return clientset.AppsV1().Deployments(metav1.NamespaceAll).Watch(ctx, options)
+		},
+	}
 
 	_, controller := cache.NewInformer(
 		watchlist,
@@ -223,15 +228,111 @@ func WatchDeployments(clientset *kubernetes.Clientset) (cache.Controller, chan s
 	return controller, stop
 }
 
-// WatchSecrets monitors Secret resources
-func WatchSecrets(clientset *kubernetes.Clientset) (cache.Controller, chan struct{}) {
-	watchlist := cache.NewListWatchFromClient(
-		clientset.CoreV1().RESTClient(),
-		"secrets",
-		metav1.NamespaceAll,
-		fields.Everything(),
+// WatchStatefulSets monitors StatefulSet resources
+func WatchStatefulSets(clientset kubernetes.Interface) (cache.Controller, chan struct{}) {
+	return watchWorkload("StatefulSet", &appsv1.StatefulSet{}, &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			return clientset.AppsV1().StatefulSets(metav1.NamespaceAll).List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			return clientset.AppsV1().StatefulSets(metav1.NamespaceAll).Watch(ctx, options)
+		},
+	})
+}
+
+// WatchDaemonSets monitors DaemonSet resources
+func WatchDaemonSets(clientset kubernetes.Interface) (cache.Controller, chan struct{}) {
+	return watchWorkload("DaemonSet", &appsv1.DaemonSet{}, &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			return clientset.AppsV1().DaemonSets(metav1.NamespaceAll).List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			return clientset.AppsV1().DaemonSets(metav1.NamespaceAll).Watch(ctx, options)
+		},
+	})
+}
+
+// WatchReplicaSets monitors ReplicaSet resources not managed by a Deployment
+func WatchReplicaSets(clientset kubernetes.Interface) (cache.Controller, chan struct{}) {
+	return watchWorkload("ReplicaSet", &appsv1.ReplicaSet{}, &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			return clientset.AppsV1().ReplicaSets(metav1.NamespaceAll).List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			return clientset.AppsV1().ReplicaSets(metav1.NamespaceAll).Watch(ctx, options)
+		},
+	})
+}
+
+// WatchJobs monitors Job resources not created by a CronJob
+func WatchJobs(clientset kubernetes.Interface) (cache.Controller, chan struct{}) {
+	return watchWorkload("Job", &batchv1.Job{}, &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			return clientset.BatchV1().Jobs(metav1.NamespaceAll).List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			return clientset.BatchV1().Jobs(metav1.NamespaceAll).Watch(ctx, options)
+		},
+	})
+}
+
+// WatchCronJobs monitors CronJob resources
+func WatchCronJobs(clientset kubernetes.Interface) (cache.Controller, chan struct{}) {
+	return watchWorkload("CronJob", &batchv1.CronJob{}, &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			return clientset.BatchV1().CronJobs(metav1.NamespaceAll).List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			return clientset.BatchV1().CronJobs(metav1.NamespaceAll).Watch(ctx, options)
+		},
+	})
+}
+
+// watchWorkload runs CheckWorkloadSecurity on every added or updated
+// workload. Workloads created by another controller are skipped, their
+// template is checked through the owning controller.
+func watchWorkload(kind string, objType runtime.Object, watchlist *cache.ListWatch) (cache.Controller, chan struct{}) {
+	check := func(obj interface{}, action string) {
+		workload := obj.(runtime.Object)
+		_, _, meta, _, _ := podTemplate(workload)
+		if metav1.GetControllerOf(&meta) != nil {
+			return
+		}
+		reportSecurityEvent("INFO", kind, meta.Name, meta.Namespace, kind+" "+action)
+		CheckWorkloadSecurity(workload)
+	}
+
+	_, controller := cache.NewInformer(
+		watchlist,
+		objType,
+		0,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				check(obj, "added")
+			},
+			UpdateFunc: func(_, newObj interface{}) {
+				check(newObj, "updated")
+			},
+		},
 	)
 
+	stop := make(chan struct{})
+	go controller.Run(stop)
+
+	return controller, stop
+}
+
+// WatchSecrets monitors Secret resources
+func WatchSecrets(clientset kubernetes.Interface) (cache.Controller, chan struct{}) {
+	watchlist := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			return clientset.CoreV1().Secrets(metav1.NamespaceAll).List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			return clientset.CoreV1().Secrets(metav1.NamespaceAll).Watch(ctx, options)
+		},
+	}
+
 	_, controller := cache.NewInformer(
 		watchlist,
 		&corev1.Secret{},
@@ -259,13 +360,20 @@ func WatchSecrets(clientset *kubernetes.Clientset) (cache.Controller, chan struc
 }
 
 // WatchPods sets up a watch on Pod resources in the cluster
-func WatchPods(clientset *kubernetes.Clientset) (cache.Controller, chan struct{}) {
-	watchlist := cache.NewListWatchFromClient(
-		clientset.CoreV1().RESTClient(),
-		"pods",
-		metav1.NamespaceAll,
-		fields.Everything(),
-	)
+func WatchPods(clientset kubernetes.Interface) (cache.Controller, chan struct{}) {
+	watchlist := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			return clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			return clientset.CoreV1().Pods(metav1.NamespaceAll).Watch(ctx, options)
+		},
+	}
+
+	// Pods created by a controller are reported against it, once per finding
+	// and pod template revision
+	owners := NewLiveOwnerIndex(clientset)
+	reported := &reportedFindings{}
 
 	_, controller := cache.NewInformer(
 		watchlist,
@@ -276,16 +384,23 @@ func WatchPods(clientset *kubernetes.Clientset) (cache.Controller, chan struct{}
 				pod := obj.(*corev1.Pod)
 				info := color.New(color.FgHiGreen).PrintfFunc()
 				info("[+]Pod Added: %s in namespace %s\n", pod.Name, pod.Namespace)
-				CheckPodSecurity(pod)
+				checkWatchedPod(pod, owners, reported)
 			},
 			DeleteFunc: func(obj interface{}) {
-				pod := obj.(*corev1.Pod)
+				if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+					obj = tombstone.Obj
+				}
+				pod, ok := obj.(*corev1.Pod)
+				if !ok {
+					return
+				}
 				fmt.Printf("Pod Deleted: %s in namespace %s\n", pod.Name, pod.Namespace)
+				forgetWatchedPod(pod, reported)
 			},
 			UpdateFunc: func(_, newObj interface{}) {
 				newPod := newObj.(*corev1.Pod)
 				fmt.Printf("Pod Updated: %s in namespace %s\n", newPod.Name, newPod.Namespace)
-				CheckPodSecurity(newPod)
+				checkWatchedPod(newPod, owners, reported)
 			},
 		},
 	)
@@ -296,121 +411,49 @@ func WatchPods(clientset *kubernetes.Clientset) (cache.Controller, chan struct{}
 	return controller, stop
 }
 
-// CheckPodSecurity performs security checks on the provided Pod
-func CheckPodSecurity(pod *corev1.Pod) {
-	// Write to findings
-	var findings []string
-
-	// Add logic for helper on emitter
-	emit := func(sev, msg string) {
-		findings = append(findings,
-			fmt.Sprintf("[SEV=%s] [CAT=PodSecurity] ns=%s kind=Pod name=%s msg=%q",
-				sev, pod.Namespace, pod.Name, msg),
-		)
-
-		// keep console output (severity-based)
-		switch sev {
-		case "CRITICAL":
-			color.New(color.FgHiRed).Printf("[CRITICAL] Pod/%s (%s): %s\n", pod.Name, pod.Namespace, msg)
-		case "WARNING", "HIGH":
-			color.New(color.FgHiRed).Printf("[WARN] Pod/%s (%s): %s\n", pod.Name, pod.Namespace, msg)
-		default:
-			color.New(color.FgYellow).Printf("[INFO] Pod/%s (%s): %s\n", pod.Name, pod.Namespace, msg)
-		}
+// evaluateRules runs the registered rules for the resource kind and
+// forwards every finding to the security event handler
+func evaluateRules(resource findings.Resource, obj runtime.Object) []findings.Finding {
+	out := rules.Evaluate(resource, obj)
+	for _, f := range out {
+		reportFinding(f)
 	}
+	return out
+}
 
-	// Example security check: Check for privileged containers
-	for _, container := range pod.Spec.Containers {
-		if container.SecurityContext != nil &&
-			container.SecurityContext.Privileged != nil &&
-			*container.SecurityContext.Privileged {
-			emit("CRITICAL", fmt.Sprintf("container %s is privileged", container.Name))
-		}
-	}
-	// Example security check: Check for insecure capabilities
-	// Implement more security checks here
-	for _, container := range pod.Spec.Containers {
-		if container.SecurityContext != nil && container.SecurityContext.Capabilities != nil {
-			for _, cap := range container.SecurityContext.Capabilities.Add {
-				if cap == "ALL" || cap == "NET_ADMIN" || cap == "SYS_ADMIN" {
-					emit("HIGH", fmt.Sprintf("container %s adds insecure capability %s", container.Name, cap))
-				}
-			}
+// evaluatePodRulesAs runs the pod rules against pod and attributes the
+// findings, except those of pod scoped rules, to owner before forwarding them
+// to the security event handler
+func evaluatePodRulesAs(owner findings.Resource, pod *corev1.Pod) []findings.Finding {
+	out := rules.Evaluate(PodResource(pod), pod)
+	for i := range out {
+		if !podScopedRules[out[i].RuleID] {
+			out[i].Resource = owner
 		}
+		reportFinding(out[i])
 	}
-	if pod.Spec.HostNetwork {
-		emit("HIGH", "pod has hostNetwork=true")
-	}
-
-	// New security checks
-
-	// Check for hostPID/hostIPC use
-	if pod.Spec.HostPID {
-		reportSecurityEvent("CRITICAL", "Pod", pod.Name, pod.Namespace,
-			"Pod has hostPID access which can expose host processes")
-	}
+	return out
+}
 
-	if pod.Spec.HostIPC {
-		reportSecurityEvent("CRITICAL", "Pod", pod.Name, pod.Namespace,
-			"Pod has hostIPC access which can expose host IPC namespace")
-	}
+// PodResource identifies a Pod in findings
+func PodResource(pod *corev1.Pod) findings.Resource {
+	return findings.Resource{APIVersion: "v1", Kind: "Pod", Namespace: pod.Namespace, Name: pod.Name, Labels: pod.Labels}
+}
 
-	// Check for host volume mounts
-	for _, volume := range pod.Spec.Volumes {
-		if volume.HostPath != nil {
-			reportSecurityEvent("WARNING", "Pod", pod.Name, pod.Namespace,
-				fmt.Sprintf("Pod mounts host path: %s", volume.HostPath.Path))
-
-			// Check for sensitive paths
-			sensitivePaths := []string{"/etc", "/var/run/docker.sock", "/proc", "/var/log"}
-			for _, sensitive := range sensitivePaths {
-				if strings.HasPrefix(volume.HostPath.Path, sensitive) {
-					reportSecurityEvent("CRITICAL", "Pod", pod.Name, pod.Namespace,
-						fmt.Sprintf("Pod mounts sensitive host path: %s", volume.HostPath.Path))
-				}
-			}
-		}
-	}
+// DeploymentResources lists the resources CheckDeploymentSecurity evaluates
+func DeploymentResources(deployment *appsv1.Deployment) []findings.Resource {
+	resource, _ := WorkloadResource(deployment)
+	return []findings.Resource{resource}
+}
 
-	// Check Security Context
-	if pod.Spec.SecurityContext == nil {
-		reportSecurityEvent("INFO", "Pod", pod.Name, pod.Namespace,
-			"Pod has no security context defined")
-	} else {
-		// Check for allowPrivilegeEscalation
-		for _, container := range pod.Spec.Containers {
-			if container.SecurityContext != nil &&
-				container.SecurityContext.AllowPrivilegeEscalation != nil &&
-				*container.SecurityContext.AllowPrivilegeEscalation {
-				reportSecurityEvent("WARNING", "Pod", pod.Name, pod.Namespace,
-					fmt.Sprintf("Container %s allows privilege escalation", container.Name))
-			}
-
-			// Check for running as root
-			if container.SecurityContext == nil ||
-				container.SecurityContext.RunAsNonRoot == nil ||
-				!*container.SecurityContext.RunAsNonRoot {
-				reportSecurityEvent("INFO", "Pod", pod.Name, pod.Namespace,
-					fmt.Sprintf("Container %s may run as root", container.Name))
-			}
-
-			// Check for read-only root filesystem
-			if container.SecurityContext == nil ||
-				container.SecurityContext.ReadOnlyRootFilesystem == nil ||
-				!*container.SecurityContext.ReadOnlyRootFilesystem {
-				reportSecurityEvent("INFO", "Pod", pod.Name, pod.Namespace,
-					fmt.Sprintf("Container %s has writable root filesystem", container.Name))
-			}
-		}
-	}
+// SecretResource identifies a Secret in findings
+func SecretResource(secret *corev1.Secret) findings.Resource {
+	return findings.Resource{APIVersion: "v1", Kind: "Secret", Namespace: secret.Namespace, Name: secret.Name, Labels: secret.Labels}
+}
 
-	// Check for latest tag
-	for _, container := range pod.Spec.Containers {
-		if strings.HasSuffix(container.Image, ":latest") || !strings.Contains(container.Image, ":") {
-			reportSecurityEvent("WARNING", "Pod", pod.Name, pod.Namespace,
-				fmt.Sprintf("Container %s uses 'latest' tag which is mutable", container.Name))
-		}
-	}
+// CheckPodSecurity performs security checks on the provided Pod
+func CheckPodSecurity(pod *corev1.Pod) []findings.Finding {
+	return evaluateRules(PodResource(pod), pod)
 }
 
 var imageNameRegex = regexp.MustCompile(`(?:([^/]+)/)?([^@:]+)(?:[@:](.+))?`)
@@ -472,98 +515,22 @@ func sendImagesToGuac(images []string) error {
 }
 
 // CheckDeploymentSecurity performs security checks on Deployments
-func CheckDeploymentSecurity(deployment *appsv1.Deployment) {
-	// Create a new Pod object from the PodTemplateSpec
-	pod := &corev1.Pod{
-		Spec: deployment.Spec.Template.Spec,
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      deployment.Name,
-			Namespace: deployment.Namespace,
-			Labels:    deployment.Spec.Template.Labels,
-		},
-	}
-
-	// Check if deployment has pod security context
-	if deployment.Spec.Template.Spec.SecurityContext == nil {
-		reportSecurityEvent("INFO", "Deployment", deployment.Name, deployment.Namespace,
-			"Deployment has no pod security context defined")
-	}
-
-	// Check for resource limits/requests
-	for _, container := range deployment.Spec.Template.Spec.Containers {
-		if container.Resources.Limits == nil || len(container.Resources.Limits) == 0 {
-			reportSecurityEvent("WARNING", "Deployment", deployment.Name, deployment.Namespace,
-				fmt.Sprintf("Container %s has no resource limits defined", container.Name))
-		}
-
-		if container.Resources.Requests == nil || len(container.Resources.Requests) == 0 {
-			reportSecurityEvent("INFO", "Deployment", deployment.Name, deployment.Namespace,
-				fmt.Sprintf("Container %s has no resource requests defined", container.Name))
-		}
-	}
-
-	// Check pod template for security issues
-	CheckPodSecurity(pod)
+func CheckDeploymentSecurity(deployment *appsv1.Deployment) []findings.Finding {
+	return CheckWorkloadSecurity(deployment)
 }
 
 // CheckClusterRoleSecurity examines a ClusterRole for security issues
-func CheckClusterRoleSecurity(role *rbacv1.ClusterRole) {
-	dangerousVerbs := []string{"create", "delete", "update", "patch"}
-	dangerousResources := []string{"secrets", "roles", "rolebindings", "clusterroles", "clusterrolebindings"}
-
-	for _, rule := range role.Rules {
-		// Check for wildcard permissions
-		if contains(rule.Resources, "*") {
-			reportSecurityEvent("CRITICAL", "ClusterRole", role.Name, "cluster-wide",
-				"ClusterRole has wildcard resource permissions")
-		}
-
-		if contains(rule.Verbs, "*") {
-			reportSecurityEvent("WARNING", "ClusterRole", role.Name, "cluster-wide",
-				"ClusterRole has wildcard verb permissions")
-		}
-
-		// Check for dangerous permissions
-		for _, resource := range rule.Resources {
-			if contains(dangerousResources, resource) {
-				for _, verb := range rule.Verbs {
-					if contains(dangerousVerbs, verb) {
-						reportSecurityEvent("WARNING", "ClusterRole", role.Name, "cluster-wide",
-							fmt.Sprintf("ClusterRole has sensitive permission: %s %s", verb, resource))
-					}
-				}
-			}
-		}
-	}
+func CheckClusterRoleSecurity(role *rbacv1.ClusterRole) []findings.Finding {
+	return evaluateRules(entity.ClusterRoleResource(role), role)
 }
 
 // CheckSecretSecurity examines secrets for security issues
-func CheckSecretSecurity(secret *corev1.Secret) {
-	// Check for default service account tokens
-	if secret.Type == corev1.SecretTypeServiceAccountToken &&
-		strings.HasPrefix(secret.Name, "default-token-") {
-		reportSecurityEvent("INFO", "Secret", secret.Name, secret.Namespace,
-			"Default service account token created")
-	}
-
-	// Check for potentially sensitive data in generic secrets
-	if secret.Type == corev1.SecretTypeOpaque {
-		sensitiveKeys := []string{"password", "token", "key", "secret", "credential", "cert"}
-		for key := range secret.Data {
-			keyLower := strings.ToLower(key)
-			for _, sensitiveKey := range sensitiveKeys {
-				if strings.Contains(keyLower, sensitiveKey) {
-					reportSecurityEvent("INFO", "Secret", secret.Name, secret.Namespace,
-						fmt.Sprintf("Secret contains potentially sensitive key: %s", key))
-					break
-				}
-			}
-		}
-	}
+func CheckSecretSecurity(secret *corev1.Secret) []findings.Finding {
+	return evaluateRules(SecretResource(secret), secret)
 }
 
 // StartKubernetesWatchers initializes all watchers
-func StartKubernetesWatchers(clientset *kubernetes.Clientset, options map[string]bool) []chan struct{} {
+func StartKubernetesWatchers(clientset kubernetes.Interface, options map[string]bool) []chan struct{} {
 	var stopChannels []chan struct{}
 
 	// Add debugging
@@ -582,6 +549,16 @@ func StartKubernetesWatchers(clientset *kubernetes.Clientset, options map[string
 		color.Green("Deployment watcher started")
 	}
 
+	if options["workloads"] {
+		for _, watcher := range []func(kubernetes.Interface) (cache.Controller, chan struct{}){
+			WatchStatefulSets, WatchDaemonSets, WatchReplicaSets, WatchJobs, WatchCronJobs,
+		} {
+			_, stopCh := watcher(clientset)
+			stopChannels = append(stopChannels, stopCh)
+		}
+		color.Green("Workload watchers started (StatefulSets, DaemonSets, ReplicaSets, Jobs, CronJobs)")
+	}
+
 	if options["secrets"] {
 		_, stopCh := WatchSecrets(clientset)
 		stopChannels = append(stopChannels, stopCh)
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"kspm/pkg/findings"
 	"kspm/pkg/k8s/internal/testutil"
 
 	"github.com/stretchr/testify/assert"
@@ -190,6 +191,65 @@ func TestCheckPodSecurity(t *testing.T) {
 	}
 }
 
+func TestCheckPodSecurityReturnsFindings(t *testing.T) {
+	SetSecurityEventHandler(&RecordingSecurityEventHandler{})
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "privileged-pod", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			HostPID: true,
+			Containers: []corev1.Container{
+				{
+					Name:  "app",
+					Image: "nginx:1.25",
+					SecurityContext: &corev1.SecurityContext{
+						Privileged: func() *bool { b := true; return &b }(),
+					},
+				},
+			},
+		},
+	}
+
+	result := CheckPodSecurity(pod)
+
+	ruleIDs := map[string]findings.Finding{}
+	for _, f := range result {
+		ruleIDs[f.RuleID] = f
+	}
+	assert.Contains(t, ruleIDs, "POD-PRIVILEGED")
+	assert.Contains(t, ruleIDs, "POD-HOST-PID")
+	assert.Equal(t, findings.SeverityCritical, ruleIDs["POD-PRIVILEGED"].Severity)
+	assert.Equal(t, findings.Resource{APIVersion: "v1", Kind: "Pod", Namespace: "default", Name: "privileged-pod"}, ruleIDs["POD-PRIVILEGED"].Resource)
+}
+
+func TestCheckPodSecurityInitAndEphemeralContainers(t *testing.T) {
+	SetSecurityEventHandler(&RecordingSecurityEventHandler{})
+
+	privileged := true
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{
+				Name:            "setup",
+				Image:           "busybox:1.36",
+				SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+			}},
+			Containers: []corev1.Container{{Name: "app", Image: "nginx:1.27"}},
+			EphemeralContainers: []corev1.EphemeralContainer{{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger", Image: "busybox"},
+			}},
+		},
+	}
+
+	messages := map[string][]string{}
+	for _, f := range CheckPodSecurity(pod) {
+		messages[f.RuleID] = append(messages[f.RuleID], f.Message)
+	}
+	assert.Equal(t, []string{"init container setup is privileged"}, messages["POD-PRIVILEGED"])
+	assert.Equal(t, []string{"Ephemeral container debugger uses 'latest' tag which is mutable"}, messages["POD-LATEST-TAG"])
+	assert.Equal(t, []string{"Ephemeral container debugger attached with image busybox"}, messages["POD-EPHEMERAL-CONTAINER"])
+}
+
 func TestCheckDeploymentSecurity(t *testing.T) {
 	recorder := &RecordingSecurityEventHandler{}
 	SetSecurityEventHandler(recorder)
@@ -0,0 +1,176 @@
+// Package manifest decodes Kubernetes objects from YAML and JSON files so the
+// security rules can run without a live cluster.
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// Object is a decoded manifest together with where it was read from
+type Object struct {
+	Object runtime.Object
+	File   string
+	Line   int // 1-based line of the first content line of the document
+}
+
+// document is a raw YAML/JSON document and its starting line
+type document struct {
+	data []byte
+	line int
+}
+
+// manifestExtensions lists the file extensions read when walking directories
+var manifestExtensions = map[string]bool{".yaml": true, ".yml": true, ".json": true}
+
+// Load reads every manifest in the given files and directories. Documents that
+// cannot be decoded are reported as errors with file:line and skipped; kinds
+// unknown to client-go (custom resources) are skipped silently.
+func Load(paths []string) ([]Object, []error) {
+	var objects []Object
+	var errs []error
+
+	for _, path := range paths {
+		files, err := manifestFiles(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, file := range files {
+			objs, fileErrs := LoadFile(file)
+			objects = append(objects, objs...)
+			errs = append(errs, fileErrs...)
+		}
+	}
+	return objects, errs
+}
+
+// manifestFiles expands a path into the manifest files it contains
+func manifestFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			// Skip hidden directories such as .git
+			if p != path && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if manifestExtensions[strings.ToLower(filepath.Ext(p))] {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", path, err)
+	}
+	return files, nil
+}
+
+// LoadFile decodes every document in a single file
+func LoadFile(file string) ([]Object, []error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, []error{fmt.Errorf("read %s: %w", file, err)}
+	}
+	defer f.Close()
+
+	return Decode(f, file)
+}
+
+// Decode splits a multi-document stream and decodes each document. file is
+// only used to attribute objects and errors.
+func Decode(r io.Reader, file string) ([]Object, []error) {
+	docs, err := splitDocuments(r)
+	if err != nil {
+		return nil, []error{fmt.Errorf("read %s: %w", file, err)}
+	}
+
+	decoder := scheme.Codecs.UniversalDeserializer()
+	var objects []Object
+	var errs []error
+	for _, doc := range docs {
+		obj, _, err := decoder.Decode(doc.data, nil, nil)
+		if runtime.IsNotRegisteredError(err) {
+			// Custom resources have no checks; skip them quietly
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", file, doc.line, err))
+			continue
+		}
+
+		// Expand kind: List into its items
+		if list, ok := obj.(*corev1.List); ok {
+			for _, item := range list.Items {
+				itemObj, _, err := decoder.Decode(item.Raw, nil, nil)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s:%d: %w", file, doc.line, err))
+					continue
+				}
+				objects = append(objects, Object{Object: itemObj, File: file, Line: doc.line})
+			}
+			continue
+		}
+		objects = append(objects, Object{Object: obj, File: file, Line: doc.line})
+	}
+	return objects, errs
+}
+
+// splitDocuments splits YAML on "---" separators, recording the first content
+// line of each document and dropping documents that are empty or only comments
+func splitDocuments(r io.Reader) ([]document, error) {
+	var docs []document
+	var buf bytes.Buffer
+	start := 0
+
+	flush := func() {
+		if start > 0 {
+			docs = append(docs, document{data: append([]byte(nil), buf.Bytes()...), line: start})
+		}
+		buf.Reset()
+		start = 0
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "---") && strings.TrimSpace(strings.TrimPrefix(line, "---")) == "" {
+			flush()
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if start == 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			start = n
+		}
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return docs, nil
+}
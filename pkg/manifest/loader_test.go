@@ -0,0 +1,109 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const multiDoc = `# leading comment
+apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: app
+    image: nginx:1.27
+    securityContext:
+      privileged: true
+---
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: prod
+spec:
+  selector:
+    matchLabels: {app: api}
+  template:
+    metadata:
+      labels: {app: api}
+    spec:
+      containers:
+      - name: api
+        image: api:1.0
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: ignored
+`
+
+func TestDecodeMultiDocument(t *testing.T) {
+	objects, errs := Decode(strings.NewReader(multiDoc), "app.yaml")
+	require.Empty(t, errs)
+	require.Len(t, objects, 2)
+
+	pod, ok := objects[0].Object.(*corev1.Pod)
+	require.True(t, ok)
+	assert.Equal(t, "web", pod.Name)
+	assert.Equal(t, "app.yaml", objects[0].File)
+	assert.Equal(t, 2, objects[0].Line)
+
+	deployment, ok := objects[1].Object.(*appsv1.Deployment)
+	require.True(t, ok)
+	assert.Equal(t, "api", deployment.Name)
+	assert.Equal(t, 14, objects[1].Line)
+}
+
+func TestDecodeListAndErrors(t *testing.T) {
+	list := `{"apiVersion": "v1", "kind": "List", "items": [
+  {"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "a"}},
+  {"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "b"}}
+]}`
+	objects, errs := Decode(strings.NewReader(list), "list.json")
+	require.Empty(t, errs)
+	assert.Len(t, objects, 2)
+
+	_, errs = Decode(strings.NewReader("kind: [broken"), "bad.yaml")
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "bad.yaml:1")
+}
+
+func TestLoadDirectoryAndScan(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"), []byte(multiDoc), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# not a manifest"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".git"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "x.yaml"), []byte("kind: [broken"), 0o600))
+
+	objects, errs := Load([]string{dir})
+	require.Empty(t, errs)
+	require.Len(t, objects, 2)
+
+	result := Scan(objects)
+	assert.Empty(t, result.Skipped)
+	assert.NotEmpty(t, result.Resources)
+
+	var privileged bool
+	for _, f := range result.Findings {
+		require.NotNil(t, f.Location)
+		assert.Equal(t, filepath.Join(dir, "app.yaml"), f.Location.File)
+		if f.RuleID == "POD-PRIVILEGED" {
+			privileged = true
+			assert.Equal(t, 2, f.Location.Line)
+		}
+	}
+	assert.True(t, privileged)
+
+	_, errs = Load([]string{filepath.Join(dir, "missing")})
+	assert.Len(t, errs, 1)
+}
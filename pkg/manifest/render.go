@@ -0,0 +1,186 @@
+package manifest
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+)
+
+// HelmOptions configures an in-process Helm render
+type HelmOptions struct {
+	// ValuesFiles are merged in order; later files win
+	ValuesFiles []string
+	ReleaseName string
+	Namespace   string
+}
+
+// RenderHelm renders a chart directory without a cluster and decodes the
+// output. Findings are attributed to the template file that produced them.
+func RenderHelm(chartDir string, opts HelmOptions) ([]Object, []error) {
+	chrt, err := loader.Load(chartDir)
+	if err != nil {
+		return nil, []error{fmt.Errorf("load chart %s: %w", chartDir, err)}
+	}
+
+	values := map[string]interface{}{}
+	for _, file := range opts.ValuesFiles {
+		override, err := chartutil.ReadValuesFile(file)
+		if err != nil {
+			return nil, []error{fmt.Errorf("read values %s: %w", file, err)}
+		}
+		values = mergeValues(values, override.AsMap())
+	}
+
+	if err := chartutil.ProcessDependenciesWithMerge(chrt, values); err != nil {
+		return nil, []error{fmt.Errorf("process chart dependencies: %w", err)}
+	}
+
+	if opts.ReleaseName == "" {
+		opts.ReleaseName = "paranoia"
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = "default"
+	}
+	renderValues, err := chartutil.ToRenderValues(chrt, values, chartutil.ReleaseOptions{
+		Name:      opts.ReleaseName,
+		Namespace: opts.Namespace,
+		Revision:  1,
+		IsInstall: true,
+	}, chartutil.DefaultCapabilities)
+	if err != nil {
+		return nil, []error{fmt.Errorf("build render values: %w", err)}
+	}
+
+	rendered, err := engine.Render(chrt, renderValues)
+	if err != nil {
+		return nil, []error{fmt.Errorf("render chart %s: %w", chartDir, err)}
+	}
+
+	// Render order is map order; sort for stable output
+	names := make([]string, 0, len(rendered))
+	for name := range rendered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var objects []Object
+	var errs []error
+	for _, name := range names {
+		base := filepath.Base(name)
+		if strings.HasPrefix(base, "_") || !manifestExtensions[strings.ToLower(filepath.Ext(base))] {
+			continue
+		}
+
+		// Template names are prefixed with the chart name, e.g. "app/templates/deployment.yaml"
+		source := filepath.Join(chartDir, filepath.FromSlash(strings.TrimPrefix(name, chrt.Name()+"/")))
+		objs, decodeErrs := Decode(strings.NewReader(rendered[name]), source)
+		for i := range objs {
+			// Lines refer to the rendered output, not the template source
+			objs[i].Line = 0
+		}
+		objects = append(objects, objs...)
+		errs = append(errs, decodeErrs...)
+	}
+	return objects, errs
+}
+
+// mergeValues overlays override onto base, merging nested maps like helm -f
+func mergeValues(base, override map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(base))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range override {
+		if next, ok := v.(map[string]interface{}); ok {
+			if current, ok := out[k].(map[string]interface{}); ok {
+				out[k] = mergeValues(current, next)
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// RenderKustomize builds a kustomization directory without a cluster and
+// decodes the output. Resources are attributed to the file they were read
+// from when kustomize can report it, otherwise to the kustomization itself.
+func RenderKustomize(dir string) ([]Object, []error) {
+	opts := krusty.MakeDefaultOptions()
+	opts.LoadRestrictions = types.LoadRestrictionsNone
+
+	fsys := filesys.MakeFsOnDisk()
+	root, _, err := fsys.CleanedAbs(dir)
+	if err != nil {
+		return nil, []error{fmt.Errorf("build kustomization %s: %w", dir, err)}
+	}
+	resMap, err := krusty.MakeKustomizer(opts).Run(originFs{FileSystem: fsys, root: root.String()}, dir)
+	if err != nil {
+		return nil, []error{fmt.Errorf("build kustomization %s: %w", dir, err)}
+	}
+
+	var objects []Object
+	var errs []error
+	for _, res := range resMap.Resources() {
+		source := filepath.Join(dir, "kustomization.yaml")
+		if origin, err := res.GetOrigin(); err == nil && origin != nil && origin.Path != "" {
+			source = filepath.Join(dir, origin.Path)
+		}
+		// The annotation is only there for attribution, not part of the object
+		if err := res.SetOrigin(nil); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source, err))
+			continue
+		}
+
+		data, err := res.AsYAML()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source, err))
+			continue
+		}
+		objs, decodeErrs := Decode(strings.NewReader(string(data)), source)
+		for i := range objs {
+			objs[i].Line = 0
+		}
+		objects = append(objects, objs...)
+		errs = append(errs, decodeErrs...)
+	}
+	return objects, errs
+}
+
+// originFs reads the root kustomization with the originAnnotations build
+// option added, so every resource records the file it was read from. Kustomize
+// only takes the option from the kustomization itself.
+type originFs struct {
+	filesys.FileSystem
+	root string
+}
+
+func (fs originFs) ReadFile(path string) ([]byte, error) {
+	data, err := fs.FileSystem.ReadFile(path)
+	if err != nil || filepath.Dir(path) != fs.root || !slices.Contains(konfig.RecognizedKustomizationFileNames(), filepath.Base(path)) {
+		return data, err
+	}
+
+	var kustomization map[string]interface{}
+	if err := yaml.Unmarshal(data, &kustomization); err != nil || kustomization == nil {
+		// Left for kustomize to report
+		return data, nil
+	}
+	metadata, _ := kustomization["buildMetadata"].([]interface{})
+	if slices.Contains(metadata, interface{}(types.OriginAnnotations)) {
+		return data, nil
+	}
+	kustomization["buildMetadata"] = append(metadata, types.OriginAnnotations)
+	return yaml.Marshal(kustomization)
+}
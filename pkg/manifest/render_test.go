@@ -0,0 +1,92 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestRenderHelm(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Chart.yaml"), "apiVersion: v2\nname: web\nversion: 0.1.0\n")
+	writeFile(t, filepath.Join(dir, "values.yaml"), "image: nginx:1.27\nprivileged: false\n")
+	writeFile(t, filepath.Join(dir, "templates", "_helpers.tpl"), `{{- define "web.name" -}}{{ .Release.Name }}-web{{- end -}}`)
+	writeFile(t, filepath.Join(dir, "templates", "NOTES.txt"), "installed")
+	writeFile(t, filepath.Join(dir, "templates", "deployment.yaml"), `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "web.name" . }}
+  namespace: {{ .Release.Namespace }}
+spec:
+  selector:
+    matchLabels: {app: web}
+  template:
+    metadata:
+      labels: {app: web}
+    spec:
+      containers:
+      - name: web
+        image: {{ .Values.image }}
+        securityContext:
+          privileged: {{ .Values.privileged }}
+`)
+	override := filepath.Join(dir, "ci-values.yaml")
+	writeFile(t, override, "privileged: true\n")
+
+	objects, errs := RenderHelm(dir, HelmOptions{ValuesFiles: []string{override}, ReleaseName: "rel", Namespace: "prod"})
+	require.Empty(t, errs)
+	require.Len(t, objects, 1)
+
+	deployment, ok := objects[0].Object.(*appsv1.Deployment)
+	require.True(t, ok)
+	assert.Equal(t, "rel-web", deployment.Name)
+	assert.Equal(t, "prod", deployment.Namespace)
+	assert.Equal(t, filepath.Join(dir, "templates", "deployment.yaml"), objects[0].File)
+
+	result := Scan(objects)
+	var privileged bool
+	for _, f := range result.Findings {
+		if f.RuleID == "POD-PRIVILEGED" {
+			privileged = true
+			assert.Equal(t, filepath.Join(dir, "templates", "deployment.yaml"), f.Location.File)
+		}
+	}
+	assert.True(t, privileged, "values override should enable privileged")
+}
+
+func TestRenderKustomize(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "base", "kustomization.yaml"), "resources:\n- pod.yaml\n")
+	writeFile(t, filepath.Join(dir, "base", "pod.yaml"), `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: nginx:1.27
+`)
+	writeFile(t, filepath.Join(dir, "overlay", "kustomization.yaml"), "namespace: prod\nnamePrefix: ci-\nresources:\n- ../base\n")
+
+	objects, errs := RenderKustomize(filepath.Join(dir, "overlay"))
+	require.Empty(t, errs)
+	require.Len(t, objects, 1)
+
+	pod, ok := objects[0].Object.(*corev1.Pod)
+	require.True(t, ok)
+	assert.Equal(t, "ci-web", pod.Name)
+	assert.Equal(t, "prod", pod.Namespace)
+	assert.Equal(t, filepath.Join(dir, "base", "pod.yaml"), objects[0].File)
+	assert.NotContains(t, pod.Annotations, "config.kubernetes.io/origin")
+}
@@ -0,0 +1,33 @@
+package manifest
+
+import (
+	"kspm/pkg/findings"
+	"kspm/pkg/k8s"
+)
+
+// Result holds the outcome of an offline scan
+type Result struct {
+	Findings  []findings.Finding
+	Resources []findings.Resource
+	// Skipped lists decoded objects whose kind has no checks
+	Skipped []Object
+}
+
+// Scan runs the cluster checks against decoded manifests and attributes every
+// finding to the file and line of its source document
+func Scan(objects []Object) Result {
+	var result Result
+	for _, o := range objects {
+		out, scanned, ok := k8s.CheckObject(o.Object)
+		if !ok {
+			result.Skipped = append(result.Skipped, o)
+			continue
+		}
+		for i := range out {
+			out[i].Location = &findings.Location{File: o.File, Line: o.Line}
+		}
+		result.Findings = append(result.Findings, out...)
+		result.Resources = append(result.Resources, scanned...)
+	}
+	return result
+}
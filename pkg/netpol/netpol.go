@@ -0,0 +1,374 @@
+// Package netpol analyzes which pods the NetworkPolicies of a cluster select
+// for ingress and egress, and where that coverage has gaps.
+package netpol
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"kspm/pkg/findings"
+	"kspm/pkg/k8s"
+	"kspm/pkg/rules"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Rule IDs of the findings reported for coverage gaps and misconfigured
+// policies. The coverage gap rules declare the NoNetworkPolicy signal; the
+// NETPOL-POLICY- findings are about a policy, not a gap.
+const (
+	RuleNoDefaultDeny         = "NETPOL-NO-DEFAULT-DENY"
+	RulePodNoIngress          = "NETPOL-POD-NO-INGRESS"
+	RulePodUnrestrictedEgress = "NETPOL-POD-UNRESTRICTED-EGRESS"
+	RulePolicySelectsNothing  = "NETPOL-POLICY-SELECTS-NOTHING"
+	RulePolicyAllowsMetadata  = "NETPOL-POLICY-ALLOWS-METADATA"
+)
+
+// MetadataEndpoint is the link-local address cloud providers serve instance
+// metadata, including node credentials, on
+var MetadataEndpoint = netip.MustParseAddr("169.254.169.254")
+
+// metadataPort is the port the metadata endpoint listens on
+const metadataPort = 80
+
+// Snapshot holds the objects the coverage is computed from
+type Snapshot struct {
+	Pods     []corev1.Pod
+	Policies []networkingv1.NetworkPolicy
+	// Workloads are the ReplicaSets and Jobs the pods are attributed to
+	// through their controllers, so replicas are reported once
+	Workloads []runtime.Object
+}
+
+// Fetch lists the pods, NetworkPolicies, ReplicaSets and Jobs of a namespace ("" for all)
+func Fetch(ctx context.Context, clientset kubernetes.Interface, namespace string) (*Snapshot, error) {
+	opts := metav1.ListOptions{}
+	policies, err := clientset.NetworkingV1().NetworkPolicies(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list network policies: %w", err)
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	replicaSets, err := clientset.AppsV1().ReplicaSets(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicasets: %w", err)
+	}
+	jobs, err := clientset.BatchV1().Jobs(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	s := &Snapshot{Pods: pods.Items, Policies: policies.Items}
+	for i := range replicaSets.Items {
+		s.Workloads = append(s.Workloads, &replicaSets.Items[i])
+	}
+	for i := range jobs.Items {
+		s.Workloads = append(s.Workloads, &jobs.Items[i])
+	}
+	return s, nil
+}
+
+// policy is a NetworkPolicy with its parsed pod selector
+type policy struct {
+	*networkingv1.NetworkPolicy
+	selector labels.Selector
+	ingress  bool
+	egress   bool
+}
+
+// selects reports whether the policy applies to the pod
+func (p policy) selects(pod *corev1.Pod) bool {
+	return p.Namespace == pod.Namespace && p.selector.Matches(labels.Set(pod.Labels))
+}
+
+// defaultDeny reports whether the policy selects every pod of its namespace
+// and allows no traffic in the direction
+func (p policy) defaultDeny(ingress bool) bool {
+	if !p.selector.Empty() {
+		return false
+	}
+	if ingress {
+		return p.ingress && len(p.Spec.Ingress) == 0
+	}
+	return p.egress && len(p.Spec.Egress) == 0
+}
+
+// parsePolicy resolves the pod selector and the directions a policy
+// restricts. Without policyTypes a policy restricts ingress, and egress when
+// it has egress rules.
+func parsePolicy(np *networkingv1.NetworkPolicy) (policy, bool) {
+	selector, err := metav1.LabelSelectorAsSelector(&np.Spec.PodSelector)
+	if err != nil {
+		return policy{}, false
+	}
+	p := policy{NetworkPolicy: np, selector: selector}
+	if len(np.Spec.PolicyTypes) == 0 {
+		p.ingress, p.egress = true, len(np.Spec.Egress) > 0
+	}
+	for _, t := range np.Spec.PolicyTypes {
+		p.ingress = p.ingress || t == networkingv1.PolicyTypeIngress
+		p.egress = p.egress || t == networkingv1.PolicyTypeEgress
+	}
+	return p, true
+}
+
+// PolicyResource identifies a NetworkPolicy in findings
+func PolicyResource(np *networkingv1.NetworkPolicy) findings.Resource {
+	return findings.Resource{APIVersion: "networking.k8s.io/v1", Kind: "NetworkPolicy", Namespace: np.Namespace, Name: np.Name, Labels: np.Labels}
+}
+
+// NamespaceResource identifies a Namespace in findings
+func NamespaceResource(namespace string) findings.Resource {
+	return findings.Resource{APIVersion: "v1", Kind: "Namespace", Name: namespace}
+}
+
+// Analyze computes which pods the policies select for ingress and egress and
+// reports namespaces without a default deny, pods no policy restricts,
+// policies whose selectors match no pod and egress rules reaching the
+// metadata endpoint. Pods on the host network, which policies do not apply
+// to, and finished pods are left out. It returns the findings and the
+// resources evaluated.
+func (s *Snapshot) Analyze() ([]findings.Finding, []findings.Resource) {
+	owners := k8s.NewOwnerIndex()
+	for _, obj := range s.Workloads {
+		owners.Add(obj)
+	}
+
+	podsByNamespace := map[string][]*corev1.Pod{}
+	for i := range s.Pods {
+		pod := &s.Pods[i]
+		if pod.Spec.HostNetwork || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		podsByNamespace[pod.Namespace] = append(podsByNamespace[pod.Namespace], pod)
+	}
+	policiesByNamespace := map[string][]policy{}
+	for i := range s.Policies {
+		if p, ok := parsePolicy(&s.Policies[i]); ok {
+			policiesByNamespace[p.Namespace] = append(policiesByNamespace[p.Namespace], p)
+		}
+	}
+
+	var out []findings.Finding
+	var scanned []findings.Resource
+	namespaces := make([]string, 0, len(podsByNamespace))
+	for ns := range podsByNamespace {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	for _, ns := range namespaces {
+		scanned = append(scanned, NamespaceResource(ns))
+		if f, ok := checkDefaultDeny(ns, policiesByNamespace[ns]); ok {
+			out = append(out, f)
+		}
+
+		seen := map[string]bool{}
+		for _, pod := range podsByNamespace[ns] {
+			resource, owned := owners.Owner(pod.ObjectMeta)
+			if !owned {
+				resource = k8s.PodResource(pod)
+			}
+			if key := resource.Kind + "/" + resource.Name; !seen[key] {
+				seen[key] = true
+				scanned = append(scanned, resource)
+			}
+			out = append(out, checkPod(resource, pod, policiesByNamespace[ns])...)
+		}
+	}
+
+	for _, p := range sortedPolicies(policiesByNamespace) {
+		scanned = append(scanned, PolicyResource(p.NetworkPolicy))
+		out = append(out, checkSelectors(p, podsByNamespace[p.Namespace])...)
+		out = append(out, checkMetadataEgress(p)...)
+	}
+	return findings.Dedupe(out), scanned
+}
+
+func sortedPolicies(byNamespace map[string][]policy) []policy {
+	var out []policy
+	for _, list := range byNamespace {
+		out = append(out, list...)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Namespace != out[j].Namespace {
+			return out[i].Namespace < out[j].Namespace
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// checkDefaultDeny flags a namespace with no policy denying all ingress or egress by default
+func checkDefaultDeny(namespace string, policies []policy) (findings.Finding, bool) {
+	var ingress, egress bool
+	for _, p := range policies {
+		ingress = ingress || p.defaultDeny(true)
+		egress = egress || p.defaultDeny(false)
+	}
+	var missing []string
+	if !ingress {
+		missing = append(missing, "ingress")
+	}
+	if !egress {
+		missing = append(missing, "egress")
+	}
+	if len(missing) == 0 {
+		return findings.Finding{}, false
+	}
+	return rules.NewFinding(RuleNoDefaultDeny, NamespaceResource(namespace), rules.Violation{
+		Message:  fmt.Sprintf("namespace has no default deny NetworkPolicy for %s", strings.Join(missing, " and ")),
+		Evidence: []string{fmt.Sprintf("%d NetworkPolicies in namespace", len(policies))},
+	}), true
+}
+
+// checkPod flags a pod no policy selects for ingress, or for egress
+func checkPod(resource findings.Resource, pod *corev1.Pod, policies []policy) []findings.Finding {
+	var ingress, egress bool
+	for _, p := range policies {
+		if p.selects(pod) {
+			ingress = ingress || p.ingress
+			egress = egress || p.egress
+		}
+	}
+
+	var out []findings.Finding
+	if !ingress {
+		out = append(out, rules.NewFinding(RulePodNoIngress, resource, rules.Violation{
+			Message: "no NetworkPolicy restricts ingress, every pod in the cluster can connect",
+		}))
+	}
+	if !egress {
+		out = append(out, rules.NewFinding(RulePodUnrestrictedEgress, resource, rules.Violation{
+			Message: fmt.Sprintf("no NetworkPolicy restricts egress, including to the metadata endpoint %s", MetadataEndpoint),
+		}))
+	}
+	return out
+}
+
+// checkSelectors flags a policy whose pod selector, or whose peer pod
+// selectors in its own namespace, match no pod. Empty selectors, which match
+// every pod, are not flagged.
+func checkSelectors(p policy, pods []*corev1.Pod) []findings.Finding {
+	var out []findings.Finding
+	check := func(selector *metav1.LabelSelector, field string) {
+		if selector == nil || (len(selector.MatchLabels) == 0 && len(selector.MatchExpressions) == 0) {
+			return
+		}
+		parsed, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			return
+		}
+		for _, pod := range pods {
+			if parsed.Matches(labels.Set(pod.Labels)) {
+				return
+			}
+		}
+		out = append(out, rules.NewFinding(RulePolicySelectsNothing, PolicyResource(p.NetworkPolicy), rules.Violation{
+			Message:  fmt.Sprintf("%s %s matches no pods", field, metav1.FormatLabelSelector(selector)),
+			Evidence: []string{field},
+		}))
+	}
+
+	check(&p.Spec.PodSelector, "spec.podSelector")
+	for i, rule := range p.Spec.Ingress {
+		for j, peer := range rule.From {
+			if peer.NamespaceSelector == nil {
+				check(peer.PodSelector, fmt.Sprintf("spec.ingress[%d].from[%d].podSelector", i, j))
+			}
+		}
+	}
+	for i, rule := range p.Spec.Egress {
+		for j, peer := range rule.To {
+			if peer.NamespaceSelector == nil {
+				check(peer.PodSelector, fmt.Sprintf("spec.egress[%d].to[%d].podSelector", i, j))
+			}
+		}
+	}
+	return out
+}
+
+// checkMetadataEgress flags the egress rules of a policy that allow the
+// metadata endpoint, through an empty destination list or an ipBlock
+// containing it
+func checkMetadataEgress(p policy) []findings.Finding {
+	if !p.egress {
+		return nil
+	}
+	var out []findings.Finding
+	for i, rule := range p.Spec.Egress {
+		if !allowsMetadataPort(rule.Ports) {
+			continue
+		}
+		reason := ""
+		if len(rule.To) == 0 {
+			reason = "allows egress to every destination"
+		}
+		for _, peer := range rule.To {
+			if peer.IPBlock != nil && blockContains(peer.IPBlock, MetadataEndpoint) {
+				reason = fmt.Sprintf("allows egress to ipBlock %s", peer.IPBlock.CIDR)
+				break
+			}
+		}
+		if reason == "" {
+			continue
+		}
+		out = append(out, rules.NewFinding(RulePolicyAllowsMetadata, PolicyResource(p.NetworkPolicy), rules.Violation{
+			Message:  fmt.Sprintf("egress rule %d %s, including the metadata endpoint %s", i, reason, MetadataEndpoint),
+			Evidence: []string{fmt.Sprintf("spec.egress[%d]", i)},
+		}))
+	}
+	return out
+}
+
+// allowsMetadataPort reports whether the ports of a rule include TCP port 80
+func allowsMetadataPort(ports []networkingv1.NetworkPolicyPort) bool {
+	if len(ports) == 0 {
+		return true
+	}
+	for _, port := range ports {
+		if port.Protocol != nil && *port.Protocol != corev1.ProtocolTCP {
+			continue
+		}
+		if port.Port == nil {
+			return true
+		}
+		// Named ports only resolve on pods, never on an external address
+		if port.Port.Type == intstr.String {
+			continue
+		}
+		first, last := port.Port.IntVal, port.Port.IntVal
+		if port.EndPort != nil {
+			last = *port.EndPort
+		}
+		if first <= metadataPort && metadataPort <= last {
+			return true
+		}
+	}
+	return false
+}
+
+// blockContains reports whether an ipBlock allows an address
+func blockContains(block *networkingv1.IPBlock, addr netip.Addr) bool {
+	prefix, err := netip.ParsePrefix(block.CIDR)
+	if err != nil || !prefix.Contains(addr) {
+		return false
+	}
+	for _, except := range block.Except {
+		if p, err := netip.ParsePrefix(except); err == nil && p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+package netpol
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"kspm/pkg/findings"
+	"kspm/pkg/riskposture"
+	"kspm/pkg/rules"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func pod(namespace, name string, labels map[string]string, owner *metav1.OwnerReference) *corev1.Pod {
+	p := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels}}
+	if owner != nil {
+		p.OwnerReferences = []metav1.OwnerReference{*owner}
+	}
+	return p
+}
+
+func TestAnalyze(t *testing.T) {
+	controller := true
+	replicaSet := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "prod", Name: "web-abc",
+		OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &controller}},
+	}}
+	rsOwner := &metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-abc", Controller: &controller}
+	hostNetwork := pod("prod", "node-exporter", nil, nil)
+	hostNetwork.Spec.HostNetwork = true
+
+	https := intstr.FromInt32(443)
+	clientset := fake.NewSimpleClientset(
+		replicaSet,
+		pod("prod", "web-abc-1", map[string]string{"app": "web"}, rsOwner),
+		pod("prod", "web-abc-2", map[string]string{"app": "web"}, rsOwner),
+		pod("prod", "db", map[string]string{"app": "db"}, nil),
+		hostNetwork,
+		pod("secure", "api", map[string]string{"app": "api"}, nil),
+		&networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "db-ingress"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{From: []networkingv1.NetworkPolicyPeer{
+					{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+					{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "batch"}}},
+				}}},
+			},
+		},
+		&networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "web-egress"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+				Egress: []networkingv1.NetworkPolicyEgressRule{
+					{
+						To:    []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "0.0.0.0/0"}}},
+						Ports: []networkingv1.NetworkPolicyPort{{Port: &https}},
+					},
+					{To: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "169.254.0.0/16", Except: []string{"169.254.1.0/24"}}}}},
+				},
+			},
+		},
+		&networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "stale"},
+			Spec:       networkingv1.NetworkPolicySpec{PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "legacy"}}},
+		},
+		&networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "secure", Name: "default-deny"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			},
+		},
+	)
+
+	snapshot, err := Fetch(context.Background(), clientset, "")
+	require.NoError(t, err)
+	out, scanned := snapshot.Analyze()
+
+	var got []string
+	for _, f := range out {
+		got = append(got, fmt.Sprintf("%s %s: %s", f.RuleID, f.Resource, f.Message))
+		rule, ok := rules.Get(f.RuleID)
+		if assert.True(t, ok, "%s is defined in the rule registry", f.RuleID) {
+			assert.Equal(t, rule.Severity, f.Severity)
+			assert.Equal(t, rule.Remediation, f.Remediation)
+			assert.Contains(t, rule.Kinds, f.Resource.Kind)
+		}
+	}
+	assert.Equal(t, []string{
+		"NETPOL-NO-DEFAULT-DENY Namespace/prod: namespace has no default deny NetworkPolicy for ingress and egress",
+		"NETPOL-POD-UNRESTRICTED-EGRESS Pod/db (prod): no NetworkPolicy restricts egress, including to the metadata endpoint 169.254.169.254",
+		"NETPOL-POD-NO-INGRESS Deployment/web (prod): no NetworkPolicy restricts ingress, every pod in the cluster can connect",
+		"NETPOL-POLICY-SELECTS-NOTHING NetworkPolicy/db-ingress (prod): spec.ingress[0].from[1].podSelector app=batch matches no pods",
+		"NETPOL-POLICY-SELECTS-NOTHING NetworkPolicy/stale (prod): spec.podSelector app=legacy matches no pods",
+		"NETPOL-POLICY-ALLOWS-METADATA NetworkPolicy/web-egress (prod): egress rule 1 allows egress to ipBlock 169.254.0.0/16, including the metadata endpoint 169.254.169.254",
+	}, got, "replicas are reported once, host network pods and covered namespaces not at all")
+	assert.Len(t, scanned, 2+3+4, "namespaces, workloads and policies")
+}
+
+func TestAllowsMetadataPort(t *testing.T) {
+	http, dns := intstr.FromInt32(80), intstr.FromInt32(53)
+	named := intstr.FromString("http")
+	udp := corev1.ProtocolUDP
+	endPort := int32(8080)
+	low := intstr.FromInt32(1)
+
+	assert.True(t, allowsMetadataPort(nil))
+	assert.True(t, allowsMetadataPort([]networkingv1.NetworkPolicyPort{{Port: &http}}))
+	assert.True(t, allowsMetadataPort([]networkingv1.NetworkPolicyPort{{Port: &low, EndPort: &endPort}}))
+	assert.False(t, allowsMetadataPort([]networkingv1.NetworkPolicyPort{{Port: &dns}}))
+	assert.False(t, allowsMetadataPort([]networkingv1.NetworkPolicyPort{{Port: &http, Protocol: &udp}}))
+	assert.False(t, allowsMetadataPort([]networkingv1.NetworkPolicyPort{{Port: &named}}), "named ports never match an external address")
+}
+
+func TestRemediationsRenderNamespace(t *testing.T) {
+	posture := riskposture.NewRiskPosture(riskposture.SignalsFromFindings([]findings.Finding{
+		{RuleID: RuleNoDefaultDeny, Severity: findings.SeverityHigh, Resource: NamespaceResource("prod")},
+		{RuleID: RulePodNoIngress, Severity: findings.SeverityMedium, Resource: findings.Resource{Kind: "Deployment", Namespace: "prod", Name: "web"}},
+		{RuleID: RulePodUnrestrictedEgress, Severity: findings.SeverityMedium, Resource: findings.Resource{Kind: "Pod", Namespace: "batch", Name: "job"}},
+		{RuleID: RulePolicySelectsNothing, Severity: findings.SeverityLow, Resource: findings.Resource{Kind: "NetworkPolicy", Namespace: "legacy", Name: "stale"}},
+	}))
+
+	require.Len(t, posture.Signals, 1, "policies selecting nothing are low and raise no signal")
+	assert.Equal(t, riskposture.Signal{Name: "NoNetworkPolicy", Severity: findings.SeverityHigh, Weight: 20, Namespaces: []string{"prod", "batch"}}, posture.Signals[0])
+
+	fixes := posture.Remediations()
+	require.Len(t, fixes, 2, "one per namespace, policies selecting nothing need no default deny")
+	assert.Equal(t, "default-deny-netpol-batch", fixes[0].ID)
+	assert.Equal(t, "Namespace/prod", fixes[1].AppliesTo)
+	assert.Contains(t, fixes[1].YAML, "namespace: prod")
+	assert.NotContains(t, fixes[1].YAML, "{{NAMESPACE}}")
+}
+
+func TestSignalFollowsRule(t *testing.T) {
+	podGap := findings.Finding{RuleID: RulePodNoIngress, Severity: findings.SeverityMedium, Resource: findings.Resource{Kind: "Pod", Namespace: "prod", Name: "db"}}
+	signals := riskposture.SignalsFromFindings([]findings.Finding{podGap})
+	require.Len(t, signals, 1)
+	assert.Equal(t, riskposture.Signal{Name: "NoNetworkPolicy", Severity: findings.SeverityMedium, Weight: 10, Namespaces: []string{"prod"}}, signals[0])
+
+	original, ok := rules.Get(RulePodNoIngress)
+	require.True(t, ok)
+	t.Cleanup(func() { require.NoError(t, rules.SetWeight(original.ID, original.Weight)) })
+	require.NoError(t, rules.SetWeight(RulePodNoIngress, 35))
+	signals = riskposture.SignalsFromFindings([]findings.Finding{podGap})
+	assert.Equal(t, 35, signals[0].Weight, "the policy file weight is used")
+}
@@ -0,0 +1,65 @@
+package netpol
+
+import (
+	"kspm/pkg/findings"
+	"kspm/pkg/rules"
+)
+
+// workloadKinds are the kinds pods are reported as, through their controllers
+var workloadKinds = []string{"Pod", "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job", "CronJob"}
+
+// The findings are built from the whole snapshot, so the rules carry no Check
+func init() {
+	for _, rule := range netpolRules {
+		rules.MustDefine(rule)
+	}
+}
+
+var netpolRules = []rules.Rule{
+	{
+		ID:          RuleNoDefaultDeny,
+		Kinds:       []string{"Namespace"},
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryNetworkPolicy,
+		Description: "Namespace has no default deny NetworkPolicy",
+		Remediation: "Apply a NetworkPolicy with podSelector {} and no rules for the missing policy types",
+		Signal:      "NoNetworkPolicy",
+		Weight:      20,
+	},
+	{
+		ID:          RulePodNoIngress,
+		Kinds:       workloadKinds,
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryNetworkPolicy,
+		Description: "No NetworkPolicy restricts ingress to the pods",
+		Remediation: "Select the pods with a NetworkPolicy allowing only the expected ingress",
+		Signal:      "NoNetworkPolicy",
+		Weight:      10,
+	},
+	{
+		ID:          RulePodUnrestrictedEgress,
+		Kinds:       workloadKinds,
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryNetworkPolicy,
+		Description: "No NetworkPolicy restricts egress from the pods",
+		Remediation: "Select the pods with a NetworkPolicy allowing only the expected egress",
+		Signal:      "NoNetworkPolicy",
+		Weight:      10,
+	},
+	{
+		ID:          RulePolicySelectsNothing,
+		Kinds:       []string{"NetworkPolicy"},
+		Severity:    findings.SeverityLow,
+		Category:    findings.CategoryNetworkPolicy,
+		Description: "NetworkPolicy selector matches no pods",
+		Remediation: "Fix the selector labels or delete the stale NetworkPolicy",
+	},
+	{
+		ID:          RulePolicyAllowsMetadata,
+		Kinds:       []string{"NetworkPolicy"},
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryNetworkPolicy,
+		Description: "NetworkPolicy allows egress to the cloud metadata endpoint",
+		Remediation: "Add " + MetadataEndpoint.String() + "/32 to the ipBlock except list",
+	},
+}
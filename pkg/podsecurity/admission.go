@@ -0,0 +1,161 @@
+package podsecurity
+
+import (
+	"fmt"
+	"sort"
+
+	"kspm/pkg/findings"
+	"kspm/pkg/rules"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/pod-security-admission/api"
+)
+
+// Rule IDs of the Pod Security Admission readiness findings
+const (
+	RuleAdmissionRejected   = "PSA-REJECTED"
+	RuleAdmissionUnenforced = "PSA-UNENFORCED"
+)
+
+func init() {
+	rules.MustDefine(rules.Rule{
+		ID:          RuleAdmissionRejected,
+		Kinds:       workloadKinds,
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Workload would be rejected by the level its namespace enforces",
+		Remediation: "Fix the violated controls or lower the " + api.EnforceLevelLabel + " label of the namespace",
+	})
+	rules.MustDefine(rules.Rule{
+		ID:          RuleAdmissionUnenforced,
+		Kinds:       []string{"Namespace"},
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Namespace enforces a lower level than all of its workloads satisfy",
+		Remediation: "Raise the " + api.EnforceLevelLabel + " label of the namespace to the level its workloads satisfy",
+	})
+}
+
+// WorkloadResult is a workload and its evaluation
+type WorkloadResult struct {
+	Workload Workload
+	Result   Result
+}
+
+// NamespaceReadiness summarizes a namespace's Pod Security Admission labels
+// against the workloads running in it
+type NamespaceReadiness struct {
+	Namespace string
+	// Enforce, Audit and Warn are the configured levels; empty when unlabeled
+	Enforce api.Level
+	Audit   api.Level
+	Warn    api.Level
+	// Achievable is the most restrictive level every workload satisfies
+	Achievable api.Level
+	Workloads  []WorkloadResult
+}
+
+// Rejected returns the workloads a namespace enforcing level would reject
+func (n NamespaceReadiness) Rejected(level api.Level) []WorkloadResult {
+	var out []WorkloadResult
+	for _, w := range n.Workloads {
+		if !w.Result.Allows(level) {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// ReadyFor reports whether every workload would be admitted at level
+func (n NamespaceReadiness) ReadyFor(level api.Level) bool {
+	return api.CompareLevels(n.Achievable, level) >= 0
+}
+
+// EffectiveEnforce is the enforced level, privileged when unlabeled
+func (n NamespaceReadiness) EffectiveEnforce() api.Level {
+	if n.Enforce == "" {
+		return api.LevelPrivileged
+	}
+	return n.Enforce
+}
+
+// Readiness groups evaluated workloads by namespace and compares them with
+// each namespace's pod-security.kubernetes.io labels. Namespaces that only
+// appear through their workloads are treated as unlabeled.
+func Readiness(namespaces []corev1.Namespace, workloads []Workload) []NamespaceReadiness {
+	byName := map[string]*NamespaceReadiness{}
+	get := func(name string) *NamespaceReadiness {
+		if n, ok := byName[name]; ok {
+			return n
+		}
+		n := &NamespaceReadiness{Namespace: name, Achievable: api.LevelRestricted}
+		byName[name] = n
+		return n
+	}
+
+	for _, ns := range namespaces {
+		n := get(ns.Name)
+		n.Enforce = levelLabel(ns.Labels, api.EnforceLevelLabel)
+		n.Audit = levelLabel(ns.Labels, api.AuditLevelLabel)
+		n.Warn = levelLabel(ns.Labels, api.WarnLevelLabel)
+	}
+	for _, w := range workloads {
+		n := get(w.Resource.Namespace)
+		result := w.Evaluate()
+		n.Workloads = append(n.Workloads, WorkloadResult{Workload: w, Result: result})
+		if api.CompareLevels(result.Level, n.Achievable) < 0 {
+			n.Achievable = result.Level
+		}
+	}
+
+	out := make([]NamespaceReadiness, 0, len(byName))
+	for _, n := range byName {
+		out = append(out, *n)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Namespace < out[j].Namespace })
+	return out
+}
+
+// levelLabel reads a level label, ignoring missing or invalid values
+func levelLabel(labels map[string]string, key string) api.Level {
+	level, err := api.ParseLevel(labels[key])
+	if err != nil {
+		return ""
+	}
+	return level
+}
+
+// ReadinessFindings reports workloads the enforced level would reject and
+// namespaces that could enforce a stricter level than they do
+func ReadinessFindings(list []NamespaceReadiness) []findings.Finding {
+	var out []findings.Finding
+	for _, n := range list {
+		enforce := n.EffectiveEnforce()
+		for _, w := range n.Rejected(enforce) {
+			out = append(out, rules.NewFinding(RuleAdmissionRejected, w.Workload.Resource, rules.Violation{
+				Message:  fmt.Sprintf("would be rejected by namespace %s enforcing %s (satisfies %s)", n.Namespace, enforce, w.Result.Level),
+				Evidence: violationStrings(w.Result.ViolationsAt(enforce)),
+			}))
+		}
+		if api.CompareLevels(n.Achievable, enforce) > 0 {
+			evidence := fmt.Sprintf("%s=%s", api.EnforceLevelLabel, n.Enforce)
+			if n.Enforce == "" {
+				evidence = api.EnforceLevelLabel + " not set"
+			}
+			out = append(out, rules.NewFinding(RuleAdmissionUnenforced, findings.Resource{APIVersion: "v1", Kind: "Namespace", Name: n.Namespace}, rules.Violation{
+				Message:  fmt.Sprintf("enforces %s but every workload already satisfies %s", enforce, n.Achievable),
+				Evidence: []string{evidence},
+			}))
+		}
+	}
+	return out
+}
+
+// violationStrings renders violations as evidence lines
+func violationStrings(list []Violation) []string {
+	out := make([]string, 0, len(list))
+	for _, v := range list {
+		out = append(out, v.String())
+	}
+	return out
+}
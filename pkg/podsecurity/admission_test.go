@@ -0,0 +1,115 @@
+package podsecurity
+
+import (
+	"context"
+	"testing"
+
+	"kspm/pkg/rules"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/pod-security-admission/api"
+)
+
+func podIn(namespace, name string, spec corev1.PodSpec) Workload {
+	w, _ := WorkloadFromObject(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}, Spec: spec})
+	return w
+}
+
+func TestReadiness(t *testing.T) {
+	baselineSpec := restrictedSpec()
+	baselineSpec.SecurityContext = nil
+
+	hostSpec := restrictedSpec()
+	hostSpec.HostPID = true
+
+	namespaces := []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "apps", Labels: map[string]string{
+			api.EnforceLevelLabel: "baseline",
+			api.WarnLevelLabel:    "restricted",
+		}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "secure", Labels: map[string]string{api.EnforceLevelLabel: "restricted"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "empty"}},
+	}
+	workloads := []Workload{
+		podIn("apps", "web", restrictedSpec()),
+		podIn("apps", "legacy", baselineSpec),
+		podIn("secure", "agent", hostSpec),
+		podIn("unlabeled", "api", restrictedSpec()),
+	}
+
+	readiness := Readiness(namespaces, workloads)
+	require.Len(t, readiness, 4)
+	byName := map[string]NamespaceReadiness{}
+	for _, n := range readiness {
+		byName[n.Namespace] = n
+	}
+
+	apps := byName["apps"]
+	assert.Equal(t, api.LevelBaseline, apps.Enforce)
+	assert.Equal(t, api.LevelRestricted, apps.Warn)
+	assert.Equal(t, api.LevelBaseline, apps.Achievable)
+	assert.False(t, apps.ReadyFor(api.LevelRestricted))
+	require.Len(t, apps.Rejected(api.LevelRestricted), 1)
+	assert.Equal(t, "legacy", apps.Rejected(api.LevelRestricted)[0].Workload.Resource.Name)
+	assert.Empty(t, apps.Rejected(apps.EffectiveEnforce()))
+
+	assert.True(t, byName["empty"].ReadyFor(api.LevelRestricted), "namespaces without workloads are ready")
+	assert.Equal(t, api.LevelPrivileged, byName["unlabeled"].EffectiveEnforce())
+
+	byRule := map[string][]string{}
+	for _, f := range ReadinessFindings(readiness) {
+		byRule[f.RuleID] = append(byRule[f.RuleID], f.Resource.Kind+"/"+f.Resource.Name)
+	}
+	assert.Equal(t, []string{"Pod/agent"}, byRule[RuleAdmissionRejected])
+	assert.ElementsMatch(t, []string{"Namespace/empty", "Namespace/unlabeled"}, byRule[RuleAdmissionUnenforced])
+
+	for _, f := range ReadinessFindings(readiness) {
+		rule, ok := rules.Get(f.RuleID)
+		if assert.True(t, ok, "%s is defined in the rule registry", f.RuleID) {
+			assert.Equal(t, rule.Severity, f.Severity)
+			assert.Equal(t, rule.Remediation, f.Remediation)
+		}
+	}
+}
+
+func TestReadinessOperatorPods(t *testing.T) {
+	controller := true
+	baselineSpec := restrictedSpec()
+	baselineSpec.SecurityContext = nil
+	operatorPod := func(namespace string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "db-0", Namespace: namespace,
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "example.com/v1", Kind: "Database", Name: "db", Controller: &controller}},
+			},
+			Spec: baselineSpec,
+		}
+	}
+	clientset := fake.NewSimpleClientset(operatorPod("data"), operatorPod("secure"))
+	workloads, errs := ListWorkloads(context.Background(), clientset, "")
+	require.Empty(t, errs)
+
+	readiness := Readiness([]corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "data", Labels: map[string]string{api.EnforceLevelLabel: "baseline"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "secure", Labels: map[string]string{api.EnforceLevelLabel: "restricted"}}},
+	}, workloads)
+	require.Len(t, readiness, 2)
+	byName := map[string]NamespaceReadiness{}
+	for _, n := range readiness {
+		byName[n.Namespace] = n
+	}
+	assert.False(t, byName["data"].ReadyFor(api.LevelRestricted), "pods of unlisted controllers block the upgrade")
+	require.Len(t, byName["data"].Rejected(api.LevelRestricted), 1)
+	assert.Equal(t, "db-0", byName["data"].Rejected(api.LevelRestricted)[0].Workload.Resource.Name)
+
+	byRule := map[string][]string{}
+	for _, f := range ReadinessFindings(readiness) {
+		byRule[f.RuleID] = append(byRule[f.RuleID], f.Resource.Namespace+"/"+f.Resource.Name)
+	}
+	assert.Empty(t, byRule[RuleAdmissionUnenforced], "restricted is not suggested")
+	assert.Equal(t, []string{"secure/db-0"}, byRule[RuleAdmissionRejected])
+}
@@ -0,0 +1,149 @@
+// Package podsecurity evaluates pod specs against the upstream Pod Security
+// Standards (privileged, baseline and restricted) using the checks that back
+// Pod Security Admission.
+package podsecurity
+
+import (
+	"fmt"
+	"sort"
+
+	"kspm/pkg/findings"
+	"kspm/pkg/rules"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+	psapolicy "k8s.io/pod-security-admission/policy"
+)
+
+// Rule IDs of the findings reported for violated controls
+const (
+	RuleBaseline   = "PSS-BASELINE"
+	RuleRestricted = "PSS-RESTRICTED"
+)
+
+// workloadKinds are the kinds whose pod spec is evaluated
+var workloadKinds = []string{"Pod", "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job", "CronJob"}
+
+// The findings are built from the upstream checks, so the rules carry no Check
+func init() {
+	rules.MustDefine(rules.Rule{
+		ID:          RuleBaseline,
+		Kinds:       workloadKinds,
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Pod spec violates a baseline Pod Security Standards control",
+		Remediation: "Adjust the pod spec to meet the Pod Security Standards baseline profile",
+	})
+	rules.MustDefine(rules.Rule{
+		ID:          RuleRestricted,
+		Kinds:       workloadKinds,
+		Severity:    findings.SeverityMedium,
+		Category:    findings.CategoryPodSecurity,
+		Description: "Pod spec violates a restricted Pod Security Standards control",
+		Remediation: "Adjust the pod spec to meet the Pod Security Standards restricted profile",
+	})
+}
+
+// checks are the upstream controls, evaluated at their latest version
+var checks = psapolicy.DefaultChecks()
+
+// Violation is a single Pod Security Standards control the pod fails
+type Violation struct {
+	// Control is the upstream check ID, e.g. "hostNamespaces"
+	Control string
+	// Level is the profile the control belongs to
+	Level  api.Level
+	Reason string
+	Detail string
+}
+
+// String renders the violation as "control: reason (detail)"
+func (v Violation) String() string {
+	if v.Detail == "" {
+		return fmt.Sprintf("%s: %s", v.Control, v.Reason)
+	}
+	return fmt.Sprintf("%s: %s (%s)", v.Control, v.Reason, v.Detail)
+}
+
+// Result is the outcome of evaluating one pod spec
+type Result struct {
+	// Level is the most restrictive profile the pod satisfies
+	Level      api.Level
+	Violations []Violation
+}
+
+// Allows reports whether the pod would be admitted by a namespace enforcing level
+func (r Result) Allows(level api.Level) bool {
+	return api.CompareLevels(r.Level, level) >= 0
+}
+
+// ViolationsAt returns the violations that a namespace enforcing level rejects
+func (r Result) ViolationsAt(level api.Level) []Violation {
+	var out []Violation
+	for _, v := range r.Violations {
+		if api.CompareLevels(v.Level, level) <= 0 {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Evaluate runs every baseline and restricted control against a pod spec.
+// meta carries the annotations some controls read (e.g. AppArmor).
+func Evaluate(meta *metav1.ObjectMeta, spec *corev1.PodSpec) Result {
+	result := Result{Level: api.LevelRestricted}
+	for _, check := range checks {
+		if len(check.Versions) == 0 {
+			continue
+		}
+		latest := check.Versions[len(check.Versions)-1]
+		res := latest.CheckPod(meta, spec)
+		if res.Allowed {
+			continue
+		}
+
+		result.Violations = append(result.Violations, Violation{
+			Control: string(check.ID),
+			Level:   check.Level,
+			Reason:  res.ForbiddenReason,
+			Detail:  res.ForbiddenDetail,
+		})
+		switch check.Level {
+		case api.LevelBaseline:
+			result.Level = api.LevelPrivileged
+		case api.LevelRestricted:
+			if result.Level == api.LevelRestricted {
+				result.Level = api.LevelBaseline
+			}
+		}
+	}
+
+	// Baseline violations first, then by control
+	sort.SliceStable(result.Violations, func(i, j int) bool {
+		a, b := result.Violations[i], result.Violations[j]
+		if a.Level != b.Level {
+			return a.Level == api.LevelBaseline
+		}
+		return a.Control < b.Control
+	})
+	return result
+}
+
+// Findings converts violated controls into findings of the PSS rules.
+// Baseline violations, which block even the baseline profile, are reported as
+// RuleBaseline and restricted ones as RuleRestricted.
+func Findings(resource findings.Resource, result Result) []findings.Finding {
+	out := make([]findings.Finding, 0, len(result.Violations))
+	for _, v := range result.Violations {
+		id := RuleRestricted
+		if v.Level == api.LevelBaseline {
+			id = RuleBaseline
+		}
+		out = append(out, rules.NewFinding(id, resource, rules.Violation{
+			Message:  fmt.Sprintf("violates %s control %s", v.Level, v),
+			Evidence: []string{v.Control},
+		}))
+	}
+	return out
+}
@@ -0,0 +1,141 @@
+package podsecurity
+
+import (
+	"context"
+	"testing"
+
+	"kspm/pkg/findings"
+	"kspm/pkg/rules"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/pod-security-admission/api"
+)
+
+func restrictedSpec() corev1.PodSpec {
+	return corev1.PodSpec{
+		SecurityContext: &corev1.PodSecurityContext{
+			RunAsNonRoot:   ptr(true),
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		},
+		Containers: []corev1.Container{{
+			Name:  "app",
+			Image: "nginx:1.27",
+			SecurityContext: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: ptr(false),
+				Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+			},
+		}},
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func TestEvaluateLevels(t *testing.T) {
+	spec := restrictedSpec()
+	result := Evaluate(&metav1.ObjectMeta{}, &spec)
+	assert.Equal(t, api.LevelRestricted, result.Level)
+	assert.Empty(t, result.Violations)
+	assert.True(t, result.Allows(api.LevelRestricted))
+
+	// Missing seccomp and runAsNonRoot only break restricted
+	spec.SecurityContext = nil
+	result = Evaluate(&metav1.ObjectMeta{}, &spec)
+	assert.Equal(t, api.LevelBaseline, result.Level)
+	assert.False(t, result.Allows(api.LevelRestricted))
+	assert.True(t, result.Allows(api.LevelBaseline))
+	controls := map[string]api.Level{}
+	for _, v := range result.Violations {
+		controls[v.Control] = v.Level
+	}
+	assert.Equal(t, api.LevelRestricted, controls["runAsNonRoot"])
+	assert.Equal(t, api.LevelRestricted, controls["seccompProfile_restricted"])
+
+	// Host namespaces break baseline
+	spec.HostNetwork = true
+	spec.Containers[0].Ports = []corev1.ContainerPort{{ContainerPort: 80, HostPort: 80}}
+	result = Evaluate(&metav1.ObjectMeta{}, &spec)
+	assert.Equal(t, api.LevelPrivileged, result.Level)
+	require.NotEmpty(t, result.Violations)
+	assert.Equal(t, api.LevelBaseline, result.Violations[0].Level, "baseline violations sort first")
+	controls = map[string]api.Level{}
+	for _, v := range result.Violations {
+		controls[v.Control] = v.Level
+	}
+	assert.Contains(t, controls, "hostNamespaces")
+	assert.Contains(t, controls, "hostPorts")
+}
+
+func TestWorkloadFindings(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name:            "app",
+					Image:           "nginx:1.27",
+					SecurityContext: &corev1.SecurityContext{Privileged: ptr(true)},
+				}},
+			}},
+		},
+	}
+
+	w, ok := WorkloadFromObject(deployment)
+	require.True(t, ok)
+	assert.Equal(t, findings.Resource{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "prod", Name: "web"}, w.Resource)
+
+	result := w.Evaluate()
+	assert.Equal(t, api.LevelPrivileged, result.Level)
+
+	out := Findings(w.Resource, result)
+	require.Len(t, out, len(result.Violations))
+	assert.Equal(t, RuleBaseline, out[0].RuleID)
+	assert.Equal(t, findings.SeverityHigh, out[0].Severity)
+	assert.Equal(t, "Deployment", out[0].Resource.Kind)
+	assert.Contains(t, out[0].Message, "violates baseline control")
+	rule, ok := rules.Get(RuleBaseline)
+	require.True(t, ok, "defined so --disable-rules and the policy file accept it")
+	assert.Equal(t, out[0].Severity, rule.Severity)
+	assert.Equal(t, out[0].Remediation, rule.Remediation)
+
+	_, ok = WorkloadFromObject(&corev1.Secret{})
+	assert.False(t, ok)
+}
+
+func TestListWorkloadsSkipsControlledObjects(t *testing.T) {
+	controller := true
+	clientset := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "prod"}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "web-5d9c", Namespace: "prod",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &controller}},
+		}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name: "web-5d9c-abcde", Namespace: "prod",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-5d9c", Controller: &controller}},
+		}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "prod"}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "canary-7f8b", Namespace: "prod",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "canary", Controller: &controller}},
+		}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name: "db-0", Namespace: "prod",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "example.com/v1", Kind: "Database", Name: "db", Controller: &controller}},
+		}},
+	)
+
+	workloads, errs := ListWorkloads(context.Background(), clientset, "prod")
+	require.Empty(t, errs)
+
+	var names []string
+	for _, w := range workloads {
+		names = append(names, w.Resource.Kind+"/"+w.Resource.Name)
+	}
+	assert.ElementsMatch(t, []string{"Deployment/web", "Pod/debug", "ReplicaSet/canary-7f8b", "Pod/db-0"}, names,
+		"objects run by unlisted controllers are evaluated themselves")
+}
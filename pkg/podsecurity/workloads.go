@@ -0,0 +1,148 @@
+package podsecurity
+
+import (
+	"context"
+	"fmt"
+
+	"kspm/pkg/findings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Workload is an object that runs pods together with the pod spec it uses
+type Workload struct {
+	Resource findings.Resource
+	// PodMeta is the pod (or pod template) metadata
+	PodMeta metav1.ObjectMeta
+	PodSpec corev1.PodSpec
+}
+
+// Evaluate runs the Pod Security Standards against the workload's pod spec
+func (w Workload) Evaluate() Result {
+	return Evaluate(&w.PodMeta, &w.PodSpec)
+}
+
+// WorkloadFromObject extracts the pod spec of a Pod or workload controller
+func WorkloadFromObject(obj runtime.Object) (Workload, bool) {
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		return Workload{Resource: resource("v1", "Pod", o.ObjectMeta), PodMeta: o.ObjectMeta, PodSpec: o.Spec}, true
+	case *appsv1.Deployment:
+		return templateWorkload("apps/v1", "Deployment", o.ObjectMeta, o.Spec.Template), true
+	case *appsv1.StatefulSet:
+		return templateWorkload("apps/v1", "StatefulSet", o.ObjectMeta, o.Spec.Template), true
+	case *appsv1.DaemonSet:
+		return templateWorkload("apps/v1", "DaemonSet", o.ObjectMeta, o.Spec.Template), true
+	case *appsv1.ReplicaSet:
+		return templateWorkload("apps/v1", "ReplicaSet", o.ObjectMeta, o.Spec.Template), true
+	case *batchv1.Job:
+		return templateWorkload("batch/v1", "Job", o.ObjectMeta, o.Spec.Template), true
+	case *batchv1.CronJob:
+		return templateWorkload("batch/v1", "CronJob", o.ObjectMeta, o.Spec.JobTemplate.Spec.Template), true
+	}
+	return Workload{}, false
+}
+
+func templateWorkload(apiVersion, kind string, meta metav1.ObjectMeta, template corev1.PodTemplateSpec) Workload {
+	return Workload{Resource: resource(apiVersion, kind, meta), PodMeta: template.ObjectMeta, PodSpec: template.Spec}
+}
+
+func resource(apiVersion, kind string, meta metav1.ObjectMeta) findings.Resource {
+	return findings.Resource{APIVersion: apiVersion, Kind: kind, Namespace: meta.Namespace, Name: meta.Name, Labels: meta.Labels}
+}
+
+// listedControllers are the controller kinds ListWorkloads reports pod specs against
+var listedControllers = map[schema.GroupKind]bool{
+	{Group: "apps", Kind: "Deployment"}:  true,
+	{Group: "apps", Kind: "StatefulSet"}: true,
+	{Group: "apps", Kind: "DaemonSet"}:   true,
+	{Group: "apps", Kind: "ReplicaSet"}:  true,
+	{Group: "batch", Kind: "Job"}:        true,
+	{Group: "batch", Kind: "CronJob"}:    true,
+}
+
+// controlledByListed reports whether an object's controller is one of the
+// kinds ListWorkloads lists
+func controlledByListed(meta *metav1.ObjectMeta) bool {
+	ref := metav1.GetControllerOf(meta)
+	if ref == nil {
+		return false
+	}
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	return err == nil && listedControllers[schema.GroupKind{Group: gv.Group, Kind: ref.Kind}]
+}
+
+// ListWorkloads lists the pod-creating objects in a namespace ("" for all).
+// Pods, ReplicaSets and Jobs created by a listed controller are skipped so
+// each pod spec is reported once, against the object that owns it. Objects
+// run by other controllers, such as operators, are evaluated themselves.
+func ListWorkloads(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]Workload, []error) {
+	var out []Workload
+	var errs []error
+	add := func(obj runtime.Object, meta metav1.ObjectMeta) {
+		if controlledByListed(&meta) {
+			return
+		}
+		if w, ok := WorkloadFromObject(obj); ok {
+			out = append(out, w)
+		}
+	}
+	opts := metav1.ListOptions{}
+
+	if list, err := clientset.AppsV1().Deployments(namespace).List(ctx, opts); err != nil {
+		errs = append(errs, fmt.Errorf("failed to list deployments: %w", err))
+	} else {
+		for i := range list.Items {
+			add(&list.Items[i], list.Items[i].ObjectMeta)
+		}
+	}
+	if list, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, opts); err != nil {
+		errs = append(errs, fmt.Errorf("failed to list statefulsets: %w", err))
+	} else {
+		for i := range list.Items {
+			add(&list.Items[i], list.Items[i].ObjectMeta)
+		}
+	}
+	if list, err := clientset.AppsV1().DaemonSets(namespace).List(ctx, opts); err != nil {
+		errs = append(errs, fmt.Errorf("failed to list daemonsets: %w", err))
+	} else {
+		for i := range list.Items {
+			add(&list.Items[i], list.Items[i].ObjectMeta)
+		}
+	}
+	if list, err := clientset.AppsV1().ReplicaSets(namespace).List(ctx, opts); err != nil {
+		errs = append(errs, fmt.Errorf("failed to list replicasets: %w", err))
+	} else {
+		for i := range list.Items {
+			add(&list.Items[i], list.Items[i].ObjectMeta)
+		}
+	}
+	if list, err := clientset.BatchV1().CronJobs(namespace).List(ctx, opts); err != nil {
+		errs = append(errs, fmt.Errorf("failed to list cronjobs: %w", err))
+	} else {
+		for i := range list.Items {
+			add(&list.Items[i], list.Items[i].ObjectMeta)
+		}
+	}
+	if list, err := clientset.BatchV1().Jobs(namespace).List(ctx, opts); err != nil {
+		errs = append(errs, fmt.Errorf("failed to list jobs: %w", err))
+	} else {
+		for i := range list.Items {
+			add(&list.Items[i], list.Items[i].ObjectMeta)
+		}
+	}
+	if list, err := clientset.CoreV1().Pods(namespace).List(ctx, opts); err != nil {
+		errs = append(errs, fmt.Errorf("failed to list pods: %w", err))
+	} else {
+		for i := range list.Items {
+			add(&list.Items[i], list.Items[i].ObjectMeta)
+		}
+	}
+	return out, errs
+}
@@ -0,0 +1,164 @@
+// Package policy loads the per-cluster policy file that tunes rule severities,
+// signal weights and the thresholds used by the checks.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"kspm/pkg/controlchecks"
+	"kspm/pkg/entity"
+	"kspm/pkg/findings"
+	"kspm/pkg/k8s"
+	"kspm/pkg/rules"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultFile is read from the working directory when --policy is not set
+const DefaultFile = ".paranoia-policy.yaml"
+
+// RuleOverride changes the severity and/or signal weight of a rule
+type RuleOverride struct {
+	Severity string `json:"severity,omitempty"`
+	Weight   *int   `json:"weight,omitempty"`
+}
+
+// Policy is the tunable part of the checks. Lists that are set replace the
+// built-in defaults rather than extending them.
+type Policy struct {
+	// Rules maps a rule ID to its overrides
+	Rules                map[string]RuleOverride `json:"rules,omitempty"`
+	SensitiveHostPaths   []string                `json:"sensitiveHostPaths,omitempty"`
+	SensitiveSecretKeys  []string                `json:"sensitiveSecretKeys,omitempty"`
+	DangerousVerbs       []string                `json:"dangerousVerbs,omitempty"`
+	RequiredClusterRoles []string                `json:"requiredClusterRoles,omitempty"`
+	RequiredNodeCount    int                     `json:"requiredNodeCount,omitempty"`
+}
+
+// Default returns the built-in policy
+func Default() Policy {
+	return Policy{
+		SensitiveHostPaths:   k8s.DefaultSensitiveHostPaths,
+		SensitiveSecretKeys:  k8s.DefaultSensitiveSecretKeys,
+		DangerousVerbs:       entity.DefaultDangerousVerbs,
+		RequiredClusterRoles: controlchecks.DefaultRequiredClusterRoles,
+		RequiredNodeCount:    controlchecks.DefaultRequiredNodeCount,
+	}
+}
+
+// Load reads a policy file on top of the defaults
+func Load(file string) (Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return Policy{}, fmt.Errorf("read %s: %w", file, err)
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return Policy{}, fmt.Errorf("%s: %w", file, err)
+	}
+	return p, nil
+}
+
+// Parse decodes policy YAML on top of the defaults and validates it
+func Parse(data []byte) (Policy, error) {
+	var override Policy
+	if err := yaml.UnmarshalStrict(data, &override); err != nil {
+		return Policy{}, err
+	}
+
+	p := Default()
+	p.Rules = override.Rules
+	if override.SensitiveHostPaths != nil {
+		p.SensitiveHostPaths = override.SensitiveHostPaths
+	}
+	if override.SensitiveSecretKeys != nil {
+		p.SensitiveSecretKeys = override.SensitiveSecretKeys
+	}
+	if override.DangerousVerbs != nil {
+		p.DangerousVerbs = override.DangerousVerbs
+	}
+	if override.RequiredClusterRoles != nil {
+		p.RequiredClusterRoles = override.RequiredClusterRoles
+	}
+	if override.RequiredNodeCount != 0 {
+		p.RequiredNodeCount = override.RequiredNodeCount
+	}
+
+	if err := p.validate(); err != nil {
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+// validate checks rule IDs, severities and weights
+func (p Policy) validate() error {
+	var errs []error
+	for _, id := range p.ruleIDs() {
+		o := p.Rules[id]
+		if _, ok := rules.Get(id); !ok {
+			errs = append(errs, fmt.Errorf("rules: unknown rule %s", id))
+			continue
+		}
+		if o.Severity != "" {
+			if _, err := parseSeverity(o.Severity); err != nil {
+				errs = append(errs, fmt.Errorf("rules.%s: %w", id, err))
+			}
+		}
+		if o.Weight != nil && *o.Weight < 0 {
+			errs = append(errs, fmt.Errorf("rules.%s: weight must not be negative", id))
+		}
+	}
+	if p.RequiredNodeCount < 0 {
+		errs = append(errs, fmt.Errorf("requiredNodeCount must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// Apply pushes the rule overrides and lists into the checks
+func (p Policy) Apply() error {
+	for _, id := range p.ruleIDs() {
+		o := p.Rules[id]
+		if o.Severity != "" {
+			sev, err := parseSeverity(o.Severity)
+			if err != nil {
+				return fmt.Errorf("rules.%s: %w", id, err)
+			}
+			if err := rules.SetSeverity(id, sev); err != nil {
+				return err
+			}
+		}
+		if o.Weight != nil {
+			if err := rules.SetWeight(id, *o.Weight); err != nil {
+				return err
+			}
+		}
+	}
+	k8s.SetSensitiveHostPaths(p.SensitiveHostPaths)
+	k8s.SetSensitiveSecretKeys(p.SensitiveSecretKeys)
+	entity.SetDangerousVerbs(p.DangerousVerbs)
+	return nil
+}
+
+// ruleIDs returns the overridden rule IDs in a stable order
+func (p Policy) ruleIDs() []string {
+	ids := make([]string, 0, len(p.Rules))
+	for id := range p.Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// parseSeverity accepts only the canonical severity names
+func parseSeverity(s string) (findings.Severity, error) {
+	for _, sev := range findings.Severities {
+		if strings.EqualFold(strings.TrimSpace(s), string(sev)) {
+			return sev, nil
+		}
+	}
+	return "", fmt.Errorf("unknown severity %q", s)
+}
@@ -0,0 +1,109 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kspm/pkg/controlchecks"
+	"kspm/pkg/findings"
+	"kspm/pkg/k8s"
+	"kspm/pkg/riskposture"
+	"kspm/pkg/rules"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const prodPolicy = `
+rules:
+  POD-LATEST-TAG:
+    severity: high
+    weight: 12
+sensitiveHostPaths: ["/etc", "/opt/agent"]
+requiredNodeCount: 5
+`
+
+func TestParseMergesDefaults(t *testing.T) {
+	p, err := Parse([]byte(prodPolicy))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"/etc", "/opt/agent"}, p.SensitiveHostPaths)
+	assert.Equal(t, 5, p.RequiredNodeCount)
+	assert.Equal(t, controlchecks.DefaultRequiredClusterRoles, p.RequiredClusterRoles)
+	assert.Equal(t, k8s.DefaultSensitiveSecretKeys, p.SensitiveSecretKeys)
+}
+
+func TestParseValidation(t *testing.T) {
+	tests := map[string]string{
+		"unknown rule":     "rules:\n  NOPE: {severity: HIGH}\n",
+		"unknown severity": "rules:\n  POD-LATEST-TAG: {severity: SEVERE}\n",
+		"negative weight":  "rules:\n  POD-LATEST-TAG: {weight: -1}\n",
+		"unknown field":    "requiredNodes: 3\n",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	original, _ := rules.Get("POD-LATEST-TAG")
+	t.Cleanup(func() {
+		require.NoError(t, Default().Apply())
+		require.NoError(t, rules.SetSeverity(original.ID, original.Severity))
+		require.NoError(t, rules.SetWeight(original.ID, original.Weight))
+	})
+
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	require.NoError(t, os.WriteFile(path, []byte(prodPolicy), 0o600))
+	p, err := Load(path)
+	require.NoError(t, err)
+	require.NoError(t, p.Apply())
+
+	rule, _ := rules.Get("POD-LATEST-TAG")
+	assert.Equal(t, findings.SeverityHigh, rule.Severity)
+	assert.Equal(t, 12, rule.Weight)
+
+	k8s.SetSecurityEventHandler(&k8s.RecordingSecurityEventHandler{})
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "agent", Image: "agent"}},
+			Volumes: []corev1.Volume{{
+				Name:         "data",
+				VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/opt/agent/data"}},
+			}},
+		},
+	}
+	ids := map[string]findings.Severity{}
+	for _, f := range k8s.CheckPodSecurity(pod) {
+		ids[f.RuleID] = f.Severity
+	}
+	assert.Contains(t, ids, "POD-SENSITIVE-HOSTPATH")
+	assert.Equal(t, findings.SeverityHigh, ids["POD-LATEST-TAG"])
+}
+
+func TestApplyDefinedRule(t *testing.T) {
+	original, ok := rules.Get("CTRL-MISSING-CLUSTERROLE")
+	require.True(t, ok, "control checks are defined in the registry")
+	t.Cleanup(func() {
+		require.NoError(t, rules.SetSeverity(original.ID, original.Severity))
+		require.NoError(t, rules.SetWeight(original.ID, original.Weight))
+	})
+
+	p, err := Parse([]byte("rules:\n  CTRL-MISSING-CLUSTERROLE: {severity: critical, weight: 30}\n"))
+	require.NoError(t, err)
+	require.NoError(t, p.Apply())
+
+	applied := rules.Apply([]findings.Finding{{RuleID: "CTRL-MISSING-CLUSTERROLE", Severity: findings.SeverityHigh}})
+	require.Len(t, applied, 1)
+	assert.Equal(t, findings.SeverityCritical, applied[0].Severity)
+	signal, ok := riskposture.SignalForFinding(applied[0])
+	require.True(t, ok)
+	assert.Equal(t, riskposture.Signal{Name: "MissingRequiredClusterRole", Severity: findings.SeverityCritical, Weight: 30}, signal)
+}
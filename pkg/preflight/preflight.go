@@ -0,0 +1,262 @@
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"kspm/pkg/entity"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// Report sections a missing permission leaves incomplete
+const (
+	SectionPodSecurity     = "Pod Security"
+	SectionDeployments     = "Deployments"
+	SectionRBAC            = "RBAC"
+	SectionControlPlane    = "Control Plane"
+	SectionSecrets         = "Secrets"
+	SectionVulnerabilities = "Vulnerabilities"
+	SectionIdentity        = "Identity"
+	SectionNetworkPolicies = "Network Policies"
+)
+
+// TrivyGroupVersion is the API group of the Trivy operator CRDs
+const TrivyGroupVersion = "aquasecurity.github.io/v1alpha1"
+
+const trivyGroup = "aquasecurity.github.io"
+
+// TrivyResources lists the Trivy operator CRDs Paranoia reads
+var TrivyResources = []string{"vulnerabilityreports"}
+
+// Requirement is a permission a command needs and the section of its output
+// that is missing without it
+type Requirement struct {
+	Section string                 `json:"section"`
+	Request entity.ResourceRequest `json:"request"`
+	// Namespaced requirements are checked in the namespace the command
+	// targets, the others cluster-wide
+	Namespaced bool `json:"namespaced,omitempty"`
+}
+
+// Command lists the permissions one paranoia command needs
+type Command struct {
+	Name         string
+	Requirements []Requirement
+}
+
+func require(section string, verbs []string, group string, resources ...string) []Requirement {
+	var out []Requirement
+	for _, resource := range resources {
+		for _, verb := range verbs {
+			out = append(out, Requirement{Section: section, Request: entity.ResourceRequest{Verb: verb, APIGroup: group, Resource: resource}})
+		}
+	}
+	return out
+}
+
+func namespaced(list []Requirement) []Requirement {
+	out := make([]Requirement, len(list))
+	for i, r := range list {
+		r.Namespaced = true
+		out[i] = r
+	}
+	return out
+}
+
+func concat(lists ...[]Requirement) []Requirement {
+	var out []Requirement
+	for _, list := range lists {
+		out = append(out, list...)
+	}
+	return out
+}
+
+var (
+	listVerbs  = []string{"list"}
+	watchVerbs = []string{"list", "watch"}
+
+	workloadReads = concat(
+		require(SectionPodSecurity, listVerbs, "", "pods"),
+		require(SectionDeployments, listVerbs, "apps", "deployments", "statefulsets", "daemonsets", "replicasets"),
+		require(SectionDeployments, listVerbs, "batch", "jobs", "cronjobs"),
+	)
+	rbacReads = concat(
+		require(SectionRBAC, listVerbs, "rbac.authorization.k8s.io", "roles", "clusterroles", "rolebindings", "clusterrolebindings"),
+		require(SectionRBAC, listVerbs, "", "serviceaccounts"),
+	)
+	netpolReads = concat(
+		require(SectionNetworkPolicies, listVerbs, "networking.k8s.io", "networkpolicies"),
+		require(SectionNetworkPolicies, listVerbs, "", "pods"),
+		// Owner attribution of the pods
+		require(SectionNetworkPolicies, listVerbs, "apps", "replicasets"),
+		require(SectionNetworkPolicies, listVerbs, "batch", "jobs"),
+	)
+	vulnerabilityReads = namespaced(require(SectionVulnerabilities, listVerbs, trivyGroup, TrivyResources...))
+)
+
+// Commands lists the permissions of every command that reads the cluster
+var Commands = []Command{
+	{Name: "watch", Requirements: concat(
+		require(SectionPodSecurity, watchVerbs, "", "pods"),
+		require(SectionDeployments, watchVerbs, "apps", "deployments", "statefulsets", "daemonsets", "replicasets"),
+		require(SectionDeployments, watchVerbs, "batch", "jobs", "cronjobs"),
+		// Owner attribution of watched pods
+		require(SectionPodSecurity, []string{"get"}, "apps", "replicasets"),
+		require(SectionPodSecurity, []string{"get"}, "batch", "jobs"),
+		require(SectionSecrets, watchVerbs, "", "secrets"),
+		require(SectionRBAC, watchVerbs, "rbac.authorization.k8s.io", "clusterroles"),
+	)},
+	{Name: "check", Requirements: concat(
+		require(SectionControlPlane, []string{"get"}, "rbac.authorization.k8s.io", "clusterroles"),
+		require(SectionControlPlane, listVerbs, "", "pods", "nodes"),
+	)},
+	{Name: "deployment", Requirements: require(SectionDeployments, listVerbs, "apps", "deployments")},
+	{Name: "rbac", Requirements: rbacReads},
+	{Name: "rbac subjects", Requirements: rbacReads},
+	{Name: "rbac unused", Requirements: rbacReads},
+	{Name: "who-can", Requirements: rbacReads},
+	{Name: "whoami", Requirements: require(SectionIdentity, []string{"create"}, "authorization.k8s.io", "selfsubjectaccessreviews", "selfsubjectrulesreviews")},
+	{Name: "pss", Requirements: namespaced(workloadReads)},
+	{Name: "psa", Requirements: concat(
+		require(SectionPodSecurity, listVerbs, "", "namespaces"),
+		namespaced(workloadReads),
+	)},
+	{Name: "netpol", Requirements: namespaced(netpolReads)},
+	{Name: "report", Requirements: vulnerabilityReads},
+	{Name: "report-html", Requirements: concat(
+		workloadReads,
+		rbacReads,
+		require(SectionControlPlane, []string{"get"}, "rbac.authorization.k8s.io", "clusterroles"),
+		require(SectionSecrets, listVerbs, "", "secrets"),
+		require(SectionNetworkPolicies, listVerbs, "networking.k8s.io", "networkpolicies"),
+		vulnerabilityReads,
+	)},
+}
+
+// CommandByName returns the requirements of a command
+func CommandByName(name string) (Command, bool) {
+	for _, c := range Commands {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Command{}, false
+}
+
+// Check is the outcome of one requirement
+type Check struct {
+	Requirement
+	Allowed bool `json:"allowed"`
+	// Reason explains a failed check when it is not a plain RBAC denial
+	Reason string `json:"reason,omitempty"`
+}
+
+// Readiness is the outcome of every requirement of a command
+type Readiness struct {
+	Command string  `json:"command"`
+	Checks  []Check `json:"checks"`
+}
+
+// Ready reports whether every requirement is met
+func (r Readiness) Ready() bool {
+	return len(r.Missing()) == 0
+}
+
+// Missing returns the failed checks
+func (r Readiness) Missing() []Check {
+	var out []Check
+	for _, c := range r.Checks {
+		if !c.Allowed {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// IncompleteSections lists, sorted, the sections a failed check leaves incomplete
+func (r Readiness) IncompleteSections() []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, c := range r.Missing() {
+		if !seen[c.Section] {
+			seen[c.Section] = true
+			out = append(out, c.Section)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// CRDStatus reports whether a CRD Paranoia reads is served
+type CRDStatus struct {
+	GroupVersion string `json:"groupVersion"`
+	Resource     string `json:"resource"`
+	Installed    bool   `json:"installed"`
+}
+
+// Report is the outcome of the preflight checks
+type Report struct {
+	Namespace string      `json:"namespace,omitempty"`
+	CRDs      []CRDStatus `json:"crds"`
+	Commands  []Readiness `json:"commands"`
+}
+
+// Run checks through SelfSubjectAccessReviews every permission the commands
+// need, and through discovery whether the Trivy operator CRDs are served.
+// Requirements on a CRD that is not installed fail even when RBAC allows
+// them. Namespaced requirements are checked in namespace, or cluster-wide
+// when it is empty.
+func Run(ctx context.Context, clientset kubernetes.Interface, namespace string, commands []Command) (*Report, error) {
+	report := &Report{Namespace: namespace, CRDs: TrivyCRDs(clientset)}
+	installed := map[string]bool{}
+	for _, crd := range report.CRDs {
+		installed[crd.Resource] = crd.Installed
+	}
+
+	allowed := map[string]bool{}
+	for _, command := range commands {
+		readiness := Readiness{Command: command.Name}
+		for _, req := range command.Requirements {
+			ns := ""
+			if req.Namespaced {
+				ns = namespace
+			}
+			key := ns + "\x00" + req.Request.String()
+			ok, cached := allowed[key]
+			if !cached {
+				var err error
+				if ok, err = entity.CheckAccess(ctx, clientset, req.Request, ns); err != nil {
+					return nil, fmt.Errorf("failed to check %s: %w", command.Name, err)
+				}
+				allowed[key] = ok
+			}
+
+			check := Check{Requirement: req, Allowed: ok}
+			if req.Request.APIGroup == trivyGroup && !installed[req.Request.Resource] {
+				check.Allowed = false
+				check.Reason = "Trivy operator CRD not installed"
+			}
+			readiness.Checks = append(readiness.Checks, check)
+		}
+		report.Commands = append(report.Commands, readiness)
+	}
+	return report, nil
+}
+
+// TrivyCRDs reports which Trivy operator CRDs the API server serves
+func TrivyCRDs(clientset kubernetes.Interface) []CRDStatus {
+	served := map[string]bool{}
+	if list, err := clientset.Discovery().ServerResourcesForGroupVersion(TrivyGroupVersion); err == nil {
+		for _, r := range list.APIResources {
+			served[r.Name] = true
+		}
+	}
+
+	var out []CRDStatus
+	for _, resource := range TrivyResources {
+		out = append(out, CRDStatus{GroupVersion: TrivyGroupVersion, Resource: resource, Installed: served[resource]})
+	}
+	return out
+}
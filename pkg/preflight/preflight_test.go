@@ -0,0 +1,68 @@
+package preflight
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// accessClient allows every SelfSubjectAccessReview except those on the
+// denied resources, and counts the reviews
+func accessClient(reviews *int, denied ...string) *fake.Clientset {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		*reviews++
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = true
+		for _, resource := range denied {
+			if review.Spec.ResourceAttributes.Resource == resource {
+				review.Status.Allowed = false
+			}
+		}
+		return true, review, nil
+	})
+	return clientset
+}
+
+func TestRunReportsIncompleteSections(t *testing.T) {
+	var reviews int
+	clientset := accessClient(&reviews, "secrets")
+	command, ok := CommandByName("report-html")
+	assert.True(t, ok)
+
+	report, err := Run(context.Background(), clientset, "prod", []Command{command, command})
+	assert.NoError(t, err)
+	assert.Equal(t, len(command.Requirements), reviews, "reviews are cached across commands")
+	assert.Equal(t, []CRDStatus{{GroupVersion: TrivyGroupVersion, Resource: "vulnerabilityreports"}}, report.CRDs)
+
+	readiness := report.Commands[0]
+	assert.False(t, readiness.Ready())
+	assert.Equal(t, []string{SectionSecrets, SectionVulnerabilities}, readiness.IncompleteSections())
+	for _, c := range readiness.Missing() {
+		if c.Section == SectionVulnerabilities {
+			assert.Equal(t, "Trivy operator CRD not installed", c.Reason)
+		}
+	}
+}
+
+func TestRunWithTrivyInstalled(t *testing.T) {
+	var reviews int
+	clientset := accessClient(&reviews)
+	clientset.Resources = []*metav1.APIResourceList{{
+		GroupVersion: TrivyGroupVersion,
+		APIResources: []metav1.APIResource{{Name: "vulnerabilityreports", Namespaced: true, Kind: "VulnerabilityReport"}},
+	}}
+
+	report, err := Run(context.Background(), clientset, "", Commands)
+	assert.NoError(t, err)
+	assert.True(t, report.CRDs[0].Installed)
+	for _, readiness := range report.Commands {
+		assert.True(t, readiness.Ready(), readiness.Command)
+	}
+}
@@ -0,0 +1,42 @@
+package reports
+
+import (
+	"fmt"
+	"kspm/pkg/findings"
+)
+
+// Gate is the CI pass/fail policy applied to a report
+type Gate struct {
+	// FailOn fails the gate when any finding is at or above this severity;
+	// empty disables the check
+	FailOn findings.Severity
+	// MaxScore fails the gate when the risk score exceeds it; negative disables the check
+	MaxScore int
+}
+
+// Enabled reports whether any threshold is set
+func (g Gate) Enabled() bool {
+	return g.FailOn != "" || g.MaxScore >= 0
+}
+
+// Evaluate returns the reasons the view fails the gate, or nil when it passes
+func (g Gate) Evaluate(view ReportView) []string {
+	var reasons []string
+
+	if g.FailOn != "" {
+		count := 0
+		for _, f := range view.Findings {
+			if f.Severity.AtLeast(g.FailOn) {
+				count++
+			}
+		}
+		if count > 0 {
+			reasons = append(reasons, fmt.Sprintf("%d finding(s) at or above %s", count, g.FailOn))
+		}
+	}
+
+	if g.MaxScore >= 0 && view.RiskScore > g.MaxScore {
+		reasons = append(reasons, fmt.Sprintf("risk score %d exceeds maximum %d", view.RiskScore, g.MaxScore))
+	}
+	return reasons
+}
@@ -0,0 +1,27 @@
+package reports
+
+import (
+	"kspm/pkg/findings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGateEvaluate(t *testing.T) {
+	view := BuildReportView("Test Report", []findings.Finding{
+		{RuleID: "A", Severity: findings.SeverityMedium},
+		{RuleID: "B", Severity: "HIGH"},
+	})
+	view.RiskScore = 40
+
+	assert.False(t, Gate{MaxScore: -1}.Enabled())
+	assert.Empty(t, Gate{MaxScore: -1}.Evaluate(view))
+
+	assert.Empty(t, Gate{FailOn: findings.SeverityCritical, MaxScore: -1}.Evaluate(view))
+	assert.Len(t, Gate{FailOn: findings.SeverityHigh, MaxScore: -1}.Evaluate(view), 1)
+	assert.Contains(t, Gate{FailOn: findings.SeverityMedium, MaxScore: -1}.Evaluate(view)[0], "2 finding(s)")
+
+	assert.Empty(t, Gate{MaxScore: 40}.Evaluate(view))
+	assert.Len(t, Gate{MaxScore: 39}.Evaluate(view), 1)
+	assert.Len(t, Gate{FailOn: findings.SeverityLow, MaxScore: 0}.Evaluate(view), 2)
+}
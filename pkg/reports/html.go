@@ -5,6 +5,7 @@ import (
 	"embed"
 	"fmt"
 	"html/template"
+	"kspm/pkg/findings"
 	"net/http"
 	"os"
 	"sort"
@@ -41,31 +42,20 @@ func validateEmbeddedTemplates() error {
 //go:embed templates/index.html
 var templatesFS embed.FS
 
+// Finding is the view model for a single finding row
 type Finding struct {
-	Raw      string
-	Severity string // e.g. "CRITICAL", "HIGH", "MEDIUM", "LOW", "INFO"
+	findings.Finding
+	Raw      string // rendered line shown in the report
 	BadgeCls string // CSS class for badge
 }
 
-type HTMLView struct {
-	Title       string
-	GeneratedAt string
-	Counts      map[string]int
-	Total       int
-	Findings    []Finding
-}
-
-// ParseSeverity tries to infer severity from strings like: "[HIGH] ..."
+// ParseSeverity tries to infer severity from legacy strings like: "[HIGH] ..."
 // Falls back to INFO.
 func ParseSeverity(s string) string {
 	s = strings.TrimSpace(s)
 	if strings.HasPrefix(s, "[") {
 		if end := strings.Index(s, "]"); end > 1 {
-			sev := strings.ToUpper(strings.TrimSpace(s[1:end]))
-			switch sev {
-			case "CRITICAL", "HIGH", "MEDIUM", "LOW", "INFO":
-				return sev
-			}
+			return string(findings.ParseSeverity(s[1:end]))
 		}
 	}
 	// If you sometimes use "Vulnerability:" lines, treat as HIGH by default (tweak if you want)
@@ -75,49 +65,56 @@ func ParseSeverity(s string) string {
 	return "INFO"
 }
 
-func badgeClass(sev string) string {
+func badgeClass(sev findings.Severity) string {
 	switch sev {
-	case "CRITICAL":
+	case findings.SeverityCritical:
 		return "badge critical"
-	case "HIGH":
+	case findings.SeverityHigh:
 		return "badge high"
-	case "MEDIUM":
+	case findings.SeverityMedium:
 		return "badge medium"
-	case "LOW":
+	case findings.SeverityLow:
 		return "badge low"
 	default:
 		return "badge info"
 	}
 }
 
-// Add helper for new views
-func BuildReportView(title string, rawFindings []string) ReportView {
-	hv := buildView(title, rawFindings)
-	return ReportView{
-		Title:       hv.Title,
-		GeneratedAt: hv.GeneratedAt,
-		Counts:      hv.Counts,
-		Total:       hv.Total,
-		Findings:    hv.Findings,
+// newFinding wraps a typed finding for rendering
+func newFinding(f findings.Finding) Finding {
+	f.Severity = findings.ParseSeverity(string(f.Severity))
+	return Finding{
+		Finding:  f,
+		Raw:      f.String(),
+		BadgeCls: badgeClass(f.Severity),
 	}
 }
 
-// Helper to convert raw findings
-// Helper to convert raw finding strings to Finding structs
-func CategorizeFindings(rawFindings []string) []Finding {
-	out := make([]Finding, 0, len(rawFindings))
-	for _, f := range rawFindings {
-		sev := ParseSeverity(f)
-		out = append(out, Finding{
-			Raw:      f,
-			Severity: sev,
-			BadgeCls: badgeClass(sev),
-		})
+// legacyFinding wraps a pre-formatted finding string for rendering
+func legacyFinding(raw string) Finding {
+	sev := findings.Severity(ParseSeverity(raw))
+	return Finding{
+		Finding:  findings.Finding{Severity: sev, Message: raw},
+		Raw:      raw,
+		BadgeCls: badgeClass(sev),
+	}
+}
+
+// BuildReportView builds the report view from typed findings
+func BuildReportView(title string, list []findings.Finding) ReportView {
+	return buildView(title, CategorizeFindings(list))
+}
+
+// CategorizeFindings converts typed findings to view rows
+func CategorizeFindings(list []findings.Finding) []Finding {
+	out := make([]Finding, 0, len(list))
+	for _, f := range list {
+		out = append(out, newFinding(f))
 	}
 	return out
 }
 
-func buildView(title string, findings []string) HTMLView {
+func buildView(title string, out []Finding) ReportView {
 	counts := map[string]int{
 		"CRITICAL": 0,
 		"HIGH":     0,
@@ -125,25 +122,16 @@ func buildView(title string, findings []string) HTMLView {
 		"LOW":      0,
 		"INFO":     0,
 	}
-
-	out := make([]Finding, 0, len(findings))
-	for _, f := range findings {
-		sev := ParseSeverity(f)
-		counts[sev]++
-		out = append(out, Finding{
-			Raw:      f,
-			Severity: sev,
-			BadgeCls: badgeClass(sev),
-		})
+	for _, f := range out {
+		counts[string(f.Severity)]++
 	}
 
 	// Nice default ordering: CRITICAL -> HIGH -> MEDIUM -> LOW -> INFO
-	rank := map[string]int{"CRITICAL": 0, "HIGH": 1, "MEDIUM": 2, "LOW": 3, "INFO": 4}
 	sort.SliceStable(out, func(i, j int) bool {
-		ri := rank[out[i].Severity]
-		rj := rank[out[j].Severity]
+		ri := out[i].Severity.Rank()
+		rj := out[j].Severity.Rank()
 		if ri != rj {
-			return ri < rj
+			return ri > rj
 		}
 		return out[i].Raw < out[j].Raw
 	})
@@ -153,7 +141,7 @@ func buildView(title string, findings []string) HTMLView {
 		total += v
 	}
 
-	return HTMLView{
+	return ReportView{
 		Title:       title,
 		GeneratedAt: time.Now().Format(time.RFC1123),
 		Counts:      counts,
@@ -162,10 +150,10 @@ func buildView(title string, findings []string) HTMLView {
 	}
 }
 
-func GenerateHTMLReport(title string, findings []string, outputPath string) error {
+func parseReportTemplate() (*template.Template, error) {
 	tplBytes, err := templatesFS.ReadFile("templates/index.html")
 	if err != nil {
-		return fmt.Errorf("read template: %w", err)
+		return nil, fmt.Errorf("read template: %w", err)
 	}
 
 	tpl, err := template.New("report").Funcs(template.FuncMap{
@@ -176,41 +164,27 @@ func GenerateHTMLReport(title string, findings []string, outputPath string) erro
 			}
 			return int(float64(part) / float64(total) * 100.0)
 		},
+		"lower": strings.ToLower,
 	}).Parse(string(tplBytes))
 	if err != nil {
-		return fmt.Errorf("parse template: %w", err)
+		return nil, fmt.Errorf("parse template: %w", err)
 	}
+	return tpl, nil
+}
 
-	f, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("create report file: %w", err)
-	}
-	defer f.Close()
-
-	view := buildView(title, findings)
-	if err := tpl.Execute(f, view); err != nil {
-		return fmt.Errorf("render template: %w", err)
+// GenerateHTMLReport renders pre-formatted finding strings
+func GenerateHTMLReport(title string, rawFindings []string, outputPath string) error {
+	out := make([]Finding, 0, len(rawFindings))
+	for _, raw := range rawFindings {
+		out = append(out, legacyFinding(raw))
 	}
-	return nil
+	return GenerateHTMLReportView(buildView(title, out), outputPath)
 }
 
 func GenerateHTMLReportView(view ReportView, outputPath string) error {
-	tplBytes, err := templatesFS.ReadFile("templates/index.html")
+	tpl, err := parseReportTemplate()
 	if err != nil {
-		return fmt.Errorf("read template: %w", err)
-	}
-
-	tpl, err := template.New("report").Funcs(template.FuncMap{
-		"pct": func(part, total int) int {
-			if total <= 0 {
-				return 0
-			}
-			return int(float64(part) / float64(total) * 100.0)
-		},
-		"lower": strings.ToLower,
-	}).Parse(string(tplBytes))
-	if err != nil {
-		return fmt.Errorf("parse template: %w", err)
+		return err
 	}
 
 	f, err := os.Create(outputPath)
@@ -33,7 +33,7 @@ func TestGenerateHTMLReport(t *testing.T) {
 	assert.Contains(t, contentStr, title)
 	assert.Contains(t, contentStr, "Finding 1")
 	assert.Contains(t, contentStr, "Finding 2")
-	assert.Contains(t, contentStr, "<html>")
+	assert.Contains(t, contentStr, `<html lang="en">`)
 	assert.Contains(t, contentStr, "</html>")
 }
 
@@ -52,3 +52,17 @@ func TestGenerateHTMLReportEmptyFindings(t *testing.T) {
 
 	assert.Contains(t, string(content), title)
 }
+
+func TestGenerateHTMLReportViewIncompleteSections(t *testing.T) {
+	outputPath := "incomplete-report.html"
+	defer os.Remove(outputPath)
+
+	view := BuildPostureView("Partial Report", nil, nil)
+	view.IncompleteSections = []IncompleteSection{{Section: "Secrets", Missing: []string{"list secrets"}}}
+	require.NoError(t, GenerateHTMLReportView(view, outputPath))
+
+	content, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Incomplete Sections")
+	assert.Contains(t, string(content), "list secrets")
+}
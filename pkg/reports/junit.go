@@ -0,0 +1,193 @@
+package reports
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"kspm/pkg/findings"
+	"kspm/pkg/rules"
+	"sort"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// junitCase collects the findings of one rule against one resource
+type junitCase struct {
+	ruleID   string
+	category string
+	resource findings.Resource
+	findings []findings.Finding
+}
+
+// junitCaseName renders the resource as namespace/kind/name
+func junitCaseName(r findings.Resource) string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s/%s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", r.Namespace, r.Kind, r.Name)
+}
+
+// failure renders the collected findings as a JUnit failure
+func (c *junitCase) failure() *junitFailure {
+	if len(c.findings) == 0 {
+		return nil
+	}
+
+	sev := findings.SeverityInfo
+	var body strings.Builder
+	for _, f := range c.findings {
+		fs := findings.ParseSeverity(string(f.Severity))
+		if fs.Rank() > sev.Rank() {
+			sev = fs
+		}
+		body.WriteString(f.String())
+		body.WriteString("\n")
+		for _, e := range f.Evidence {
+			fmt.Fprintf(&body, "  evidence: %s\n", e)
+		}
+	}
+	if r := c.findings[0].Remediation; r != "" {
+		fmt.Fprintf(&body, "remediation: %s\n", r)
+	}
+
+	message := c.findings[0].Message
+	if len(c.findings) > 1 {
+		message = fmt.Sprintf("%s (and %d more)", message, len(c.findings)-1)
+	}
+	return &junitFailure{Message: message, Type: string(sev), Body: body.String()}
+}
+
+// templateKinds are the workloads the pod rules are evaluated against through
+// their pod template, with the findings attributed to the workload
+var templateKinds = map[string]bool{
+	"Deployment":  true,
+	"StatefulSet": true,
+	"DaemonSet":   true,
+	"ReplicaSet":  true,
+	"Job":         true,
+	"CronJob":     true,
+}
+
+// applicableRules returns the rules a scanned resource can fail
+func applicableRules(registry *rules.Registry, kind string) []rules.Rule {
+	out := registry.Applicable(kind)
+	if templateKinds[kind] {
+		out = append(out, registry.Applicable("Pod")...)
+	}
+	return out
+}
+
+// newJUnitReport builds one test case per rule and resource: every enabled
+// rule of the registry that applies to a scanned resource passes unless it
+// produced findings, so the cases are the same from run to run
+func newJUnitReport(view ReportView, registry *rules.Registry) junitTestSuites {
+	cases := map[string]*junitCase{}
+	var order []string
+
+	get := func(ruleID, category string, resource findings.Resource) *junitCase {
+		key := ruleID + "|" + junitCaseName(resource)
+		c, ok := cases[key]
+		if !ok {
+			c = &junitCase{ruleID: ruleID, category: category, resource: resource}
+			cases[key] = c
+			order = append(order, key)
+		}
+		return c
+	}
+
+	for _, resource := range view.Resources {
+		for _, rule := range applicableRules(registry, resource.Kind) {
+			get(rule.ID, rule.Category, resource)
+		}
+	}
+	for _, f := range view.Findings {
+		c := get(f.RuleID, f.Category, f.Resource)
+		c.findings = append(c.findings, f.Finding)
+	}
+
+	suites := map[string]*junitTestSuite{}
+	report := junitTestSuites{Name: view.Title}
+	for _, key := range order {
+		c := cases[key]
+		category := c.category
+		if category == "" {
+			category = "Uncategorized"
+		}
+		suite, ok := suites[category]
+		if !ok {
+			suite = &junitTestSuite{Name: category, Timestamp: view.GeneratedAt}
+			suites[category] = suite
+		}
+
+		tc := junitTestCase{Name: junitCaseName(c.resource), ClassName: c.ruleID, Failure: c.failure()}
+		suite.Cases = append(suite.Cases, tc)
+		suite.Tests++
+		report.Tests++
+		if tc.Failure != nil {
+			suite.Failures++
+			report.Failures++
+		}
+	}
+
+	names := make([]string, 0, len(suites))
+	for name := range suites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		suite := suites[name]
+		sort.SliceStable(suite.Cases, func(i, j int) bool {
+			if suite.Cases[i].ClassName != suite.Cases[j].ClassName {
+				return suite.Cases[i].ClassName < suite.Cases[j].ClassName
+			}
+			return suite.Cases[i].Name < suite.Cases[j].Name
+		})
+		report.Suites = append(report.Suites, *suite)
+	}
+	return report
+}
+
+// WriteJUnit writes the view as JUnit XML, one test case per rule and resource
+func WriteJUnit(w io.Writer, view ReportView) error {
+	return writeJUnit(w, view, rules.Default)
+}
+
+func writeJUnit(w io.Writer, view ReportView, registry *rules.Registry) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(newJUnitReport(view, registry)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
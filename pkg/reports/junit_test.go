@@ -0,0 +1,104 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/xml"
+	"kspm/pkg/findings"
+	"kspm/pkg/rules"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	registry := rules.NewRegistry()
+	require.NoError(t, registry.Register(rules.Rule{
+		ID:       "TEST-JUNIT-RULE",
+		Kinds:    []string{"JUnitWidget"},
+		Severity: findings.SeverityHigh,
+		Category: findings.CategoryPodSecurity,
+		Check:    func(obj runtime.Object) []rules.Violation { return nil },
+	}))
+
+	failing := findings.Resource{Kind: "JUnitWidget", Namespace: "default", Name: "bad"}
+	passing := findings.Resource{Kind: "JUnitWidget", Namespace: "default", Name: "good"}
+
+	view := BuildReportView("Test Report", []findings.Finding{
+		{
+			RuleID:   "TEST-JUNIT-RULE",
+			Severity: findings.SeverityHigh,
+			Category: findings.CategoryPodSecurity,
+			Resource: failing,
+			Message:  "first problem",
+			Evidence: []string{"spec.foo=true"},
+		},
+		{
+			RuleID:   "TEST-JUNIT-RULE",
+			Severity: findings.SeverityHigh,
+			Category: findings.CategoryPodSecurity,
+			Resource: failing,
+			Message:  "second problem",
+		},
+		{
+			RuleID:   "CTRL-UNREGISTERED",
+			Severity: findings.SeverityMedium,
+			Category: findings.CategoryControlPlane,
+			Resource: findings.Resource{Kind: "Node", Name: "*"},
+			Message:  "not enough nodes",
+		},
+	})
+	view.Resources = []findings.Resource{failing, passing}
+
+	var buf bytes.Buffer
+	require.NoError(t, writeJUnit(&buf, view, registry))
+
+	var report junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, 3, report.Tests)
+	assert.Equal(t, 2, report.Failures)
+	require.Len(t, report.Suites, 2)
+
+	// Suites are sorted by category
+	assert.Equal(t, findings.CategoryControlPlane, report.Suites[0].Name)
+	pods := report.Suites[1]
+	assert.Equal(t, findings.CategoryPodSecurity, pods.Name)
+	require.Len(t, pods.Cases, 2)
+
+	bad, good := pods.Cases[0], pods.Cases[1]
+	assert.Equal(t, "default/JUnitWidget/bad", bad.Name)
+	assert.Equal(t, "TEST-JUNIT-RULE", bad.ClassName)
+	require.NotNil(t, bad.Failure)
+	assert.Equal(t, "HIGH", bad.Failure.Type)
+	assert.Contains(t, bad.Failure.Message, "first problem")
+	assert.Contains(t, bad.Failure.Body, "second problem")
+	assert.Contains(t, bad.Failure.Body, "spec.foo=true")
+
+	assert.Equal(t, "default/JUnitWidget/good", good.Name)
+	assert.Nil(t, good.Failure)
+}
+
+func TestJUnitPassingCases(t *testing.T) {
+	registry := rules.NewRegistry()
+	check := func(obj runtime.Object) []rules.Violation { return nil }
+	require.NoError(t, registry.Register(rules.Rule{ID: "TEST-POD", Kinds: []string{"Pod"}, Category: findings.CategoryPodSecurity, Check: check}))
+	require.NoError(t, registry.Register(rules.Rule{ID: "TEST-DEPLOY", Kinds: []string{"Deployment"}, Category: findings.CategoryDeploymentSecurity, Check: check}))
+	require.NoError(t, registry.Define(rules.Rule{ID: "TEST-DEFINED", Kinds: []string{"Deployment"}, Category: findings.CategoryNetworkPolicy}))
+
+	view := BuildReportView("Test Report", nil)
+	view.Resources = []findings.Resource{{Kind: "Deployment", Namespace: "default", Name: "web"}}
+
+	var cases []string
+	for _, suite := range newJUnitReport(view, registry).Suites {
+		for _, c := range suite.Cases {
+			assert.Nil(t, c.Failure)
+			cases = append(cases, c.ClassName+" "+c.Name)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"TEST-POD default/Deployment/web",
+		"TEST-DEPLOY default/Deployment/web",
+		"TEST-DEFINED default/Deployment/web",
+	}, cases, "pod rules of workloads and defined rules pass too")
+}
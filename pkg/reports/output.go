@@ -0,0 +1,168 @@
+package reports
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"kspm/pkg/findings"
+	"kspm/pkg/riskposture"
+	"kspm/pkg/suppress"
+	"strings"
+)
+
+// Output formats accepted by --output
+const (
+	FormatTable  = "table"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatSARIF  = "sarif"
+	FormatJUnit  = "junit"
+)
+
+// OutputFormats lists the supported --output values
+var OutputFormats = []string{FormatTable, FormatJSON, FormatNDJSON, FormatSARIF, FormatJUnit}
+
+// ParseOutputFormat validates an --output value
+func ParseOutputFormat(s string) (string, error) {
+	format := strings.ToLower(strings.TrimSpace(s))
+	for _, f := range OutputFormats {
+		if format == f {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported output format %q (expected %s)", s, strings.Join(OutputFormats, "|"))
+}
+
+// JSONReport is the machine-readable form of a ReportView
+type JSONReport struct {
+	Title        string                      `json:"title"`
+	GeneratedAt  string                      `json:"generatedAt"`
+	Total        int                         `json:"total"`
+	Counts       map[string]int              `json:"counts"`
+	RiskScore    int                         `json:"riskScore"`
+	RiskCounts   riskposture.RiskLevelCounts `json:"riskCounts"`
+	RiskDrivers  []string                    `json:"riskDrivers"`
+	Findings     []findings.Finding          `json:"findings"`
+	AttackPaths  []riskposture.AttackPath    `json:"attackPaths"`
+	Remediations []riskposture.Remediation   `json:"remediations"`
+	Suppressed   []suppress.Suppressed       `json:"suppressed,omitempty"`
+	// IncompleteSections lists the sections the scanning identity could not fully read
+	IncompleteSections []IncompleteSection `json:"incompleteSections,omitempty"`
+}
+
+// NewJSONReport converts a report view to its JSON form
+func NewJSONReport(view ReportView) JSONReport {
+	report := JSONReport{
+		Title:        view.Title,
+		GeneratedAt:  view.GeneratedAt,
+		Total:        view.Total,
+		Counts:       view.Counts,
+		RiskScore:    view.RiskScore,
+		RiskCounts:   view.RiskCounts,
+		RiskDrivers:  view.RiskDrivers,
+		Findings:     make([]findings.Finding, 0, len(view.Findings)),
+		AttackPaths:  view.AttackPaths,
+		Remediations: view.Remediations,
+
+		IncompleteSections: view.IncompleteSections,
+	}
+	for _, f := range view.Findings {
+		report.Findings = append(report.Findings, f.Finding)
+	}
+	for _, s := range view.Suppressed {
+		report.Suppressed = append(report.Suppressed, suppress.Suppressed{
+			Finding:       s.Finding.Finding,
+			Justification: s.Justification,
+			Expires:       s.Expires,
+		})
+	}
+
+	// Emit empty lists rather than null so consumers can iterate blindly
+	if report.RiskDrivers == nil {
+		report.RiskDrivers = []string{}
+	}
+	if report.AttackPaths == nil {
+		report.AttackPaths = []riskposture.AttackPath{}
+	}
+	if report.Remediations == nil {
+		report.Remediations = []riskposture.Remediation{}
+	}
+	return report
+}
+
+// ndjsonRecord is a single line of NDJSON output
+type ndjsonRecord struct {
+	Type string `json:"type"`
+	Data any    `json:"data"`
+}
+
+// ndjsonSummary is the trailing NDJSON record
+type ndjsonSummary struct {
+	Title       string                      `json:"title"`
+	GeneratedAt string                      `json:"generatedAt"`
+	Total       int                         `json:"total"`
+	Counts      map[string]int              `json:"counts"`
+	RiskScore   int                         `json:"riskScore"`
+	RiskCounts  riskposture.RiskLevelCounts `json:"riskCounts"`
+	RiskDrivers []string                    `json:"riskDrivers"`
+}
+
+// WriteJSON writes the view as a single indented JSON document
+func WriteJSON(w io.Writer, view ReportView) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewJSONReport(view))
+}
+
+// WriteNDJSON writes one JSON record per line: findings, attack paths,
+// remediations and suppressed findings first, then a summary record with the
+// risk score
+func WriteNDJSON(w io.Writer, view ReportView) error {
+	report := NewJSONReport(view)
+	enc := json.NewEncoder(w)
+
+	for _, f := range report.Findings {
+		if err := enc.Encode(ndjsonRecord{Type: "finding", Data: f}); err != nil {
+			return err
+		}
+	}
+	for _, p := range report.AttackPaths {
+		if err := enc.Encode(ndjsonRecord{Type: "attackPath", Data: p}); err != nil {
+			return err
+		}
+	}
+	for _, r := range report.Remediations {
+		if err := enc.Encode(ndjsonRecord{Type: "remediation", Data: r}); err != nil {
+			return err
+		}
+	}
+	for _, s := range report.Suppressed {
+		if err := enc.Encode(ndjsonRecord{Type: "suppressed", Data: s}); err != nil {
+			return err
+		}
+	}
+	return enc.Encode(ndjsonRecord{Type: "summary", Data: ndjsonSummary{
+		Title:       report.Title,
+		GeneratedAt: report.GeneratedAt,
+		Total:       report.Total,
+		Counts:      report.Counts,
+		RiskScore:   report.RiskScore,
+		RiskCounts:  report.RiskCounts,
+		RiskDrivers: report.RiskDrivers,
+	}})
+}
+
+// WriteView writes the view in the given machine-readable format
+func WriteView(w io.Writer, format string, view ReportView) error {
+	switch format {
+	case FormatJSON:
+		return WriteJSON(w, view)
+	case FormatNDJSON:
+		return WriteNDJSON(w, view)
+	case FormatSARIF:
+		return WriteSARIF(w, view)
+	case FormatJUnit:
+		return WriteJUnit(w, view)
+	}
+	return fmt.Errorf("format %q is not machine-readable", format)
+}
@@ -0,0 +1,101 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/json"
+	"kspm/pkg/findings"
+	"kspm/pkg/suppress"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPostureView() ReportView {
+	return BuildPostureView("Test Report", []findings.Finding{
+		{
+			RuleID:   "POD-PRIVILEGED",
+			Severity: findings.SeverityCritical,
+			Category: findings.CategoryPodSecurity,
+			Resource: findings.Resource{Kind: "Pod", Namespace: "default", Name: "web"},
+			Message:  "container app is privileged",
+		},
+		{
+			RuleID:   "POD-LATEST-TAG",
+			Severity: findings.SeverityMedium,
+			Category: findings.CategoryPodSecurity,
+			Resource: findings.Resource{Kind: "Pod", Namespace: "default", Name: "web"},
+			Message:  "container app uses the latest tag",
+		},
+	}, nil)
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	for _, in := range []string{"table", "JSON", " ndjson "} {
+		_, err := ParseOutputFormat(in)
+		assert.NoError(t, err, in)
+	}
+
+	_, err := ParseOutputFormat("yaml")
+	assert.Error(t, err)
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, testPostureView()))
+
+	var report JSONReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, "Test Report", report.Title)
+	assert.Equal(t, 2, report.Total)
+	require.Len(t, report.Findings, 2)
+	assert.Equal(t, "POD-PRIVILEGED", report.Findings[0].RuleID)
+	assert.Greater(t, report.RiskScore, 0)
+	assert.NotNil(t, report.AttackPaths)
+	assert.NotNil(t, report.Remediations)
+	assert.NotContains(t, buf.String(), "\x1b[", "output must not contain ANSI escapes")
+}
+
+func TestWriteJSONSuppressed(t *testing.T) {
+	view := testPostureView()
+	view.Suppressed = SuppressedFindings([]suppress.Suppressed{{
+		Finding: findings.Finding{
+			RuleID:   "POD-HOST-NETWORK",
+			Severity: findings.SeverityHigh,
+			Resource: findings.Resource{Kind: "Pod", Namespace: "kube-system", Name: "calico-node"},
+		},
+		Justification: "CNI requires host networking",
+		Expires:       "2027-01-31",
+	}})
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSON(&buf, view))
+
+	var report JSONReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, 2, report.Total, "suppressed findings are not counted")
+	require.Len(t, report.Suppressed, 1)
+	assert.Equal(t, "POD-HOST-NETWORK", report.Suppressed[0].RuleID)
+	assert.Equal(t, "CNI requires host networking", report.Suppressed[0].Justification)
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteNDJSON(&buf, testPostureView()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	types := map[string]int{}
+	for _, line := range lines {
+		var record struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(line), &record), line)
+		types[record.Type]++
+	}
+
+	assert.Equal(t, 2, types["finding"])
+	assert.Equal(t, 1, types["summary"])
+	assert.Contains(t, lines[len(lines)-1], `"type":"summary"`)
+}
@@ -0,0 +1,219 @@
+package reports
+
+import (
+	"encoding/json"
+	"io"
+	"kspm/pkg/findings"
+	"kspm/pkg/rules"
+	"strings"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolURI = "https://github.com/sn0rlaxlife/paranoia"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifText          `json:"shortDescription"`
+	Help                 sarifText          `json:"help"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+	Properties           sarifProperties    `json:"properties"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifProperties struct {
+	Category         string   `json:"category,omitempty"`
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+	Tags             []string `json:"tags,omitempty"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifText       `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifLevel maps a severity onto a SARIF result level
+func sarifLevel(sev findings.Severity) string {
+	switch sev {
+	case findings.SeverityCritical, findings.SeverityHigh:
+		return "error"
+	case findings.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// securitySeverity maps a severity onto the numeric score code-scanning
+// dashboards use to bucket results
+func securitySeverity(sev findings.Severity) string {
+	switch sev {
+	case findings.SeverityCritical:
+		return "9.5"
+	case findings.SeverityHigh:
+		return "8.0"
+	case findings.SeverityMedium:
+		return "5.5"
+	case findings.SeverityLow:
+		return "3.0"
+	default:
+		return "0.0"
+	}
+}
+
+// sarifRuleFor describes a rule, preferring the registered metadata
+func sarifRuleFor(f findings.Finding) sarifRule {
+	description := f.Message
+	help := f.Remediation
+	if rule, ok := rules.Get(f.RuleID); ok {
+		description = rule.Description
+		if rule.Remediation != "" {
+			help = rule.Remediation
+		}
+	}
+	if help == "" {
+		help = description
+	}
+
+	return sarifRule{
+		ID:                   f.RuleID,
+		ShortDescription:     sarifText{Text: description},
+		Help:                 sarifText{Text: help},
+		DefaultConfiguration: sarifConfiguration{Level: sarifLevel(f.Severity)},
+		Properties: sarifProperties{
+			Category:         f.Category,
+			SecuritySeverity: securitySeverity(f.Severity),
+			Tags:             []string{"security", "kubernetes"},
+		},
+	}
+}
+
+// sarifLocationFor builds the logical namespace/kind/name location and, for
+// findings read from manifests, the physical file location
+func sarifLocationFor(f findings.Finding) sarifLocation {
+	parts := []string{}
+	if f.Resource.Namespace != "" {
+		parts = append(parts, f.Resource.Namespace)
+	}
+	parts = append(parts, f.Resource.Kind, f.Resource.Name)
+
+	loc := sarifLocation{
+		LogicalLocations: []sarifLogicalLocation{{
+			Name:               f.Resource.Name,
+			FullyQualifiedName: strings.Join(parts, "/"),
+			Kind:               "resource",
+		}},
+	}
+	if f.Location != nil && f.Location.File != "" {
+		loc.PhysicalLocation = &sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: f.Location.File},
+		}
+		if f.Location.Line > 0 {
+			loc.PhysicalLocation.Region = &sarifRegion{StartLine: f.Location.Line}
+		}
+	}
+	return loc
+}
+
+// newSARIFLog converts typed findings to a SARIF 2.1.0 log
+func newSARIFLog(list []findings.Finding) sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "paranoia",
+			InformationURI: sarifToolURI,
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	ruleIndex := map[string]int{}
+	for _, f := range list {
+		f.Severity = findings.ParseSeverity(string(f.Severity))
+
+		idx, ok := ruleIndex[f.RuleID]
+		if !ok {
+			idx = len(run.Tool.Driver.Rules)
+			ruleIndex[f.RuleID] = idx
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRuleFor(f))
+		}
+
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    f.RuleID,
+			RuleIndex: idx,
+			Level:     sarifLevel(f.Severity),
+			Message:   sarifText{Text: f.Message},
+			Locations: []sarifLocation{sarifLocationFor(f)},
+		})
+	}
+
+	return sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}
+}
+
+// WriteSARIF writes the view's findings as a SARIF 2.1.0 log
+func WriteSARIF(w io.Writer, view ReportView) error {
+	list := make([]findings.Finding, 0, len(view.Findings))
+	for _, f := range view.Findings {
+		list = append(list, f.Finding)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(newSARIFLog(list))
+}
@@ -0,0 +1,75 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/json"
+	"kspm/pkg/findings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	view := BuildReportView("Test Report", []findings.Finding{
+		{
+			RuleID:      "TEST-CRITICAL",
+			Severity:    findings.SeverityCritical,
+			Category:    findings.CategoryPodSecurity,
+			Resource:    findings.Resource{Kind: "Pod", Namespace: "default", Name: "web"},
+			Message:     "container app is privileged",
+			Remediation: "drop privileged",
+		},
+		{
+			RuleID:   "TEST-CRITICAL",
+			Severity: findings.SeverityCritical,
+			Category: findings.CategoryPodSecurity,
+			Resource: findings.Resource{Kind: "Pod", Namespace: "default", Name: "api"},
+			Message:  "container api is privileged",
+			Location: &findings.Location{File: "deploy/api.yaml", Line: 12},
+		},
+		{
+			RuleID:   "TEST-MEDIUM",
+			Severity: findings.SeverityMedium,
+			Category: findings.CategoryRBAC,
+			Resource: findings.Resource{Kind: "ClusterRole", Name: "editor"},
+			Message:  "dangerous verbs",
+		},
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteSARIF(&buf, view))
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+
+	run := log.Runs[0]
+	assert.Equal(t, "paranoia", run.Tool.Driver.Name)
+	require.Len(t, run.Tool.Driver.Rules, 2, "rules are de-duplicated")
+	require.Len(t, run.Results, 3)
+
+	levels := map[string]string{}
+	for _, r := range run.Results {
+		levels[r.RuleID] = r.Level
+		assert.Equal(t, r.RuleID, run.Tool.Driver.Rules[r.RuleIndex].ID)
+	}
+	assert.Equal(t, "error", levels["TEST-CRITICAL"])
+	assert.Equal(t, "warning", levels["TEST-MEDIUM"])
+
+	for _, r := range run.Results {
+		loc := r.Locations[0]
+		switch r.Message.Text {
+		case "container api is privileged":
+			require.NotNil(t, loc.PhysicalLocation)
+			assert.Equal(t, "deploy/api.yaml", loc.PhysicalLocation.ArtifactLocation.URI)
+			assert.Equal(t, 12, loc.PhysicalLocation.Region.StartLine)
+		case "container app is privileged":
+			assert.Nil(t, loc.PhysicalLocation)
+			assert.Equal(t, "default/Pod/web", loc.LogicalLocations[0].FullyQualifiedName)
+		case "dangerous verbs":
+			assert.Equal(t, "ClusterRole/editor", loc.LogicalLocations[0].FullyQualifiedName)
+		}
+	}
+}
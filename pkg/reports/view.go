@@ -1,6 +1,10 @@
 package reports
 
-import "kspm/pkg/riskposture"
+import (
+	"kspm/pkg/findings"
+	"kspm/pkg/riskposture"
+	"kspm/pkg/suppress"
+)
 
 type ReportView struct {
 	// Top-level report fields - Legacy
@@ -11,11 +15,12 @@ type ReportView struct {
 	Findings    []Finding
 
 	// New: Fields for detailed posture report
-	RBACFindings         []Finding
-	DeploymentFindings   []Finding
-	ControlPlaneFindings []Finding
-	PodFindings          []Finding
-	SecretFindings       []Finding
+	RBACFindings          []Finding
+	DeploymentFindings    []Finding
+	ControlPlaneFindings  []Finding
+	PodFindings           []Finding
+	SecretFindings        []Finding
+	NetworkPolicyFindings []Finding
 
 	// New posture fields
 	RiskScore   int
@@ -24,4 +29,56 @@ type ReportView struct {
 
 	AttackPaths  []riskposture.AttackPath
 	Remediations []riskposture.Remediation
+
+	// Resources lists every object the rules were evaluated against, so
+	// exporters can report passing checks as well as failures
+	Resources []findings.Resource
+
+	// Suppressed lists accepted-risk findings excluded from the score and sections
+	Suppressed []SuppressedFinding
+
+	// IncompleteSections lists the sections the scanning identity could not
+	// fully read, as found by the preflight checks
+	IncompleteSections []IncompleteSection
+}
+
+// IncompleteSection is a report section missing data and the permissions it lacked
+type IncompleteSection struct {
+	Section string   `json:"section"`
+	Missing []string `json:"missing"`
+}
+
+// SuppressedFinding is the view model for a finding hidden by .paranoia-ignore.yaml
+type SuppressedFinding struct {
+	Finding
+	Justification string
+	Expires       string
+}
+
+// SuppressedFindings converts suppressed findings to view rows
+func SuppressedFindings(list []suppress.Suppressed) []SuppressedFinding {
+	out := make([]SuppressedFinding, 0, len(list))
+	for _, s := range list {
+		out = append(out, SuppressedFinding{
+			Finding:       newFinding(s.Finding),
+			Justification: s.Justification,
+			Expires:       s.Expires,
+		})
+	}
+	return out
+}
+
+// BuildPostureView builds the report view and fills in the risk posture
+// derived from the findings and any extra signals
+func BuildPostureView(title string, list []findings.Finding, signals []riskposture.Signal) ReportView {
+	view := BuildReportView(title, list)
+
+	signals = append(signals, riskposture.SignalsFromFindings(list)...)
+	rp := riskposture.NewRiskPosture(signals)
+
+	view.RiskCounts = rp.CountRiskLevels()
+	view.RiskScore, view.RiskDrivers = rp.Score()
+	view.AttackPaths = rp.DeriveAttackPaths()
+	view.Remediations = rp.Remediations()
+	return view
 }
@@ -2,36 +2,40 @@ package riskposture
 
 import (
 	"fmt"
+	"kspm/pkg/findings"
 	"math"
 	"sort"
+	"strings"
 )
 
 type Signal struct {
-	Name     string // e.g. Privileged Pod, Cluster Admin Binding
-	Severity string // e.g. "CRITICAL", "HIGH", "MEDIUM", "LOW", "INFO"
-	Weight   int    // numeric contribution used
+	Name     string            // e.g. Privileged Pod, Cluster Admin Binding
+	Severity findings.Severity // e.g. "CRITICAL", "HIGH", "MEDIUM", "LOW", "INFO"
+	Weight   int               // numeric contribution used
+	// Namespaces the signal was raised in, when remediations are per namespace
+	Namespaces []string
 }
 
 // Risk level counts
 type RiskLevelCounts struct {
-	Critical int
-	High     int
-	Medium   int
-	Low      int
+	Critical int `json:"critical"`
+	High     int `json:"high"`
+	Medium   int `json:"medium"`
+	Low      int `json:"low"`
 }
 
 // Method to enumerate
 func (rp *RiskPosture) CountRiskLevels() RiskLevelCounts {
 	var c RiskLevelCounts
 	for _, s := range rp.Signals {
-		switch s.Severity {
-		case "CRITICAL":
+		switch findings.ParseSeverity(string(s.Severity)) {
+		case findings.SeverityCritical:
 			c.Critical++
-		case "HIGH":
+		case findings.SeverityHigh:
 			c.High++
-		case "MEDIUM":
+		case findings.SeverityMedium:
 			c.Medium++
-		case "LOW":
+		case findings.SeverityLow:
 			c.Low++
 		}
 	}
@@ -46,41 +50,32 @@ type RiskPosture struct {
 
 // Add Attack Paths
 type AttackPath struct {
-	ID         string
-	Title      string
-	Severity   string
-	Confidence int
-	Steps      []AttackStep
-	Evidence   []string
+	ID         string       `json:"id"`
+	Title      string       `json:"title"`
+	Severity   string       `json:"severity"`
+	Confidence int          `json:"confidence"`
+	Steps      []AttackStep `json:"steps,omitempty"`
+	Evidence   []string     `json:"evidence,omitempty"`
 }
 
 type AttackStep struct {
-	Kind      string
-	Namespace string
-	Name      string
-	Why       string
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Why       string `json:"why"`
 }
 
 // Add remediation structs
 type Remediation struct {
-	ID        string
-	Title     string
-	Priority  string
-	AppliesTo string
-	YAML      string
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Priority  string `json:"priority"`
+	AppliesTo string `json:"appliesTo"`
+	YAML      string `json:"yaml,omitempty"`
 }
 
-func (rp *RiskPosture) Remediations() []Remediation {
-	var fixes []Remediation
-
-	for _, s := range rp.Signals {
-		if s.Name == "NoNetworkPolicy" {
-			fixes = append(fixes, Remediation{
-				ID:        "default-deny-netpol",
-				Title:     "Apply default deny NetworkPolicy",
-				Priority:  "HIGH",
-				AppliesTo: "Namespace",
-				YAML: `
+// defaultDenyYAML is the default deny NetworkPolicy, rendered per namespace
+const defaultDenyYAML = `
 apiVersion: networking.k8s.io/v1
 kind: NetworkPolicy
 metadata:
@@ -91,9 +86,46 @@ spec:
   policyTypes:
   - Ingress
   - Egress
-`,
-			})
+`
+
+func (rp *RiskPosture) Remediations() []Remediation {
+	var fixes []Remediation
+
+	// One default deny per namespace missing one; the template is kept when
+	// the signal does not say which namespaces
+	var noNetworkPolicy bool
+	var namespaces []string
+	seen := map[string]bool{}
+	for _, s := range rp.Signals {
+		if s.Name != "NoNetworkPolicy" {
+			continue
 		}
+		noNetworkPolicy = true
+		for _, ns := range s.Namespaces {
+			if ns != "" && !seen[ns] {
+				seen[ns] = true
+				namespaces = append(namespaces, ns)
+			}
+		}
+	}
+	sort.Strings(namespaces)
+	if noNetworkPolicy && len(namespaces) == 0 {
+		fixes = append(fixes, Remediation{
+			ID:        "default-deny-netpol",
+			Title:     "Apply default deny NetworkPolicy",
+			Priority:  "HIGH",
+			AppliesTo: "Namespace",
+			YAML:      defaultDenyYAML,
+		})
+	}
+	for _, ns := range namespaces {
+		fixes = append(fixes, Remediation{
+			ID:        "default-deny-netpol-" + ns,
+			Title:     "Apply default deny NetworkPolicy in " + ns,
+			Priority:  "HIGH",
+			AppliesTo: "Namespace/" + ns,
+			YAML:      strings.ReplaceAll(defaultDenyYAML, "{{NAMESPACE}}", ns),
+		})
 	}
 
 	return fixes
@@ -149,7 +181,7 @@ func NewRiskPosture(signals []Signal) *RiskPosture {
 func (rp *RiskPosture) Score() (int, []string) {
 	// Take the MAX weight per signal name (prevents saturating on repeats)
 	maxByName := map[string]int{}
-	sevByName := map[string]string{}
+	sevByName := map[string]findings.Severity{}
 
 	for _, s := range rp.Signals {
 		if s.Weight > maxByName[s.Name] {
@@ -178,7 +210,7 @@ func (rp *RiskPosture) Score() (int, []string) {
 	type pair struct {
 		name string
 		w    int
-		sev  string
+		sev  findings.Severity
 	}
 	arr := make([]pair, 0, len(maxByName))
 	for n, w := range maxByName {
@@ -1,91 +1,87 @@
 package riskposture
 
-import "strings"
+import (
+	"kspm/pkg/findings"
+	"kspm/pkg/rules"
+	"slices"
+)
 
-// SignalsFromFindings converts your string findings into risk signals.
-// v1: severity-driven (fast). v2: add more parsing (resource type, namespace, etc).
-func SignalsFromFindings(findings []string) []Signal {
-	var out []Signal
-	seen := map[string]bool{} // dedupe by Name
+// SignalForFinding returns the risk signal declared by the finding's rule,
+// raised in the finding's namespace
+func SignalForFinding(f findings.Finding) (Signal, bool) {
+	rule, ok := rules.Get(f.RuleID)
+	if !ok || rule.Signal == "" {
+		return Signal{}, false
+	}
+	signal := Signal{Name: rule.Signal, Severity: findings.ParseSeverity(string(f.Severity)), Weight: rule.Weight}
+	namespace := f.Resource.Namespace
+	if f.Resource.Kind == "Namespace" {
+		namespace = f.Resource.Name
+	}
+	if namespace != "" {
+		signal.Namespaces = []string{namespace}
+	}
+	return signal, true
+}
 
-	for _, f := range findings {
-		sev := ParseSeverityFromFinding(f)
-		l := strings.ToLower(f)
+// SignalsFromFindings converts typed findings into risk signals.
+// Signals are keyed on rule ID and category rather than message text.
+func SignalsFromFindings(list []findings.Finding) []Signal {
+	var out []Signal
+	index := map[string]int{} // dedupe by Name
 
-		add := func(name, severity string, weight int) {
-			// de-dupe by category; score uses max per name anyway
-			if seen[name] {
-				return
-			}
-			seen[name] = true
+	add := func(name string, severity findings.Severity, weight int, namespaces ...string) {
+		// de-dupe by category, keeping the highest severity and weight
+		i, ok := index[name]
+		if !ok {
+			index[name] = len(out)
 			out = append(out, Signal{Name: name, Severity: severity, Weight: weight})
+			i = len(out) - 1
 		}
-
-		// RBAC / cluster-admin
-		if strings.Contains(l, "cluster-admin") {
-			add("ClusterAdminBinding", "CRITICAL", 40)
-			continue
+		if severity.Rank() > out[i].Severity.Rank() {
+			out[i].Severity = severity
 		}
-
-		// NetworkPolicy
-		if strings.Contains(l, "networkpolicy") || strings.Contains(l, "no networkpolicy") {
-			add("NoNetworkPolicy", "HIGH", 20)
-			continue
+		out[i].Weight = max(out[i].Weight, weight)
+		for _, namespace := range namespaces {
+			if !slices.Contains(out[i].Namespaces, namespace) {
+				out[i].Namespaces = append(out[i].Namespaces, namespace)
+			}
 		}
+	}
 
-		// Privileged / host namespaces
-		if strings.Contains(l, "privileged") || strings.Contains(l, "hostnetwork") || strings.Contains(l, "hostpid") {
-			add("PrivilegedWorkload", "HIGH", 25)
-			continue
-		}
+	for _, f := range list {
+		sev := findings.ParseSeverity(string(f.Severity))
 
-		// Secrets exposure
-		if strings.Contains(l, "secret") && (strings.Contains(l, "env") || strings.Contains(l, "mount")) {
-			add("SecretsExposure", "HIGH", 20)
+		// Rule declared signal, e.g. ClusterAdminBinding from RBAC-BINDING-CLUSTER-ADMIN
+		if signal, ok := SignalForFinding(f); ok {
+			add(signal.Name, signal.Severity, signal.Weight, signal.Namespaces...)
 			continue
 		}
 
+		switch {
 		// Fallback: severity-only category (still not “Finding”)
-		switch sev {
-		case "CRITICAL":
-			add("CriticalFindingsPresent", "CRITICAL", 20)
-		case "HIGH":
-			add("HighFindingsPresent", "HIGH", 10)
-		case "MEDIUM":
-			add("MediumFindingsPresent", "MEDIUM", 5)
+		case sev == findings.SeverityCritical:
+			add("CriticalFindingsPresent", sev, weightForSeverity(sev))
+		case sev == findings.SeverityHigh:
+			add("HighFindingsPresent", sev, weightForSeverity(sev))
+		case sev == findings.SeverityMedium:
+			add("MediumFindingsPresent", sev, weightForSeverity(sev))
 		}
 	}
 
 	return out
 }
 
-func ParseSeverityFromFinding(s string) string {
-	s = strings.TrimSpace(s)
-	if strings.HasPrefix(s, "[") {
-		if end := strings.Index(s, "]"); end > 1 {
-			sev := strings.ToUpper(strings.TrimSpace(s[1:end]))
-			switch sev {
-			case "CRITICAL", "HIGH", "MEDIUM", "LOW", "INFO":
-				return sev
-			}
-		}
-	}
-	if strings.HasPrefix(strings.ToLower(s), "vulnerability:") {
-		return "HIGH"
-	}
-	return "INFO"
-}
-
 // Weight for Severity
-func weightForSeverity(sev string) int {
+func weightForSeverity(sev findings.Severity) int {
 	switch sev {
-	case "CRITICAL":
+	case findings.SeverityCritical:
 		return 20
-	case "HIGH":
+	case findings.SeverityHigh:
 		return 10
-	case "MEDIUM":
+	case findings.SeverityMedium:
 		return 5
-	case "LOW":
+	case findings.SeverityLow:
 		return 2
 	default:
 		return 0
@@ -0,0 +1,382 @@
+// Package rules provides the registry of named security checks shared by the
+// watchers, the report commands and any custom checks.
+package rules
+
+import (
+	"fmt"
+	"kspm/pkg/findings"
+	"slices"
+	"sort"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Violation is a single hit returned by a rule's check
+type Violation struct {
+	Message  string
+	Evidence []string
+}
+
+// CheckFunc inspects an object and returns any violations
+type CheckFunc func(obj runtime.Object) []Violation
+
+// Rule is a named check with a stable ID. Rules whose findings are built by
+// checks spanning several objects are defined without a Check.
+type Rule struct {
+	// ID is the stable identifier, e.g. "POD-PRIVILEGED"
+	ID string
+	// Kinds lists the resource kinds the rule applies to, e.g. "Pod"
+	Kinds []string
+	// Severity is the default severity of the rule's findings
+	Severity findings.Severity
+	// Category groups the rule in reports
+	Category string
+	// Description explains what the rule detects
+	Description string
+	// Remediation is a short hint on how to fix a violation
+	Remediation string
+	// Signal and Weight optionally map findings onto a risk posture signal
+	Signal string
+	Weight int
+	// Check performs the evaluation, nil for defined rules
+	Check CheckFunc
+}
+
+// AppliesTo reports whether the rule covers the given kind
+func (r Rule) AppliesTo(kind string) bool {
+	for _, k := range r.Kinds {
+		if k == kind || k == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Finding builds the finding of a violation of the rule by resource
+func (r Rule) Finding(resource findings.Resource, v Violation) findings.Finding {
+	return findings.Finding{
+		RuleID:      r.ID,
+		Severity:    r.Severity,
+		Category:    r.Category,
+		Resource:    resource,
+		Message:     v.Message,
+		Evidence:    v.Evidence,
+		Remediation: r.Remediation,
+	}
+}
+
+// Registry holds rules in registration order
+type Registry struct {
+	mu       sync.RWMutex
+	rules    []Rule
+	index    map[string]int
+	disabled map[string]bool
+	// overridden marks the rules whose severity was set by SetSeverity
+	overridden map[string]bool
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		index:      map[string]int{},
+		disabled:   map[string]bool{},
+		overridden: map[string]bool{},
+	}
+}
+
+// Register adds a rule, rejecting duplicate or incomplete definitions
+func (r *Registry) Register(rule Rule) error {
+	if rule.ID == "" {
+		return fmt.Errorf("rule has no ID")
+	}
+	if rule.Check == nil {
+		return fmt.Errorf("rule %s has no check", rule.ID)
+	}
+	if len(rule.Kinds) == 0 {
+		return fmt.Errorf("rule %s applies to no kinds", rule.ID)
+	}
+	return r.add(rule)
+}
+
+// Define adds a rule whose findings are built outside the registry, by a
+// check spanning several objects such as a binding and the role it
+// references. Evaluate never runs it, but it is listed, disabled and
+// overridden like any other rule; Apply fits its findings to the registry.
+func (r *Registry) Define(rule Rule) error {
+	if rule.ID == "" {
+		return fmt.Errorf("rule has no ID")
+	}
+	if rule.Check != nil {
+		return fmt.Errorf("rule %s has a check, register it instead", rule.ID)
+	}
+	return r.add(rule)
+}
+
+func (r *Registry) add(rule Rule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.index[rule.ID]; ok {
+		return fmt.Errorf("rule %s already registered", rule.ID)
+	}
+	r.index[rule.ID] = len(r.rules)
+	r.rules = append(r.rules, rule)
+	return nil
+}
+
+// MustRegister adds a rule and panics on error (intended for init functions)
+func (r *Registry) MustRegister(rule Rule) {
+	if err := r.Register(rule); err != nil {
+		panic(err)
+	}
+}
+
+// MustDefine defines a rule and panics on error (intended for init functions)
+func (r *Registry) MustDefine(rule Rule) {
+	if err := r.Define(rule); err != nil {
+		panic(err)
+	}
+}
+
+// Get returns the rule with the given ID
+func (r *Registry) Get(id string) (Rule, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i, ok := r.index[id]
+	if !ok {
+		return Rule{}, false
+	}
+	return r.rules[i], true
+}
+
+// Rules returns all registered rules sorted by ID
+func (r *Registry) Rules() []Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Rule, len(r.rules))
+	copy(out, r.rules)
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Enable re-enables a previously disabled rule
+func (r *Registry) Enable(id string) error {
+	return r.setDisabled(id, false)
+}
+
+// Disable stops a rule from being evaluated
+func (r *Registry) Disable(id string) error {
+	return r.setDisabled(id, true)
+}
+
+func (r *Registry) setDisabled(id string, disabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.index[id]; !ok {
+		return fmt.Errorf("unknown rule %s", id)
+	}
+	if disabled {
+		r.disabled[id] = true
+	} else {
+		delete(r.disabled, id)
+	}
+	return nil
+}
+
+// SetSeverity overrides the severity of a rule's findings
+func (r *Registry) SetSeverity(id string, severity findings.Severity) error {
+	return r.update(id, func(rule *Rule) {
+		rule.Severity = severity
+		r.overridden[rule.ID] = true
+	})
+}
+
+// SetWeight overrides the risk signal weight of a rule
+func (r *Registry) SetWeight(id string, weight int) error {
+	return r.update(id, func(rule *Rule) { rule.Weight = weight })
+}
+
+func (r *Registry) update(id string, fn func(rule *Rule)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, ok := r.index[id]
+	if !ok {
+		return fmt.Errorf("unknown rule %s", id)
+	}
+	fn(&r.rules[i])
+	return nil
+}
+
+// Enabled reports whether a rule is registered and enabled
+func (r *Registry) Enabled(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.index[id]
+	return ok && !r.disabled[id]
+}
+
+// EffectiveSeverity returns the overridden severity of a rule, or severity
+// when it was not overridden. Checks grading their findings, such as by CVE
+// severity, keep their grading unless the rule is overridden.
+func (r *Registry) EffectiveSeverity(id string, severity findings.Severity) findings.Severity {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if i, ok := r.index[id]; ok && r.overridden[id] {
+		return r.rules[i].Severity
+	}
+	return severity
+}
+
+// Apply fits findings built outside Evaluate to the registry: findings of
+// disabled rules are dropped and overridden severities replace the ones the
+// checks chose. Findings of unknown rules are kept as they are.
+func (r *Registry) Apply(list []findings.Finding) []findings.Finding {
+	out := make([]findings.Finding, 0, len(list))
+	for _, f := range list {
+		r.mu.RLock()
+		disabled := r.disabled[f.RuleID]
+		r.mu.RUnlock()
+		if disabled {
+			continue
+		}
+		f.Severity = r.EffectiveSeverity(f.RuleID, f.Severity)
+		out = append(out, f)
+	}
+	return out
+}
+
+// SetSeverity overrides a rule's severity in the default registry
+func SetSeverity(id string, severity findings.Severity) error {
+	return Default.SetSeverity(id, severity)
+}
+
+// SetWeight overrides a rule's signal weight in the default registry
+func SetWeight(id string, weight int) error {
+	return Default.SetWeight(id, weight)
+}
+
+// ForKind returns the enabled rules with a check that apply to a kind, in registration order
+func (r *Registry) ForKind(kind string) []Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	active := make([]Rule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		if rule.Check != nil && !r.disabled[rule.ID] && rule.AppliesTo(kind) {
+			active = append(active, rule)
+		}
+	}
+	return active
+}
+
+// Applicable returns the enabled rules a resource of a kind can fail, in
+// registration order: the rules ForKind returns and the defined rules naming
+// the kind. Defined rules for every kind, such as image vulnerabilities, are
+// not included.
+func (r *Registry) Applicable(kind string) []Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Rule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		if r.disabled[rule.ID] || !rule.AppliesTo(kind) {
+			continue
+		}
+		if rule.Check != nil || slices.Contains(rule.Kinds, kind) {
+			out = append(out, rule)
+		}
+	}
+	return out
+}
+
+// Evaluate runs every enabled rule that applies to the resource kind
+func (r *Registry) Evaluate(resource findings.Resource, obj runtime.Object) []findings.Finding {
+	var out []findings.Finding
+	for _, rule := range r.ForKind(resource.Kind) {
+		for _, v := range rule.Check(obj) {
+			out = append(out, rule.Finding(resource, v))
+		}
+	}
+	return out
+}
+
+// Default is the registry used by the built-in checks
+var Default = NewRegistry()
+
+// Register adds a rule to the default registry
+func Register(rule Rule) error {
+	return Default.Register(rule)
+}
+
+// MustRegister adds a rule to the default registry and panics on error
+func MustRegister(rule Rule) {
+	Default.MustRegister(rule)
+}
+
+// Define adds a rule evaluated outside the registry to the default registry
+func Define(rule Rule) error {
+	return Default.Define(rule)
+}
+
+// MustDefine defines a rule in the default registry and panics on error
+func MustDefine(rule Rule) {
+	Default.MustDefine(rule)
+}
+
+// NewFinding builds the finding of a violation of a rule of the default
+// registry, with the rule's severity, category and remediation, for checks
+// that run outside Evaluate
+func NewFinding(id string, resource findings.Resource, v Violation) findings.Finding {
+	rule, ok := Default.Get(id)
+	if !ok {
+		rule = Rule{ID: id}
+	}
+	return rule.Finding(resource, v)
+}
+
+// Get returns a rule from the default registry
+func Get(id string) (Rule, bool) {
+	return Default.Get(id)
+}
+
+// All returns every rule in the default registry
+func All() []Rule {
+	return Default.Rules()
+}
+
+// Enable re-enables a rule in the default registry
+func Enable(id string) error {
+	return Default.Enable(id)
+}
+
+// Disable disables a rule in the default registry
+func Disable(id string) error {
+	return Default.Disable(id)
+}
+
+// ForKind returns the enabled rules of the default registry for a kind
+func ForKind(kind string) []Rule {
+	return Default.ForKind(kind)
+}
+
+// EffectiveSeverity returns a rule's severity as overridden in the default registry
+func EffectiveSeverity(id string, severity findings.Severity) findings.Severity {
+	return Default.EffectiveSeverity(id, severity)
+}
+
+// Apply fits findings built outside Evaluate to the default registry
+func Apply(list []findings.Finding) []findings.Finding {
+	return Default.Apply(list)
+}
+
+// Evaluate runs the default registry against an object
+func Evaluate(resource findings.Resource, obj runtime.Object) []findings.Finding {
+	return Default.Evaluate(resource, obj)
+}
@@ -0,0 +1,151 @@
+package rules
+
+import (
+	"kspm/pkg/findings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func testRule(id string) Rule {
+	return Rule{
+		ID:          id,
+		Kinds:       []string{"Pod"},
+		Severity:    findings.SeverityHigh,
+		Category:    findings.CategoryPodSecurity,
+		Remediation: "fix it",
+		Check: func(obj runtime.Object) []Violation {
+			return []Violation{{Message: "violation", Evidence: []string{"evidence"}}}
+		},
+	}
+}
+
+func TestRegisterRejectsInvalidRules(t *testing.T) {
+	r := NewRegistry()
+
+	require.NoError(t, r.Register(testRule("TEST-ONE")))
+	assert.Error(t, r.Register(testRule("TEST-ONE")), "duplicate ID")
+	assert.Error(t, r.Register(Rule{Kinds: []string{"Pod"}, Check: testRule("x").Check}), "missing ID")
+	assert.Error(t, r.Register(Rule{ID: "TEST-NOCHECK", Kinds: []string{"Pod"}}), "missing check")
+	assert.Error(t, r.Register(Rule{ID: "TEST-NOKINDS", Check: testRule("x").Check}), "missing kinds")
+}
+
+func TestEvaluate(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register(testRule("TEST-ONE")))
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+	resource := findings.Resource{Kind: "Pod", Namespace: "default", Name: "web"}
+
+	result := r.Evaluate(resource, pod)
+	require.Len(t, result, 1)
+	assert.Equal(t, "TEST-ONE", result[0].RuleID)
+	assert.Equal(t, findings.SeverityHigh, result[0].Severity)
+	assert.Equal(t, findings.CategoryPodSecurity, result[0].Category)
+	assert.Equal(t, resource, result[0].Resource)
+	assert.Equal(t, "fix it", result[0].Remediation)
+
+	// Rules only run against the kinds they declare
+	assert.Empty(t, r.Evaluate(findings.Resource{Kind: "Secret", Name: "web"}, pod))
+}
+
+func TestDisableAndEnable(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register(testRule("TEST-ONE")))
+	pod := &corev1.Pod{}
+	resource := findings.Resource{Kind: "Pod", Name: "web"}
+
+	require.NoError(t, r.Disable("TEST-ONE"))
+	assert.False(t, r.Enabled("TEST-ONE"))
+	assert.Empty(t, r.Evaluate(resource, pod))
+
+	require.NoError(t, r.Enable("TEST-ONE"))
+	assert.True(t, r.Enabled("TEST-ONE"))
+	assert.Len(t, r.Evaluate(resource, pod), 1)
+
+	assert.Error(t, r.Disable("TEST-UNKNOWN"))
+}
+
+func TestRulesSortedByID(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register(testRule("TEST-B")))
+	require.NoError(t, r.Register(testRule("TEST-A")))
+
+	all := r.Rules()
+	require.Len(t, all, 2)
+	assert.Equal(t, "TEST-A", all[0].ID)
+	assert.Equal(t, "TEST-B", all[1].ID)
+}
+
+func TestSetSeverityAndWeight(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register(testRule("TEST-ONE")))
+
+	require.NoError(t, r.SetSeverity("TEST-ONE", findings.SeverityLow))
+	require.NoError(t, r.SetWeight("TEST-ONE", 7))
+
+	rule, ok := r.Get("TEST-ONE")
+	require.True(t, ok)
+	assert.Equal(t, findings.SeverityLow, rule.Severity)
+	assert.Equal(t, 7, rule.Weight)
+
+	result := r.Evaluate(findings.Resource{Kind: "Pod", Name: "web"}, &corev1.Pod{})
+	require.Len(t, result, 1)
+	assert.Equal(t, findings.SeverityLow, result[0].Severity)
+
+	assert.Error(t, r.SetSeverity("TEST-UNKNOWN", findings.SeverityLow))
+	assert.Error(t, r.SetWeight("TEST-UNKNOWN", 1))
+}
+
+func TestDefineAndApply(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Define(Rule{ID: "TEST-CROSS", Kinds: []string{"Pod"}, Severity: findings.SeverityMedium}))
+	require.NoError(t, r.Define(Rule{ID: "TEST-GRADED", Severity: findings.SeverityHigh}))
+	assert.Error(t, r.Define(testRule("TEST-CHECKED")), "rules with a check are registered")
+	assert.Error(t, r.Define(Rule{ID: "TEST-CROSS"}), "duplicate ID")
+
+	assert.Empty(t, r.Evaluate(findings.Resource{Kind: "Pod", Name: "web"}, &corev1.Pod{}), "defined rules are never evaluated")
+	assert.True(t, r.Enabled("TEST-CROSS"))
+
+	list := []findings.Finding{
+		{RuleID: "TEST-CROSS", Severity: findings.SeverityMedium},
+		{RuleID: "TEST-GRADED", Severity: findings.SeverityCritical},
+		{RuleID: "TEST-UNKNOWN", Severity: findings.SeverityLow},
+	}
+	require.NoError(t, r.SetSeverity("TEST-CROSS", findings.SeverityLow))
+	applied := r.Apply(list)
+	require.Len(t, applied, 3)
+	assert.Equal(t, findings.SeverityLow, applied[0].Severity)
+	assert.Equal(t, findings.SeverityCritical, applied[1].Severity, "graded severities are kept unless overridden")
+	assert.Equal(t, findings.SeverityMedium, list[0].Severity, "the input is not modified")
+
+	require.NoError(t, r.Disable("TEST-CROSS"))
+	applied = r.Apply(list)
+	require.Len(t, applied, 2)
+	assert.Equal(t, "TEST-GRADED", applied[0].RuleID)
+}
+
+func TestApplicable(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Register(testRule("TEST-CHECKED")))
+	require.NoError(t, r.Define(Rule{ID: "TEST-CROSS", Kinds: []string{"Pod"}}))
+	require.NoError(t, r.Define(Rule{ID: "TEST-ANY", Kinds: []string{"*"}}))
+	require.NoError(t, r.Define(Rule{ID: "TEST-NODE", Kinds: []string{"Node"}}))
+
+	ids := func(list []Rule) []string {
+		var out []string
+		for _, rule := range list {
+			out = append(out, rule.ID)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"TEST-CHECKED"}, ids(r.ForKind("Pod")))
+	assert.Equal(t, []string{"TEST-CHECKED", "TEST-CROSS"}, ids(r.Applicable("Pod")), "defined rules are included, wildcards are not")
+
+	require.NoError(t, r.Disable("TEST-CROSS"))
+	assert.Equal(t, []string{"TEST-CHECKED"}, ids(r.Applicable("Pod")))
+}
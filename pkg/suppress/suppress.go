@@ -0,0 +1,188 @@
+// Package suppress applies accepted-risk exceptions read from a
+// .paranoia-ignore.yaml file to findings before they are scored and reported.
+package suppress
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"kspm/pkg/findings"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultFile is read from the working directory when --ignore-file is not set
+const DefaultFile = ".paranoia-ignore.yaml"
+
+// dateLayout is the format of the expires field
+const dateLayout = "2006-01-02"
+
+// Rule is a single suppression. Every matcher that is set must match; at
+// least one matcher and a justification are required.
+type Rule struct {
+	RuleID    string `json:"rule,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	// Name is a glob such as "calico-*"
+	Name string `json:"name,omitempty"`
+	// Selector is a label selector such as "app=ci-runner,tier!=prod"
+	Selector      string `json:"selector,omitempty"`
+	Justification string `json:"justification"`
+	// Expires is an optional YYYY-MM-DD date after which the rule stops applying
+	Expires string `json:"expires,omitempty"`
+
+	selector labels.Selector
+	expires  time.Time
+}
+
+// File is a parsed suppression file
+type File struct {
+	Suppressions []Rule `json:"suppressions"`
+}
+
+// Suppressed is a finding hidden by a suppression rule
+type Suppressed struct {
+	findings.Finding
+	Justification string `json:"justification"`
+	Expires       string `json:"expires,omitempty"`
+}
+
+// Load reads and validates a suppression file
+func Load(file string) (*File, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", file, err)
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return f, nil
+}
+
+// Parse decodes and validates suppression YAML
+func Parse(data []byte) (*File, error) {
+	var f File
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for i := range f.Suppressions {
+		if err := f.Suppressions[i].compile(); err != nil {
+			errs = append(errs, fmt.Errorf("suppression %d: %w", i+1, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return &f, nil
+}
+
+// compile validates the rule and parses its selector and expiry
+func (r *Rule) compile() error {
+	if strings.TrimSpace(r.Justification) == "" {
+		return fmt.Errorf("justification is required")
+	}
+	if r.RuleID == "" && r.Namespace == "" && r.Kind == "" && r.Name == "" && r.Selector == "" {
+		return fmt.Errorf("at least one of rule, namespace, kind, name or selector is required")
+	}
+	if r.Name != "" {
+		if _, err := path.Match(r.Name, ""); err != nil {
+			return fmt.Errorf("invalid name glob %q: %w", r.Name, err)
+		}
+	}
+	if r.Selector != "" {
+		selector, err := labels.Parse(r.Selector)
+		if err != nil {
+			return fmt.Errorf("invalid selector %q: %w", r.Selector, err)
+		}
+		r.selector = selector
+	}
+	if r.Expires != "" {
+		expires, err := time.Parse(dateLayout, r.Expires)
+		if err != nil {
+			return fmt.Errorf("invalid expires %q (expected YYYY-MM-DD)", r.Expires)
+		}
+		r.expires = expires
+	}
+	return nil
+}
+
+// Expired reports whether the rule no longer applies at now. A rule is
+// valid through the whole of its expiry date.
+func (r Rule) Expired(now time.Time) bool {
+	return !r.expires.IsZero() && !now.Before(r.expires.AddDate(0, 0, 1))
+}
+
+// Matches reports whether the rule covers the finding, ignoring expiry
+func (r Rule) Matches(f findings.Finding) bool {
+	if r.RuleID != "" && !strings.EqualFold(r.RuleID, f.RuleID) {
+		return false
+	}
+	if r.Namespace != "" && r.Namespace != f.Resource.Namespace {
+		return false
+	}
+	if r.Kind != "" && !strings.EqualFold(r.Kind, f.Resource.Kind) {
+		return false
+	}
+	if r.Name != "" {
+		if ok, _ := path.Match(r.Name, f.Resource.Name); !ok {
+			return false
+		}
+	}
+	if r.selector != nil && !r.selector.Matches(labels.Set(f.Resource.Labels)) {
+		return false
+	}
+	return true
+}
+
+// Apply splits findings into those still reported and those suppressed by an
+// unexpired rule. A nil File suppresses nothing.
+func (f *File) Apply(list []findings.Finding, now time.Time) (kept []findings.Finding, suppressed []Suppressed) {
+	if f == nil {
+		return list, nil
+	}
+	for _, finding := range list {
+		rule, ok := f.match(finding, now)
+		if !ok {
+			kept = append(kept, finding)
+			continue
+		}
+		suppressed = append(suppressed, Suppressed{
+			Finding:       finding,
+			Justification: rule.Justification,
+			Expires:       rule.Expires,
+		})
+	}
+	return kept, suppressed
+}
+
+// match returns the first unexpired rule covering the finding
+func (f *File) match(finding findings.Finding, now time.Time) (Rule, bool) {
+	for _, rule := range f.Suppressions {
+		if !rule.Expired(now) && rule.Matches(finding) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Expired lists the rules whose expiry date has passed
+func (f *File) Expired(now time.Time) []Rule {
+	if f == nil {
+		return nil
+	}
+	var out []Rule
+	for _, rule := range f.Suppressions {
+		if rule.Expired(now) {
+			out = append(out, rule)
+		}
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package suppress
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kspm/pkg/findings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ignoreYAML = `
+suppressions:
+  - rule: POD-HOST-NETWORK
+    namespace: kube-system
+    name: "calico-*"
+    justification: CNI requires host networking
+  - rule: POD-SENSITIVE-HOSTPATH
+    selector: app=ci-runner
+    justification: CI runners build images through docker.sock
+    expires: 2026-06-30
+`
+
+func finding(rule, namespace, name string, labels map[string]string) findings.Finding {
+	return findings.Finding{
+		RuleID:   rule,
+		Severity: findings.SeverityHigh,
+		Resource: findings.Resource{Kind: "Pod", Namespace: namespace, Name: name, Labels: labels},
+	}
+}
+
+func TestApply(t *testing.T) {
+	file, err := Parse([]byte(ignoreYAML))
+	require.NoError(t, err)
+
+	list := []findings.Finding{
+		finding("POD-HOST-NETWORK", "kube-system", "calico-node-x7k2p", nil),
+		finding("POD-HOST-NETWORK", "default", "calico-node-x7k2p", nil),
+		finding("POD-SENSITIVE-HOSTPATH", "ci", "runner-0", map[string]string{"app": "ci-runner"}),
+		finding("POD-SENSITIVE-HOSTPATH", "ci", "builder-0", map[string]string{"app": "builder"}),
+	}
+
+	kept, suppressed := file.Apply(list, time.Date(2026, 6, 30, 23, 0, 0, 0, time.UTC))
+	require.Len(t, suppressed, 2)
+	assert.Equal(t, "calico-node-x7k2p", suppressed[0].Resource.Name)
+	assert.Equal(t, "CNI requires host networking", suppressed[0].Justification)
+	assert.Equal(t, "runner-0", suppressed[1].Resource.Name)
+	assert.Equal(t, "2026-06-30", suppressed[1].Expires)
+	require.Len(t, kept, 2)
+	assert.Equal(t, "default", kept[0].Resource.Namespace)
+	assert.Equal(t, "builder-0", kept[1].Resource.Name)
+}
+
+func TestApplyExpired(t *testing.T) {
+	file, err := Parse([]byte(ignoreYAML))
+	require.NoError(t, err)
+
+	now := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	list := []findings.Finding{finding("POD-SENSITIVE-HOSTPATH", "ci", "runner-0", map[string]string{"app": "ci-runner"})}
+
+	kept, suppressed := file.Apply(list, now)
+	assert.Len(t, kept, 1)
+	assert.Empty(t, suppressed)
+
+	expired := file.Expired(now)
+	require.Len(t, expired, 1)
+	assert.Equal(t, "POD-SENSITIVE-HOSTPATH", expired[0].RuleID)
+}
+
+func TestApplyNilFile(t *testing.T) {
+	var file *File
+	list := []findings.Finding{finding("POD-PRIVILEGED", "default", "web", nil)}
+
+	kept, suppressed := file.Apply(list, time.Now())
+	assert.Equal(t, list, kept)
+	assert.Empty(t, suppressed)
+}
+
+func TestParseValidation(t *testing.T) {
+	tests := map[string]string{
+		"missing justification": "suppressions:\n- rule: POD-PRIVILEGED\n",
+		"no matcher":            "suppressions:\n- justification: everything\n",
+		"bad selector":          "suppressions:\n- selector: 'app in (('\n  justification: x\n",
+		"bad expiry":            "suppressions:\n- rule: POD-PRIVILEGED\n  justification: x\n  expires: next week\n",
+		"unknown field":         "suppressions:\n- rule: POD-PRIVILEGED\n  justification: x\n  reason: typo\n",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	require.NoError(t, os.WriteFile(path, []byte(ignoreYAML), 0o600))
+
+	file, err := Load(path)
+	require.NoError(t, err)
+	assert.Len(t, file.Suppressions, 2)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}